@@ -1,43 +1,72 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
-	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/rs/zerolog"
 
+	migrations "pastebin/db"
 	"pastebin/internal/auth"
+	"pastebin/internal/blobstore"
+	"pastebin/internal/codec"
+	"pastebin/internal/config"
 	"pastebin/internal/database"
+	"pastebin/internal/envelope"
 	"pastebin/internal/handlers"
+	"pastebin/internal/health"
+	"pastebin/internal/logging"
+	"pastebin/internal/markdown"
+	"pastebin/internal/metrics"
+	"pastebin/internal/origin"
+	"pastebin/internal/ratelimit"
 	"pastebin/internal/repositories"
+	"pastebin/internal/secretscan"
 	"pastebin/internal/services"
+	"pastebin/internal/slug"
+	"pastebin/internal/tracing"
+	"pastebin/internal/viewer"
+	"pastebin/internal/worker"
 )
 
+// ErrDrainTimeout is returned by Run when in-flight requests or workers did not
+// finish within the configured drain timeout.
+var ErrDrainTimeout = errors.New("shutdown drain timed out")
+
 type App struct {
-	server   *echo.Echo
-	logger   zerolog.Logger
-	addr     string
-	db       *pgxpool.Pool
-	handlers *handlers.Handlers
+	cfg             *config.Config
+	server          *echo.Echo
+	metricsServer   *echo.Echo
+	logger          zerolog.Logger
+	db              *pgxpool.Pool
+	handlers        *handlers.Handlers
+	workers         *worker.Group
+	readiness       *health.Readiness
+	shutdownTracing func(context.Context) error
 }
 
 // New initializes the entire application graph: logger, database connections,
 // repositories, services, handlers, and the Echo server. Everything outside of
 // wiring lives in their respective packages.
-func New() (*App, error) {
-	logger := initLogger()
+func New(cfg *config.Config) (*App, error) {
+	logger := initLogger(cfg)
+	logger.Debug().Msgf("effective configuration:\n%s", cfg)
+
+	jwtMgr := auth.NewJWTManager(cfg.Auth.JWTSecret.Value())
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		return nil, fmt.Errorf("JWT_SECRET env var is required")
+	shutdownTracing, err := tracing.Init(context.Background(), &cfg.Tracing)
+	if err != nil {
+		return nil, fmt.Errorf("init tracing: %w", err)
 	}
-	jwtMgr := auth.NewJWTManager(jwtSecret)
 
-	db, err := database.InitDB()
+	db, err := database.InitDB(&cfg.Database)
 	if err != nil {
 		logger.Error().Err(err).Msg("failed to initialize database")
 		return nil, fmt.Errorf("init db: %w", err)
@@ -45,79 +74,270 @@ func New() (*App, error) {
 
 	authRepo := repositories.NewAuthRepository(db)
 	userRepo := repositories.NewUserRepository(db)
-	pasteRepo := repositories.NewPasteRepository(db)
+	contentCodec, err := codec.Parse(cfg.Paste.Compression.Codec)
+	if err != nil {
+		return nil, fmt.Errorf("paste compression: %w", err)
+	}
+	compressor := codec.NewCompressor(contentCodec, cfg.Paste.Compression.Threshold.Int64())
+	blobStore, err := blobstore.Open(&cfg.Blob, db)
+	if err != nil {
+		return nil, fmt.Errorf("open blob store: %w", err)
+	}
+	keyring, err := envelope.Load(&cfg.Encryption)
+	if err != nil {
+		return nil, fmt.Errorf("load master keys: %w", err)
+	}
+	if keyring != nil {
+		logger.Info().Str("key_id", keyring.CurrentID()).Msg("paste content is encrypted at rest")
+	} else if cfg.App.Env == "production" {
+		logger.Warn().Msg("no master key configured, paste content is stored unencrypted")
+	}
+	origins, err := origin.NewResolver(cfg.Paste.BaseURL, cfg.Paste.AllowedOrigins, cfg.Server.TrustProxy)
+	if err != nil {
+		return nil, fmt.Errorf("paste origins: %w", err)
+	}
+	slugs, err := slug.NewGenerator(cfg.Paste.Slug.Alphabet, cfg.Paste.Slug.Length, cfg.Paste.Slug.Reserved)
+	if err != nil {
+		return nil, fmt.Errorf("paste slugs: %w", err)
+	}
+	blobRepo := repositories.NewBlobRepository(db, blobStore, compressor, keyring)
+	pasteRepo := repositories.NewPasteRepository(db, cfg.Quota.Storage.Int64(), compressor, blobRepo, keyring, slugs)
 	analyticsRepo := repositories.NewAnalyticsRepository(db)
 	profileRepo := repositories.NewProfileRepository(db)
+	usageRepo := repositories.NewUsageRepository(db)
+	secretRuleRepo := repositories.NewSecretRuleRepository(db)
+	commentRepo := repositories.NewCommentRepository(db)
+
+	// "off" keeps the rules manageable but scans nothing
+	secretScanOn := cfg.SecretScan.Policy != "off"
+	secretPolicy := secretscan.ActionWarn
+	if secretScanOn {
+		if secretPolicy, err = secretscan.ParseAction(cfg.SecretScan.Policy); err != nil {
+			return nil, fmt.Errorf("secret scan policy: %w", err)
+		}
+	}
+	scanner := secretscan.New(secretPolicy)
+	var pasteScanner *secretscan.Scanner
+	if secretScanOn {
+		pasteScanner = scanner
+	}
 
-	authSvc := services.NewAuthService(authRepo, userRepo, jwtMgr, logger)
-	pasteSvc := services.NewPasteService(pasteRepo, logger)
+	authSvc := services.NewAuthService(authRepo, userRepo, jwtMgr, cfg.Auth.TokenTTL, logger)
+	pasteSvc := services.NewPasteService(pasteRepo, usageRepo, cfg.Paste.MaxSize.Int64(), services.DailyQuota{
+		Pastes: cfg.Quota.DailyPastes,
+		Bytes:  cfg.Quota.DailyBytes.Int64(),
+	}, slugs, pasteScanner, logger)
 	analyticsSvc := services.NewAnalyticsService(analyticsRepo, logger)
 
-	profileSvc := services.NewProfileService(profileRepo, logger)
+	profileSvc := services.NewProfileService(profileRepo, cfg.Quota.Storage.Int64(), logger)
+	adminSvc := services.NewAdminService(userRepo, cfg.Quota.Storage.Int64(), logger)
+	secretRuleSvc := services.NewSecretRuleService(secretRuleRepo, scanner, cfg.SecretScan.RefreshInterval, logger)
+	commentSvc := services.NewCommentService(commentRepo, pasteRepo, logger)
 
 	authHandler := handlers.NewAuthHandler(authSvc, logger)
-	pasteHandler := handlers.NewPasteHandler(pasteSvc, logger)
+	pasteViewer, err := viewer.New()
+	if err != nil {
+		return nil, fmt.Errorf("paste viewer: %w", err)
+	}
+	pasteHandler := handlers.NewPasteHandler(pasteSvc, pasteViewer, markdown.New(), logger)
 	analyticsHandler := handlers.NewAnalyticsHandler(analyticsSvc, logger)
 	profileHandler := handlers.NewProfileHandler(profileSvc, &logger)
-	handlerSet := handlers.NewHandlers(authHandler, pasteHandler, analyticsHandler, profileHandler)
+	adminHandler := handlers.NewAdminHandler(adminSvc, secretRuleSvc, logger)
+	commentHandler := handlers.NewCommentHandler(commentSvc, logger)
+
+	readiness := health.NewReadiness()
+	workers := worker.NewGroup(logger)
+	workers.Register("blob-gc", services.NewBlobGC(blobRepo, cfg.Blob.GCInterval, cfg.Blob.GCGrace, logger).Run)
+	workers.Register("expired-paste-purger", services.NewExpiredPastePurger(pasteRepo, logger).Run)
+	workers.Register("hourly-views-pruner", services.NewHourlyViewsPruner(analyticsRepo, logger).Run)
+	if secretScanOn {
+		workers.Register("secret-rules-refresh", secretRuleSvc.Run)
+	}
+	schemaVersion, err := migrations.LatestVersion()
+	if err != nil {
+		return nil, fmt.Errorf("resolve schema version: %w", err)
+	}
+	checker := health.NewChecker(db, readiness, workers, schemaVersion, cfg.Health.CheckTimeout)
+	healthHandler := handlers.NewHealthHandler(checker)
+
+	handlerSet := handlers.NewHandlers(authHandler, pasteHandler, analyticsHandler, profileHandler, healthHandler, adminHandler, commentHandler)
 
 	e := echo.New()
 	e.HideBanner = true
 	e.HidePort = true
+	e.Server.ReadTimeout = cfg.Server.ReadTimeout
+	e.Server.WriteTimeout = cfg.Server.WriteTimeout
+	e.Server.IdleTimeout = cfg.Server.IdleTimeout
+	if cfg.Server.TrustProxy {
+		e.IPExtractor = echo.ExtractIPFromXFFHeader()
+	} else {
+		e.IPExtractor = echo.ExtractIPDirect()
+	}
 	e.Use(middleware.Recover())
-	e.Use(middleware.Logger())
+	e.Use(tracing.Middleware())
+	if cfg.Metrics.Enabled {
+		e.Use(metrics.Middleware())
+	}
+	e.Use(logging.Middleware(logger))
+	e.Use(origin.Middleware(origins))
+	e.Use(handlers.BodyLimit(cfg.Server.BodyLimit.Int64()))
+
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		limiter = initRateLimiter(cfg, db, logger)
+		workers.Register("ratelimit-cleanup", limiter.Run)
+	}
 
 	authMiddleware := auth.AuthMiddleware(jwtMgr)
-	handlerSet.RegisterRoutes(e, authMiddleware)
+	adminMiddleware := auth.RequireAdmin(adminSvc.IsAdmin)
+	handlerSet.RegisterRoutes(e, authMiddleware, adminMiddleware, limiter)
 
-	addr := resolveAddr()
+	var metricsServer *echo.Echo
+	if cfg.Metrics.Enabled {
+		if err := metrics.RegisterPool(db); err != nil {
+			return nil, fmt.Errorf("register pool metrics: %w", err)
+		}
+		metricsServer = initMetricsServer(e, &cfg.Metrics)
+	}
 
 	return &App{
-		server:   e,
-		logger:   logger,
-		addr:     addr,
-		db:       db,
-		handlers: handlerSet,
+		cfg:             cfg,
+		server:          e,
+		metricsServer:   metricsServer,
+		logger:          logger,
+		db:              db,
+		handlers:        handlerSet,
+		workers:         workers,
+		readiness:       readiness,
+		shutdownTracing: shutdownTracing,
 	}, nil
 }
 
-// Run starts the HTTP server and blocks until it exits.
-func (a *App) Run() error {
-	defer a.db.Close()
-	a.logger.Info().Str("addr", a.addr).Msg("starting pastebin api")
-	return a.server.Start(a.addr)
+// Run starts the HTTP servers and background workers and blocks until ctx is
+// cancelled (normally by SIGINT/SIGTERM) or a server fails, then shuts down
+// gracefully. A nil return means every component stopped cleanly.
+func (a *App) Run(ctx context.Context) error {
+	serverErr := make(chan error, 2)
+	if a.metricsServer != nil {
+		go func() {
+			a.logger.Info().Str("addr", a.cfg.Metrics.Addr).Msg("starting metrics server")
+			if err := a.metricsServer.Start(a.cfg.Metrics.Addr); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				serverErr <- fmt.Errorf("metrics server: %w", err)
+			}
+		}()
+	}
+	go func() {
+		a.logger.Info().Str("addr", a.cfg.Server.Addr).Msg("starting pastebin api")
+		if err := a.server.Start(a.cfg.Server.Addr); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- fmt.Errorf("api server: %w", err)
+		}
+	}()
+
+	a.workers.Start(context.Background())
+	a.readiness.SetReady(true)
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		a.logger.Info().Msg("shutdown signal received")
+	case runErr = <-serverErr:
+		a.logger.Error().Err(runErr).Msg("server failed, shutting down")
+	}
+	return errors.Join(runErr, a.shutdown())
 }
 
-func resolveAddr() string {
-	addr := os.Getenv("PORT")
-	if addr == "" {
-		return ":8080"
+// shutdown flips readiness, drains HTTP traffic, stops workers, flushes traces
+// and closes the database pool, in that order.
+func (a *App) shutdown() error {
+	a.readiness.SetReady(false)
+	if grace := a.cfg.Shutdown.ReadinessGrace; grace > 0 {
+		a.logger.Info().Dur("grace", grace).Msg("not ready, waiting before draining")
+		time.Sleep(grace)
+	}
+
+	a.logger.Info().Dur("timeout", a.cfg.Shutdown.DrainTimeout).Msg("draining in-flight requests")
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.Shutdown.DrainTimeout)
+	defer cancel()
+
+	var errs []error
+	if err := a.server.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("drain api server: %w", err))
+		_ = a.server.Close()
+	}
+	if a.metricsServer != nil {
+		if err := a.metricsServer.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("drain metrics server: %w", err))
+			_ = a.metricsServer.Close()
+		}
+	}
+	if err := a.workers.Stop(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("stop workers: %w", err))
 	}
-	if !strings.HasPrefix(addr, ":") {
-		return ":" + addr
+	if err := a.shutdownTracing(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("flush traces: %w", err))
 	}
-	return addr
+	a.db.Close()
+
+	err := errors.Join(errs...)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = errors.Join(ErrDrainTimeout, err)
+	}
+	if err != nil {
+		a.logger.Error().Err(err).Msg("shutdown finished with errors")
+		return err
+	}
+	a.logger.Info().Msg("shutdown complete")
+	return nil
 }
 
-func initLogger() zerolog.Logger {
-	env := os.Getenv("APP_ENV")
-	if env == "" {
-		env = "production"
+func initRateLimiter(cfg *config.Config, db *pgxpool.Pool, logger zerolog.Logger) *ratelimit.Limiter {
+	var store ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimit.Store == "postgres" {
+		store = ratelimit.NewPostgresStore(db)
+	}
+	rule := func(r config.RateLimitRule) ratelimit.Rule {
+		return ratelimit.Rule{PerMinute: r.PerMinute, Burst: r.Burst}
 	}
+	return ratelimit.NewLimiter(store, map[ratelimit.Group]ratelimit.Rule{
+		ratelimit.GroupPublicRead:    rule(cfg.RateLimit.PublicRead),
+		ratelimit.GroupAuth:          rule(cfg.RateLimit.Auth),
+		ratelimit.GroupRead:          rule(cfg.RateLimit.Read),
+		ratelimit.GroupWrite:         rule(cfg.RateLimit.Write),
+		ratelimit.GroupPasswordCheck: rule(cfg.RateLimit.PasswordCheck),
+	}, logger)
+}
 
-	if env == "development" {
-		return zerolog.New(zerolog.ConsoleWriter{Out: os.Stdout}).
-			Level(zerolog.DebugLevel).
-			With().
-			Timestamp().
-			Str("env", env).
-			Logger()
+// initMetricsServer mounts /metrics on the API server when metrics are exposed
+// there. Otherwise it returns a dedicated server for Run to start.
+func initMetricsServer(api *echo.Echo, cfg *config.MetricsConfig) *echo.Echo {
+	if cfg.ExposeOnAPI {
+		api.GET("/metrics", echo.WrapHandler(metrics.Handler()))
+		return nil
 	}
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	e.GET("/metrics", echo.WrapHandler(metrics.Handler()))
+	return e
+}
+
+func initLogger(cfg *config.Config) zerolog.Logger {
+	// Validate has already checked the level.
+	level, _ := zerolog.ParseLevel(cfg.Logger.Level)
 
-	return zerolog.New(os.Stdout).
-		Level(zerolog.InfoLevel).
+	var logger zerolog.Logger
+	if cfg.App.Env == "development" {
+		logger = zerolog.New(zerolog.ConsoleWriter{Out: os.Stdout})
+	} else {
+		logger = zerolog.New(os.Stdout)
+	}
+	logger = logger.
+		Level(level).
+		Hook(tracing.LogHook{}).
 		With().
 		Timestamp().
-		Str("env", env).
+		Str("env", cfg.App.Env).
 		Logger()
+	logging.SetDefault(logger)
+	return logger
 }
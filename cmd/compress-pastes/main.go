@@ -0,0 +1,113 @@
+// Command compress-pastes rewrites existing inline pastes with the configured
+// compression, in batches, so rows created before compression was enabled
+// shrink too. Pastes already moved to the blob store are skipped. It is safe
+// to run against a live database: rows being written concurrently are skipped
+// and picked up on the next run.
+//
+// Usage:
+//
+//	compress-pastes [--codec zstd] [--threshold 4KiB] [--batch-size 500] [--decompress]
+//
+// The database is taken from DATABASE_URL; codec and threshold default to
+// PASTE_COMPRESSION_CODEC and PASTE_COMPRESSION_THRESHOLD. Encrypted content
+// needs the master keys in ENCRYPTION_MASTER_KEY or ENCRYPTION_KEY_FILE.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"pastebin/internal/codec"
+	"pastebin/internal/config"
+	"pastebin/internal/database"
+	"pastebin/internal/envelope"
+	"pastebin/internal/repositories"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	_ = godotenv.Load()
+
+	defaults := config.Default()
+	codecName := envOr("PASTE_COMPRESSION_CODEC", defaults.Paste.Compression.Codec)
+	threshold := defaults.Paste.Compression.Threshold
+	if v := os.Getenv("PASTE_COMPRESSION_THRESHOLD"); v != "" {
+		if err := threshold.UnmarshalText([]byte(v)); err != nil {
+			return fmt.Errorf("PASTE_COMPRESSION_THRESHOLD: %w", err)
+		}
+	}
+
+	fs := flag.NewFlagSet("compress-pastes", flag.ExitOnError)
+	fs.StringVar(&codecName, "codec", codecName, "codec to store content with: zstd, gzip or none")
+	fs.TextVar(&threshold, "threshold", threshold, "only compress content at least this large")
+	batchSize := fs.Int("batch-size", 500, "rows rewritten per transaction")
+	decompress := fs.Bool("decompress", false, "store every paste as plain text again (run before rolling back the migration)")
+	_ = fs.Parse(os.Args[1:])
+
+	contentCodec, err := codec.Parse(codecName)
+	if err != nil {
+		return err
+	}
+	if *batchSize <= 0 {
+		return fmt.Errorf("--batch-size must be positive")
+	}
+
+	dbCfg := defaults.Database
+	dbCfg.URL = config.Secret(os.Getenv("DATABASE_URL"))
+	if dbCfg.URL == "" {
+		return fmt.Errorf("DATABASE_URL is required")
+	}
+	db, err := database.InitDB(&dbCfg)
+	if err != nil {
+		return fmt.Errorf("connect to database: %w", err)
+	}
+	defer db.Close()
+
+	keyring, err := envelope.Load(&config.EncryptionConfig{
+		MasterKey: config.Secret(os.Getenv("ENCRYPTION_MASTER_KEY")),
+		KeyFile:   os.Getenv("ENCRYPTION_KEY_FILE"),
+	})
+	if err != nil {
+		return fmt.Errorf("load master keys: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	pasteRepo := repositories.NewPasteRepository(db, 0, codec.NewCompressor(contentCodec, threshold.Int64()), nil, keyring, nil)
+	after, total := uuid.Nil, 0
+	for {
+		last, changed, err := pasteRepo.RecompressBatch(ctx, after, *batchSize, *decompress)
+		if err != nil {
+			return fmt.Errorf("after %s: %w", after, err)
+		}
+		if last == uuid.Nil {
+			break
+		}
+		total += changed
+		log.Printf("rewrote %d pastes up to %s (%d so far)", changed, last, total)
+		after = last
+	}
+	log.Printf("done, rewrote %d pastes", total)
+	return nil
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
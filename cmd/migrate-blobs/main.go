@@ -0,0 +1,119 @@
+// Command migrate-blobs moves paste content still stored inline in the pastes
+// table into the content-addressed blob store, in batches. Identical content
+// ends up stored once. It is safe to run against a live database: rows being
+// written concurrently are skipped and picked up on the next run.
+//
+// Usage:
+//
+//	migrate-blobs [--batch-size 500]
+//
+// The database is taken from DATABASE_URL and the store from BLOB_STORE and
+// BLOB_DIR; new blobs are compressed according to PASTE_COMPRESSION_CODEC and
+// PASTE_COMPRESSION_THRESHOLD, and encrypted when master keys are given in
+// ENCRYPTION_MASTER_KEY or ENCRYPTION_KEY_FILE.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"pastebin/internal/blobstore"
+	"pastebin/internal/codec"
+	"pastebin/internal/config"
+	"pastebin/internal/database"
+	"pastebin/internal/envelope"
+	"pastebin/internal/repositories"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	_ = godotenv.Load()
+
+	defaults := config.Default()
+	blobCfg := defaults.Blob
+	blobCfg.Store = envOr("BLOB_STORE", blobCfg.Store)
+	blobCfg.Dir = envOr("BLOB_DIR", blobCfg.Dir)
+	contentCodec, err := codec.Parse(envOr("PASTE_COMPRESSION_CODEC", defaults.Paste.Compression.Codec))
+	if err != nil {
+		return err
+	}
+	threshold := defaults.Paste.Compression.Threshold
+	if v := os.Getenv("PASTE_COMPRESSION_THRESHOLD"); v != "" {
+		if err := threshold.UnmarshalText([]byte(v)); err != nil {
+			return fmt.Errorf("PASTE_COMPRESSION_THRESHOLD: %w", err)
+		}
+	}
+
+	fs := flag.NewFlagSet("migrate-blobs", flag.ExitOnError)
+	batchSize := fs.Int("batch-size", 500, "pastes moved per transaction")
+	_ = fs.Parse(os.Args[1:])
+	if *batchSize <= 0 {
+		return fmt.Errorf("--batch-size must be positive")
+	}
+
+	dbCfg := defaults.Database
+	dbCfg.URL = config.Secret(os.Getenv("DATABASE_URL"))
+	if dbCfg.URL == "" {
+		return fmt.Errorf("DATABASE_URL is required")
+	}
+	db, err := database.InitDB(&dbCfg)
+	if err != nil {
+		return fmt.Errorf("connect to database: %w", err)
+	}
+	defer db.Close()
+
+	store, err := blobstore.Open(&blobCfg, db)
+	if err != nil {
+		return fmt.Errorf("open blob store: %w", err)
+	}
+
+	keyring, err := envelope.Load(&config.EncryptionConfig{
+		MasterKey: config.Secret(os.Getenv("ENCRYPTION_MASTER_KEY")),
+		KeyFile:   os.Getenv("ENCRYPTION_KEY_FILE"),
+	})
+	if err != nil {
+		return fmt.Errorf("load master keys: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	compressor := codec.NewCompressor(contentCodec, threshold.Int64())
+	blobRepo := repositories.NewBlobRepository(db, store, compressor, keyring)
+	pasteRepo := repositories.NewPasteRepository(db, 0, compressor, blobRepo, keyring, nil)
+	after, total := uuid.Nil, 0
+	for {
+		last, moved, err := pasteRepo.MoveBatchToBlobs(ctx, after, *batchSize)
+		if err != nil {
+			return fmt.Errorf("after %s: %w", after, err)
+		}
+		if last == uuid.Nil {
+			break
+		}
+		total += moved
+		log.Printf("moved %d pastes up to %s (%d so far)", moved, last, total)
+		after = last
+	}
+	log.Printf("done, moved %d pastes", total)
+	return nil
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
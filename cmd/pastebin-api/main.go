@@ -22,15 +22,34 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"pastebin/app"
 	_ "pastebin/docs" // This is required for swagger
+	"pastebin/internal/config"
 
 	"github.com/joho/godotenv"
 )
 
+// Exit codes reported to the process supervisor.
+const (
+	exitOK           = 0
+	exitError        = 1
+	exitDrainTimeout = 2
+)
+
 func main() {
+	os.Exit(run())
+}
+
+func run() int {
 	// Try to load .env file from multiple possible locations
 	envPaths := []string{
 		".env",       // Current directory
@@ -52,12 +71,34 @@ func main() {
 		log.Printf("Warning: .env file not found in any of the expected locations, using system env vars")
 	}
 
-	application, appErr := app.New()
+	cfg, opts, err := config.Load(os.Args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		log.Printf("failed to load configuration: %v", err)
+		return exitError
+	}
+	if opts.PrintConfig {
+		fmt.Print(cfg)
+		return exitOK
+	}
+
+	application, appErr := app.New(cfg)
 	if appErr != nil {
-		log.Fatalf("failed to initialize app: %v", appErr)
+		log.Printf("failed to initialize app: %v", appErr)
+		return exitError
 	}
 
-	if err := application.Run(); err != nil {
-		log.Fatalf("server exited with error: %v", err)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := application.Run(ctx); err != nil {
+		log.Printf("server exited with error: %v", err)
+		if errors.Is(err, app.ErrDrainTimeout) {
+			return exitDrainTimeout
+		}
+		return exitError
 	}
+	return exitOK
 }
@@ -0,0 +1,166 @@
+// Command rotate-keys re-wraps the data keys of encrypted paste content under
+// the current master key, so an old master key can be retired. Content itself
+// is not rewritten. It is safe to run against a live database: rows being
+// written concurrently are skipped and picked up on the next run.
+//
+// To rotate, generate a key with --generate-key, list it first in
+// ENCRYPTION_MASTER_KEY or ENCRYPTION_KEY_FILE while keeping the old key after
+// it, restart the API, run rotate-keys, then remove the old key.
+//
+// Usage:
+//
+//	rotate-keys [--batch-size 500] [--encrypt-plaintext]
+//	rotate-keys --generate-key
+//
+// --encrypt-plaintext also encrypts content stored before a master key was
+// configured. The database is taken from DATABASE_URL, the master keys from
+// ENCRYPTION_MASTER_KEY and ENCRYPTION_KEY_FILE, and the blob store from
+// BLOB_STORE and BLOB_DIR.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"pastebin/internal/blobstore"
+	"pastebin/internal/codec"
+	"pastebin/internal/config"
+	"pastebin/internal/database"
+	"pastebin/internal/envelope"
+	"pastebin/internal/repositories"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	_ = godotenv.Load()
+
+	fs := flag.NewFlagSet("rotate-keys", flag.ExitOnError)
+	batchSize := fs.Int("batch-size", 500, "rows updated per transaction")
+	encryptPlaintext := fs.Bool("encrypt-plaintext", false, "also encrypt content stored before a master key was configured")
+	generateKey := fs.Bool("generate-key", false, "print a new random master key and exit")
+	_ = fs.Parse(os.Args[1:])
+
+	if *generateKey {
+		key, err := envelope.GenerateKey()
+		if err != nil {
+			return err
+		}
+		fmt.Println(key)
+		return nil
+	}
+	if *batchSize <= 0 {
+		return fmt.Errorf("--batch-size must be positive")
+	}
+
+	keyring, err := envelope.Load(&config.EncryptionConfig{
+		MasterKey: config.Secret(os.Getenv("ENCRYPTION_MASTER_KEY")),
+		KeyFile:   os.Getenv("ENCRYPTION_KEY_FILE"),
+	})
+	if err != nil {
+		return fmt.Errorf("load master keys: %w", err)
+	}
+	if keyring == nil {
+		return fmt.Errorf("ENCRYPTION_MASTER_KEY or ENCRYPTION_KEY_FILE is required")
+	}
+
+	defaults := config.Default()
+	blobCfg := defaults.Blob
+	blobCfg.Store = envOr("BLOB_STORE", blobCfg.Store)
+	blobCfg.Dir = envOr("BLOB_DIR", blobCfg.Dir)
+	contentCodec, err := codec.Parse(envOr("PASTE_COMPRESSION_CODEC", defaults.Paste.Compression.Codec))
+	if err != nil {
+		return err
+	}
+
+	dbCfg := defaults.Database
+	dbCfg.URL = config.Secret(os.Getenv("DATABASE_URL"))
+	if dbCfg.URL == "" {
+		return fmt.Errorf("DATABASE_URL is required")
+	}
+	db, err := database.InitDB(&dbCfg)
+	if err != nil {
+		return fmt.Errorf("connect to database: %w", err)
+	}
+	defer db.Close()
+
+	store, err := blobstore.Open(&blobCfg, db)
+	if err != nil {
+		return fmt.Errorf("open blob store: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	compressor := codec.NewCompressor(contentCodec, defaults.Paste.Compression.Threshold.Int64())
+	blobRepo := repositories.NewBlobRepository(db, store, compressor, keyring)
+	pasteRepo := repositories.NewPasteRepository(db, 0, compressor, blobRepo, keyring, nil)
+	log.Printf("rotating to master key %s", keyring.CurrentID())
+
+	if *encryptPlaintext {
+		after, total := uuid.Nil, 0
+		for {
+			last, encrypted, err := pasteRepo.EncryptBatch(ctx, after, *batchSize)
+			if err != nil {
+				return fmt.Errorf("encrypting after %s: %w", after, err)
+			}
+			if last == uuid.Nil {
+				break
+			}
+			total += encrypted
+			log.Printf("encrypted %d pastes up to %s (%d so far)", encrypted, last, total)
+			after = last
+		}
+		log.Printf("encrypted %d pastes", total)
+	}
+
+	after, total := uuid.Nil, 0
+	for {
+		last, rewrapped, err := pasteRepo.RewrapBatch(ctx, after, *batchSize)
+		if err != nil {
+			return fmt.Errorf("rewrapping pastes after %s: %w", after, err)
+		}
+		if last == uuid.Nil {
+			break
+		}
+		total += rewrapped
+		log.Printf("rewrapped %d paste keys up to %s (%d so far)", rewrapped, last, total)
+		after = last
+	}
+	log.Printf("rewrapped %d paste keys", total)
+
+	afterHash, total := "", 0
+	for {
+		last, rewrapped, err := blobRepo.RewrapBatch(ctx, afterHash, *batchSize)
+		if err != nil {
+			return fmt.Errorf("rewrapping blobs after %q: %w", afterHash, err)
+		}
+		if last == "" {
+			break
+		}
+		total += rewrapped
+		log.Printf("rewrapped %d blob keys up to %s (%d so far)", rewrapped, last, total)
+		afterHash = last
+	}
+	log.Printf("done, rewrapped %d blob keys", total)
+	return nil
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS pastes_analytics_hourly(
+paste_id UUID NOT NULL REFERENCES pastes(id) ON DELETE CASCADE,
+bucket TIMESTAMPTZ NOT NULL,
+views INTEGER NOT NULL DEFAULT 0,
+PRIMARY KEY (paste_id, bucket)
+);
+CREATE INDEX IF NOT EXISTS idx_pastes_analytics_hourly_bucket ON pastes_analytics_hourly(bucket);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS pastes_analytics_hourly;
+-- +goose StatementEnd
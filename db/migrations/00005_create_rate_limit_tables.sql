@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS rate_limit_buckets(
+key TEXT PRIMARY KEY,
+tokens DOUBLE PRECISION NOT NULL,
+allowed BOOLEAN NOT NULL DEFAULT true,
+updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_updated_at ON rate_limit_buckets(updated_at);
+
+CREATE TABLE IF NOT EXISTS user_daily_usage(
+user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+day DATE NOT NULL,
+pastes INTEGER NOT NULL DEFAULT 0,
+bytes BIGINT NOT NULL DEFAULT 0,
+PRIMARY KEY (user_id, day)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_daily_usage;
+DROP TABLE IF EXISTS rate_limit_buckets;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS storage_used_bytes BIGINT NOT NULL DEFAULT 0;
+-- NULL means the configured default quota applies.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS storage_quota_bytes BIGINT;
+
+UPDATE users u SET storage_used_bytes = s.bytes
+FROM (SELECT user_id, SUM(octet_length(content)) AS bytes FROM pastes GROUP BY user_id) s
+WHERE u.id = s.user_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN IF EXISTS storage_quota_bytes;
+ALTER TABLE users DROP COLUMN IF EXISTS storage_used_bytes;
+ALTER TABLE users DROP COLUMN IF EXISTS is_admin;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Compressed pastes keep an empty content and store their bytes in content_data;
+-- content_size is always the uncompressed size in bytes.
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS content_codec TEXT NOT NULL DEFAULT 'identity';
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS content_data BYTEA;
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS content_size BIGINT NOT NULL DEFAULT 0;
+UPDATE pastes SET content_size = octet_length(content);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Compressed rows cannot be restored in SQL; run compress-pastes --decompress first.
+ALTER TABLE pastes DROP COLUMN IF EXISTS content_size;
+ALTER TABLE pastes DROP COLUMN IF EXISTS content_data;
+ALTER TABLE pastes DROP COLUMN IF EXISTS content_codec;
+-- +goose StatementEnd
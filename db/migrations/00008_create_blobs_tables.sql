@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+-- blobs tracks content-addressed paste content by SHA-256 of the plain text.
+-- refs counts the pastes pointing at a blob; unreferenced blobs are removed by
+-- the garbage collector once unreferenced_at is older than the grace period.
+CREATE TABLE IF NOT EXISTS blobs(
+hash TEXT PRIMARY KEY,
+size BIGINT NOT NULL,
+codec TEXT NOT NULL DEFAULT 'identity',
+refs INTEGER NOT NULL DEFAULT 0,
+created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+unreferenced_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_blobs_unreferenced_at ON blobs(unreferenced_at) WHERE refs = 0;
+
+-- blob_data holds the bytes when the postgres blob store is used.
+CREATE TABLE IF NOT EXISTS blob_data(
+hash TEXT PRIMARY KEY,
+data BYTEA NOT NULL
+);
+
+-- Pastes with a content_hash keep an empty content column; older rows still
+-- store their content inline until moved with migrate-blobs.
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS content_hash TEXT REFERENCES blobs(hash);
+CREATE INDEX IF NOT EXISTS idx_pastes_content_hash ON pastes(content_hash);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Pastes stored as blobs lose their content; move them back inline first.
+ALTER TABLE pastes DROP COLUMN IF EXISTS content_hash;
+DROP TABLE IF EXISTS blob_data;
+DROP TABLE IF EXISTS blobs;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Encrypted pastes keep content empty and the sealed (optionally compressed)
+-- bytes in content_data. They never reference a blob. Password-protected
+-- pastes created before this migration stay in plaintext until their owner
+-- sets the password again, or edits the content giving the current password.
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS content_encrypted BOOLEAN NOT NULL DEFAULT false;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE pastes DROP COLUMN IF EXISTS content_encrypted;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Envelope-encrypted content keeps its bytes in content_data (or the blob
+-- store) encrypted under a per-item data key. The data key is stored wrapped
+-- by the master key whose fingerprint is in content_key_id / key_id. Rows
+-- without a key are unencrypted until rotate-keys --encrypt-plaintext runs.
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS content_key BYTEA;
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS content_key_id TEXT;
+ALTER TABLE blobs ADD COLUMN IF NOT EXISTS key BYTEA;
+ALTER TABLE blobs ADD COLUMN IF NOT EXISTS key_id TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Encrypted content cannot be read without its data key, so only roll back
+-- while no master key has been configured.
+ALTER TABLE blobs DROP COLUMN IF EXISTS key_id;
+ALTER TABLE blobs DROP COLUMN IF EXISTS key;
+ALTER TABLE pastes DROP COLUMN IF EXISTS content_key_id;
+ALTER TABLE pastes DROP COLUMN IF EXISTS content_key;
+-- +goose StatementEnd
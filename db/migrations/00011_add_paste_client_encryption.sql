@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- client_encryption holds the algorithm metadata of pastes encrypted by the
+-- client. Their content is base64 ciphertext the server cannot read; the key
+-- only ever lives in the fragment of the share URL.
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS client_encryption JSONB;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE pastes DROP COLUMN IF EXISTS client_encryption;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Slugs used to be recovered from the end of url and were not unique. Take
+-- them over into their own column; where two pastes ended up with the same
+-- slug, the older one keeps it and the others get their id as slug instead.
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS slug TEXT;
+UPDATE pastes SET slug = substring(url FROM '/p/([^/]+)$') WHERE slug IS NULL;
+UPDATE pastes SET slug = replace(id::text, '-', '')
+WHERE slug IS NULL OR id IN (
+    SELECT id FROM (
+        SELECT id, row_number() OVER (PARTITION BY slug ORDER BY created_at, id) AS n FROM pastes
+    ) ranked WHERE n > 1
+);
+UPDATE pastes SET url = regexp_replace(url, '/p/[^/]*$', '/p/' || slug) WHERE url NOT LIKE '%/p/' || slug;
+ALTER TABLE pastes ALTER COLUMN slug SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pastes_slug ON pastes(slug);
+
+-- Renamed slugs keep pointing at their paste. A slug is either current in
+-- pastes or kept here, never both.
+CREATE TABLE IF NOT EXISTS paste_slug_redirects(
+slug TEXT PRIMARY KEY,
+paste_id UUID NOT NULL REFERENCES pastes(id) ON DELETE CASCADE,
+created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_paste_slug_redirects_paste_id ON paste_slug_redirects(paste_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS paste_slug_redirects;
+DROP INDEX IF EXISTS idx_pastes_slug;
+ALTER TABLE pastes DROP COLUMN IF EXISTS slug;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Paste URLs are built per request from the slug, which 00012 extracted from
+-- these columns, and the origin the request came in on. Stored URLs went
+-- stale whenever BASE_URL changed.
+ALTER TABLE pastes DROP COLUMN IF EXISTS url;
+ALTER TABLE pastes_analytics DROP COLUMN IF EXISTS url;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- The origin is not known here, so the URLs restored are relative.
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS url TEXT;
+UPDATE pastes SET url = '/p/' || slug;
+ALTER TABLE pastes ALTER COLUMN url SET NOT NULL;
+ALTER TABLE pastes_analytics ADD COLUMN IF NOT EXISTS url TEXT;
+UPDATE pastes_analytics a SET url = p.url FROM pastes p WHERE p.id = a.paste_id;
+-- +goose StatementEnd
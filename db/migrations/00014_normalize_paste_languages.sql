@@ -0,0 +1,182 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Languages used to be free text. Map the names, aliases and extensions the
+-- language registry knows to its canonical IDs so filtering by language
+-- finds older pastes; values it does not know are left as they are.
+UPDATE pastes p SET language = m.id
+FROM (VALUES
+	('.bash', 'bash'),
+	('.c', 'c'),
+	('.cc', 'cpp'),
+	('.cfg', 'ini'),
+	('.cjs', 'javascript'),
+	('.cpp', 'cpp'),
+	('.cs', 'csharp'),
+	('.css', 'css'),
+	('.cts', 'typescript'),
+	('.cxx', 'cpp'),
+	('.diff', 'diff'),
+	('.ex', 'elixir'),
+	('.exs', 'elixir'),
+	('.go', 'go'),
+	('.h', 'c'),
+	('.hh', 'cpp'),
+	('.hpp', 'cpp'),
+	('.hs', 'haskell'),
+	('.htm', 'html'),
+	('.html', 'html'),
+	('.ini', 'ini'),
+	('.java', 'java'),
+	('.js', 'javascript'),
+	('.json', 'json'),
+	('.jsx', 'javascript'),
+	('.kt', 'kotlin'),
+	('.kts', 'kotlin'),
+	('.log', 'text'),
+	('.lua', 'lua'),
+	('.markdown', 'markdown'),
+	('.md', 'markdown'),
+	('.mjs', 'javascript'),
+	('.mk', 'makefile'),
+	('.mts', 'typescript'),
+	('.patch', 'diff'),
+	('.php', 'php'),
+	('.pl', 'perl'),
+	('.pm', 'perl'),
+	('.ps1', 'powershell'),
+	('.psm1', 'powershell'),
+	('.py', 'python'),
+	('.pyw', 'python'),
+	('.r', 'r'),
+	('.rb', 'ruby'),
+	('.rs', 'rust'),
+	('.sc', 'scala'),
+	('.scala', 'scala'),
+	('.sh', 'bash'),
+	('.sql', 'sql'),
+	('.svg', 'xml'),
+	('.swift', 'swift'),
+	('.toml', 'toml'),
+	('.ts', 'typescript'),
+	('.tsx', 'typescript'),
+	('.txt', 'text'),
+	('.xml', 'xml'),
+	('.xsd', 'xml'),
+	('.xsl', 'xml'),
+	('.yaml', 'yaml'),
+	('.yml', 'yaml'),
+	('.zsh', 'bash'),
+	('bash', 'bash'),
+	('c', 'c'),
+	('c#', 'csharp'),
+	('c++', 'cpp'),
+	('cc', 'cpp'),
+	('cfg', 'ini'),
+	('cjs', 'javascript'),
+	('containerfile', 'dockerfile'),
+	('cpp', 'cpp'),
+	('cs', 'csharp'),
+	('csharp', 'csharp'),
+	('css', 'css'),
+	('cts', 'typescript'),
+	('cxx', 'cpp'),
+	('diff', 'diff'),
+	('docker', 'dockerfile'),
+	('dockerfile', 'dockerfile'),
+	('dosini', 'ini'),
+	('elixir', 'elixir'),
+	('ex', 'elixir'),
+	('exs', 'elixir'),
+	('go', 'go'),
+	('golang', 'go'),
+	('h', 'c'),
+	('haskell', 'haskell'),
+	('hh', 'cpp'),
+	('hpp', 'cpp'),
+	('hs', 'haskell'),
+	('htm', 'html'),
+	('html', 'html'),
+	('ini', 'ini'),
+	('java', 'java'),
+	('javascript', 'javascript'),
+	('js', 'javascript'),
+	('json', 'json'),
+	('jsx', 'javascript'),
+	('kotlin', 'kotlin'),
+	('kt', 'kotlin'),
+	('kts', 'kotlin'),
+	('log', 'text'),
+	('lua', 'lua'),
+	('make', 'makefile'),
+	('makefile', 'makefile'),
+	('markdown', 'markdown'),
+	('md', 'markdown'),
+	('mjs', 'javascript'),
+	('mk', 'makefile'),
+	('mts', 'typescript'),
+	('mysql', 'sql'),
+	('nginx', 'nginx'),
+	('nginx configuration', 'nginx'),
+	('nginxconf', 'nginx'),
+	('node', 'javascript'),
+	('nodejs', 'javascript'),
+	('patch', 'diff'),
+	('perl', 'perl'),
+	('php', 'php'),
+	('pl', 'perl'),
+	('plain', 'text'),
+	('plain text', 'text'),
+	('plaintext', 'text'),
+	('pm', 'perl'),
+	('posh', 'powershell'),
+	('postgres', 'sql'),
+	('postgresql', 'sql'),
+	('powershell', 'powershell'),
+	('ps1', 'powershell'),
+	('psm1', 'powershell'),
+	('psql', 'sql'),
+	('pwsh', 'powershell'),
+	('py', 'python'),
+	('py3', 'python'),
+	('python', 'python'),
+	('python3', 'python'),
+	('pyw', 'python'),
+	('r', 'r'),
+	('rb', 'ruby'),
+	('rlang', 'r'),
+	('rs', 'rust'),
+	('ruby', 'ruby'),
+	('rust', 'rust'),
+	('sc', 'scala'),
+	('scala', 'scala'),
+	('sh', 'bash'),
+	('shell', 'bash'),
+	('shell-script', 'bash'),
+	('shellscript', 'bash'),
+	('sql', 'sql'),
+	('sqlite', 'sql'),
+	('svg', 'xml'),
+	('swift', 'swift'),
+	('text', 'text'),
+	('toml', 'toml'),
+	('ts', 'typescript'),
+	('tsx', 'typescript'),
+	('txt', 'text'),
+	('typescript', 'typescript'),
+	('udiff', 'diff'),
+	('xhtml', 'html'),
+	('xml', 'xml'),
+	('xsd', 'xml'),
+	('xsl', 'xml'),
+	('yaml', 'yaml'),
+	('yml', 'yaml'),
+	('zsh', 'bash')
+) AS m(name, id)
+WHERE lower(btrim(p.language)) = m.name AND p.language <> m.id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- The original spellings are not kept, so normalized languages stay as they are.
+SELECT 1;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Secret scanning rules managed by admins. A row with the ID of a built-in
+-- rule overrides it; an empty pattern or description keeps the built-in one.
+-- An empty action falls back to the configured policy.
+CREATE TABLE IF NOT EXISTS secret_rules(
+id TEXT PRIMARY KEY,
+description TEXT NOT NULL DEFAULT '',
+pattern TEXT NOT NULL DEFAULT '',
+min_entropy DOUBLE PRECISION NOT NULL DEFAULT 0,
+action TEXT NOT NULL DEFAULT '',
+enabled BOOLEAN NOT NULL DEFAULT true,
+updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Rule IDs and finding fingerprints the owner accepted as false positives.
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS secret_allowlist TEXT[] NOT NULL DEFAULT '{}';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE pastes DROP COLUMN IF EXISTS secret_allowlist;
+DROP TABLE IF EXISTS secret_rules;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The paste a paste was forked from. Deleting the source keeps its forks but
+-- ends their lineage there.
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS forked_from UUID REFERENCES pastes(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_pastes_forked_from ON pastes(forked_from);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_pastes_forked_from;
+ALTER TABLE pastes DROP COLUMN IF EXISTS forked_from;
+-- +goose StatementEnd
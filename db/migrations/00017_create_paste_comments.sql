@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Threaded comments on pastes, optionally anchored to a range of lines.
+-- Deleted comments keep their row, without a body, so replies keep their
+-- place in the thread.
+CREATE TABLE IF NOT EXISTS paste_comments(
+id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+paste_id UUID NOT NULL REFERENCES pastes(id) ON DELETE CASCADE,
+user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+parent_id UUID REFERENCES paste_comments(id) ON DELETE CASCADE,
+body TEXT NOT NULL,
+line_start INTEGER,
+line_end INTEGER,
+created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+deleted_at TIMESTAMPTZ,
+CHECK (line_start IS NULL AND line_end IS NULL OR line_start >= 1 AND line_end >= line_start)
+);
+CREATE INDEX IF NOT EXISTS idx_paste_comments_paste_id ON paste_comments(paste_id, created_at);
+
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS comments_enabled BOOLEAN NOT NULL DEFAULT true;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE pastes DROP COLUMN IF EXISTS comments_enabled;
+DROP TABLE IF EXISTS paste_comments;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Pastes users starred to find again. Stars go with the paste or the user
+-- deleting them.
+CREATE TABLE IF NOT EXISTS paste_stars(
+user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+paste_id UUID NOT NULL REFERENCES pastes(id) ON DELETE CASCADE,
+created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+PRIMARY KEY (user_id, paste_id)
+);
+CREATE INDEX IF NOT EXISTS idx_paste_stars_paste_id ON paste_stars(paste_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS paste_stars;
+-- +goose StatementEnd
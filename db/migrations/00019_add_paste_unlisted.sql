@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Owners can keep a public paste out of the explore and trending feeds while
+-- it stays shareable by link. The partial index backs the explore feed.
+-- Pastes created before the feeds existed were shared expecting only their
+-- link to lead to them, so they start out unlisted; new pastes are listed
+-- unless their owner opts out.
+ALTER TABLE pastes ADD COLUMN IF NOT EXISTS unlisted BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE pastes ALTER COLUMN unlisted SET DEFAULT false;
+CREATE INDEX IF NOT EXISTS idx_pastes_listed_created_at ON pastes(created_at DESC) WHERE NOT is_private AND NOT unlisted AND password = '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_pastes_listed_created_at;
+ALTER TABLE pastes DROP COLUMN IF EXISTS unlisted;
+-- +goose StatementEnd
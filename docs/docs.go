@@ -24,56 +24,33 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/analytics": {
+        "/admin/secret-rules": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve all analytics with pagination",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "List the built-in and admin-defined rules pastes are scanned with, including disabled ones. Admin only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "analytics"
-                ],
-                "summary": "Get all analytics",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Sort order",
-                        "name": "order",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Limit number of results",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Offset for pagination",
-                        "name": "offset",
-                        "in": "query"
-                    }
+                    "admin"
                 ],
+                "summary": "List secret scanning rules",
                 "responses": {
                     "200": {
-                        "description": "List of analytics",
+                        "description": "Secret scanning rules",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Analytics"
+                                "$ref": "#/definitions/secretscan.Rule"
                             }
                         }
                     },
-                    "400": {
-                        "description": "Invalid parameters",
+                    "403": {
+                        "description": "Admin access required",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -82,7 +59,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Unable to get analytics",
+                        "description": "Unable to list secret rules",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -93,14 +70,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/analytics/paste": {
-            "get": {
+        "/admin/secret-rules/{id}": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve analytics for a specific paste",
+                "description": "Create the rule with this ID, or replace it. A built-in rule can be changed or disabled; an empty pattern or description keeps the built-in one. pattern is an RE2 regular expression whose named group \"secret\", when present, marks the secret within the match. The rule applies to new scans within the configured refresh interval. Admin only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -108,27 +85,45 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "analytics"
+                    "admin"
                 ],
-                "summary": "Get analytics by paste ID",
+                "summary": "Create or change a secret scanning rule",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Paste ID",
-                        "name": "pasteID",
-                        "in": "query",
+                        "description": "Rule ID, lower-case letters, digits and dashes",
+                        "name": "id",
+                        "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Rule",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SecretRuleInput"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Analytics data",
+                        "description": "Saved rule",
                         "schema": {
-                            "$ref": "#/definitions/models.Analytics"
+                            "$ref": "#/definitions/secretscan.Rule"
                         }
                     },
                     "400": {
-                        "description": "Invalid paste ID",
+                        "description": "Invalid rule ID, pattern or action",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Admin access required",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -137,7 +132,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Unable to get analytics",
+                        "description": "Unable to save secret rule",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -146,65 +141,51 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/analytics/user": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve analytics for a specific user with pagination",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Delete an admin-defined rule, or reset a changed built-in rule to its defaults. Admin only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "analytics"
+                    "admin"
                 ],
-                "summary": "Get analytics by user",
+                "summary": "Delete a secret scanning rule",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
-                        "name": "userID",
-                        "in": "query",
+                        "description": "Rule ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Sort order",
-                        "name": "order",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Limit number of results",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Offset for pagination",
-                        "name": "offset",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of analytics",
+                        "description": "Rule deleted or reset",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Analytics"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
                     },
-                    "400": {
-                        "description": "Invalid parameters",
+                    "403": {
+                        "description": "Admin access required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "No stored rule with this ID",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -213,7 +194,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Unable to get analytics",
+                        "description": "Unable to delete secret rule",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -224,14 +205,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/analytics/{id}": {
-            "get": {
+        "/admin/users/{id}/storage-quota": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve specific analytics entry by its ID",
+                "description": "Set a per-user storage quota in bytes. 0 removes the limit and null restores the configured default. Admin only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -239,27 +220,54 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "analytics"
+                    "admin"
                 ],
-                "summary": "Get analytics by ID",
+                "summary": "Override a user's storage quota",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Analytics ID",
+                        "description": "User ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Quota override",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.StorageQuotaInput"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Analytics data",
+                        "description": "Updated storage usage",
                         "schema": {
-                            "$ref": "#/definitions/models.Analytics"
+                            "$ref": "#/definitions/models.StorageUsage"
                         }
                     },
                     "400": {
-                        "description": "Invalid ID",
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Admin access required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -268,7 +276,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Unable to get analytics",
+                        "description": "Unable to set storage quota",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -279,14 +287,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/create-analytics": {
-            "post": {
+        "/analytics": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new analytics entry for a paste",
+                "description": "Retrieve all analytics with pagination",
                 "consumes": [
                     "application/json"
                 ],
@@ -296,30 +304,39 @@ const docTemplate = `{
                 "tags": [
                     "analytics"
                 ],
-                "summary": "Create analytics entry",
+                "summary": "Get all analytics",
                 "parameters": [
                     {
-                        "description": "Analytics data",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.AnalyticsInput"
-                        }
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit number of results",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset for pagination",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Analytics created successfully",
+                    "200": {
+                        "description": "List of analytics",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Analytics"
                             }
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Invalid parameters",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -328,7 +345,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Unable to create analytics",
+                        "description": "Unable to get analytics",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -339,9 +356,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/login": {
-            "post": {
-                "description": "Authenticate user and return JWT token",
+        "/analytics/paste": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve analytics for a specific paste",
                 "consumes": [
                     "application/json"
                 ],
@@ -349,29 +371,27 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "analytics"
                 ],
-                "summary": "Login user",
+                "summary": "Get analytics by paste ID",
                 "parameters": [
                     {
-                        "description": "User login credentials",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.LoginInput"
-                        }
+                        "type": "string",
+                        "description": "Paste ID",
+                        "name": "pasteID",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Login successful",
+                        "description": "Analytics data",
                         "schema": {
-                            "$ref": "#/definitions/models.LoginResponse"
+                            "$ref": "#/definitions/models.Analytics"
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Invalid paste ID",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -379,8 +399,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "500": {
+                        "description": "Unable to get analytics",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -391,14 +411,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/paste": {
-            "post": {
+        "/analytics/summary": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new paste with optional expiration",
+                "description": "Retrieve dashboard totals for the authenticated user's pastes: paste and view counts, 7/30 day views, top pastes, language breakdown, visibility split and pastes expiring in the next 24h",
                 "consumes": [
                     "application/json"
                 ],
@@ -406,29 +426,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "pastes"
+                    "analytics"
                 ],
-                "summary": "Create a new paste",
-                "parameters": [
-                    {
-                        "description": "Paste data",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
+                "summary": "Get analytics summary",
+                "responses": {
+                    "200": {
+                        "description": "Analytics summary",
                         "schema": {
-                            "$ref": "#/definitions/models.PasteInput"
+                            "$ref": "#/definitions/models.AnalyticsSummary"
                         }
                     },
-                    {
-                        "type": "string",
-                        "description": "Expiration duration (e.g., '24h', '7d')",
-                        "name": "expires_in",
-                        "in": "query"
-                    }
-                ],
-                "responses": {
-                    "201": {
-                        "description": "Paste created successfully",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -436,8 +445,75 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "400": {
-                        "description": "Invalid request",
+                    "500": {
+                        "description": "Unable to get analytics summary",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/analytics/user": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve analytics for a specific user with pagination",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Get analytics by user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit number of results",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset for pagination",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of analytics",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Analytics"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -446,7 +522,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Unable to create paste",
+                        "description": "Unable to get analytics",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -457,9 +533,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/paste/{id}": {
+        "/analytics/{id}": {
             "get": {
-                "description": "Retrieve a specific paste by its ID",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve specific analytics entry by its ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -467,13 +548,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "pastes"
+                    "analytics"
                 ],
-                "summary": "Get paste by ID",
+                "summary": "Get analytics by ID",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Paste ID",
+                        "description": "Analytics ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -481,13 +562,13 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Paste data",
+                        "description": "Analytics data",
                         "schema": {
-                            "$ref": "#/definitions/models.PasteOutput"
+                            "$ref": "#/definitions/models.Analytics"
                         }
                     },
                     "400": {
-                        "description": "Invalid paste ID",
+                        "description": "Invalid ID",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -496,7 +577,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Unable to get paste",
+                        "description": "Unable to get analytics",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -505,14 +586,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/comments/{id}": {
             "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update an existing paste by ID",
+                "description": "Replace the body of one of the caller's comments. Comments can't be edited once the paste owner disables comments, or by callers no longer allowed to view the paste.",
                 "consumes": [
                     "application/json"
                 ],
@@ -520,30 +603,42 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "pastes"
+                    "comments"
                 ],
-                "summary": "Update a paste",
+                "summary": "Edit a comment",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Paste ID",
+                        "description": "Comment ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Paste update data",
+                        "type": "string",
+                        "description": "Password for password-protected pastes",
+                        "name": "password",
+                        "in": "query"
+                    },
+                    {
+                        "description": "New body",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.PatchPaste"
+                            "$ref": "#/definitions/models.CommentUpdate"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Paste updated successfully",
+                        "description": "Updated comment",
+                        "schema": {
+                            "$ref": "#/definitions/models.Comment"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid comment ID or body",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -551,8 +646,17 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "400": {
-                        "description": "Invalid request",
+                    "403": {
+                        "description": "Not the author, or comments are disabled",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Comment not found, or its paste is not visible to the caller",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -561,7 +665,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Unable to update paste",
+                        "description": "Unable to update comment",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -577,29 +681,32 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete a specific paste by its ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Delete one of the caller's comments on a paste the caller is still allowed to view, or any comment on one of the caller's pastes. Replies to it are kept.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "pastes"
+                    "comments"
                 ],
-                "summary": "Delete paste by ID",
+                "summary": "Delete a comment",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Paste ID",
+                        "description": "Comment ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Password for password-protected pastes",
+                        "name": "password",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Paste deleted successfully",
+                        "description": "Comment deleted",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -608,7 +715,25 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Invalid paste ID",
+                        "description": "Invalid comment ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Neither the author nor the paste owner",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Comment not found, or its paste is not visible to the caller",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -617,7 +742,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Cannot delete paste",
+                        "description": "Unable to delete comment",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -628,14 +753,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/pastes": {
-            "get": {
+        "/create-analytics": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieve all pastes for the authenticated user",
+                "description": "Create a new analytics entry for a paste",
                 "consumes": [
                     "application/json"
                 ],
@@ -643,21 +768,41 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "pastes"
+                    "analytics"
+                ],
+                "summary": "Create analytics entry",
+                "parameters": [
+                    {
+                        "description": "Analytics data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AnalyticsInput"
+                        }
+                    }
                 ],
-                "summary": "Get all pastes for user",
                 "responses": {
-                    "200": {
-                        "description": "List of pastes",
+                    "201": {
+                        "description": "Analytics created successfully",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.PasteOutput"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Unable to create analytics",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -668,42 +813,49 @@ const docTemplate = `{
                 }
             }
         },
-        "/register": {
-            "post": {
-                "description": "Register a new user with email and password",
-                "consumes": [
-                    "application/json"
-                ],
+        "/explore": {
+            "get": {
+                "description": "List recent public pastes, newest first. Pastes with a password, private pastes and pastes their owner marked unlisted never appear. Content is not included.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "explore"
                 ],
-                "summary": "Register a new user",
+                "summary": "Explore public pastes",
                 "parameters": [
                     {
-                        "description": "User registration data",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.RegisterInput"
-                        }
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only pastes in these languages, as accepted by GET /languages",
+                        "name": "language",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of pastes to return (default: 10, max: 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of pastes to skip (default: 0)",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "User registered successfully",
+                    "200": {
+                        "description": "Paginated list of public pastes",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.PaginatedPastesResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Unknown language or invalid pagination parameters",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -712,7 +864,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Failed to register",
+                        "description": "Unable to list public pastes",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -722,93 +874,2206 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.Analytics": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "string"
-                },
-                "paste_id": {
-                    "type": "string"
-                },
-                "url": {
-                    "type": "string"
-                },
-                "views": {
-                    "type": "integer"
+        },
+        "/healthz": {
+            "get": {
+                "description": "Reports that the process is up and serving HTTP. It does not check dependencies.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "Alive",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
                 }
             }
         },
-        "models.AnalyticsInput": {
-            "type": "object",
-            "properties": {
+        "/languages": {
+            "get": {
+                "description": "List the languages pastes can be written in, with the aliases and file extensions accepted for each in the language field",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "List supported languages",
+                "responses": {
+                    "200": {
+                        "description": "Supported languages",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/language.Language"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/login": {
+            "post": {
+                "description": "Authenticate user and return JWT token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Login user",
+                "parameters": [
+                    {
+                        "description": "User login credentials",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.LoginInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Login successful",
+                        "schema": {
+                            "$ref": "#/definitions/models.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/p/{slug}": {
+            "get": {
+                "description": "Retrieve a public paste by its URL slug. Clients preferring text/html in Accept, such as browsers, get a syntax-highlighted page instead of JSON. render=html always returns a page with a Markdown paste rendered to sanitized HTML. Slugs a paste was renamed from redirect to its current slug.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/html"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Get public paste by slug",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Paste slug",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "html"
+                        ],
+                        "type": "string",
+                        "description": "html renders a Markdown paste as a page",
+                        "name": "render",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "rendered_html adds the sanitized HTML of a Markdown paste",
+                        "name": "include",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paste data",
+                        "schema": {
+                            "$ref": "#/definitions/models.PasteOutput"
+                        }
+                    },
+                    "301": {
+                        "description": "Redirect to the paste's current slug",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid slug or render mode, or paste is not Markdown",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Markdown too large to render",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Unable to get paste",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/p/{slug}/comments": {
+            "get": {
+                "description": "List the comment threads on the paste shared under slug, oldest first, with replies nested under their parent. Deleted comments are kept with an empty body only where they have replies. Anyone allowed to view the paste may read its comments, giving its password when it has one.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "List comments on a paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Paste slug",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Password for password-protected pastes",
+                        "name": "password",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Comment threads",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Comment"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found or not visible to the caller",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Unable to list comments",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a comment to the paste shared under slug, optionally replying to another comment on it or anchored to a range of its lines. line_end defaults to line_start. The caller must be allowed to view the paste, giving its password when it has one, and its owner must not have disabled comments.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Comment on a paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Paste slug",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Password for password-protected pastes",
+                        "name": "password",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Comment",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CommentInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created comment",
+                        "schema": {
+                            "$ref": "#/definitions/models.Comment"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid body, line range or parent",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Comments are disabled on this paste",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found or not visible to the caller",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Unable to create comment",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/p/{slug}/fork": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a paste owned by the caller with the content, language and title of the paste shared under slug, recording it in forked_from. The caller must be allowed to view the source, giving its password when it has one, and forks of private or password-protected pastes are private. A fork of a password-protected paste is protected by the same password. Client-encrypted pastes are forked as ciphertext. The fork counts against the caller's quotas and is scanned for secrets like any new paste.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Fork a paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Slug of the paste to fork",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Source password and fork options",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.ForkInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created fork",
+                        "schema": {
+                            "$ref": "#/definitions/models.PasteOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or slug",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found or not visible to the caller",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Slug already taken",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Storage quota exceeded",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Secrets found, listed in data",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit or daily quota exceeded",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Unable to fork paste",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/p/{slug}/star": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Star the paste shared under slug so it shows in GET /stars. The caller must be allowed to view the paste, giving its password when it has one. Starring a paste again changes nothing.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stars"
+                ],
+                "summary": "Star a paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Paste slug",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Password for password-protected pastes",
+                        "name": "password",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paste starred",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found or not visible to the caller",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Unable to star paste",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove the caller's star from the paste shared under slug. Succeeds when the paste was not starred.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stars"
+                ],
+                "summary": "Unstar a paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Paste slug",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Star removed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Unable to unstar paste",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/paste": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new paste with optional expiration. slug requests a custom slug instead of a generated one. With encryption set, content is base64 ciphertext encrypted by the client (see pkg/clientcrypt); it is stored as-is, never highlighted, and its language is ignored. language is normalized against GET /languages, so \"golang\" is stored as \"go\"; when omitted it is detected from the content and the title as a file name. Content is scanned for credentials and any found are listed in secret_findings. For public pastes the secret scan policy may instead make the paste private or reject it with 422; list a finding's fingerprint or rule ID in secret_allowlist to accept it as a false positive. redact masks emails, IP addresses, bearer tokens, JWTs, card numbers and matches of redact_patterns with placeholders such as <IP_1> before storing the content, and redactions counts what was masked by category.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Create a new paste",
+                "parameters": [
+                    {
+                        "description": "Paste data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PasteInput"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Expiration duration (e.g., '24h', '7d')",
+                        "name": "expires_in",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created paste with shareable URL",
+                        "schema": {
+                            "$ref": "#/definitions/models.PasteOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, invalid slug, unknown language, invalid secret allowlist or redact pattern, or malformed encrypted content",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Slug already taken",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Paste too large or storage quota exceeded",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Secrets found, listed in data",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit or daily quota exceeded",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Unable to create paste",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/paste/filter": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the caller's live pastes in the given languages and creation dates, sorted as asked. Content is not included; fetch a paste by ID or slug for it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Filter the caller's pastes",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only pastes in these languages",
+                        "name": "languages",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only pastes created at or after this time (RFC 3339)",
+                        "name": "date_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only pastes created at or before this time (RFC 3339)",
+                        "name": "date_to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created_at",
+                            "updated_at",
+                            "views",
+                            "stars",
+                            "title"
+                        ],
+                        "type": "string",
+                        "description": "Sort column (default: created_at)",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order (default: desc)",
+                        "name": "sort_order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Matching pastes",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.PasteOutput"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Unable to filter pastes",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/paste/{id}": {
+            "get": {
+                "description": "Retrieve a specific paste by its ID. Password required for password-protected pastes if user is not the owner. Owners reading an encrypted paste without the password get empty content with content_locked set.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Get paste by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Paste ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Password for password-protected pastes",
+                        "name": "password",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "rendered_html adds the sanitized HTML of a Markdown paste",
+                        "name": "include",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paste data",
+                        "schema": {
+                            "$ref": "#/definitions/models.PasteOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid paste ID, missing password or paste is not Markdown",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid password",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Markdown too large to render",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Unable to get paste",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update an existing paste by ID. Changing the password of a paste whose content is encrypted without sending new content needs current_password. An empty language asks for it to be detected from the content. The paste is scanned for credentials again when its content or secret_allowlist changes or it becomes public, as on creation. comments_enabled set to false stops new comments and edits; existing comments stay readable.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Update a paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Paste ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Paste update data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PatchPaste"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paste updated successfully, with any secrets found",
+                        "schema": {
+                            "$ref": "#/definitions/models.PasteUpdateOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, current password required, unknown language, invalid secret allowlist or malformed encrypted content",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Invalid current password",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Paste too large or storage quota exceeded",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Secrets found, listed in data",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Unable to update paste",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a specific paste by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Delete paste by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Paste ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paste deleted successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid paste ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Unable to delete paste",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/paste/{id}/forks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the forks of one of the caller's pastes, newest first. Private forks of other users are counted in the paste's forks but not listed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "List forks of a paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Paste ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of forks to return (default: 10, max: 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of forks to skip (default: 0)",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paginated list of forks",
+                        "schema": {
+                            "$ref": "#/definitions/models.PaginatedPastesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid paste ID or pagination parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Unable to list forks",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/paste/{id}/slug": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Give a paste a new slug. The old slug keeps working and redirects to the new one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Rename a paste's slug",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Paste ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New slug",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SlugInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "New slug and URL",
+                        "schema": {
+                            "$ref": "#/definitions/models.SlugOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid paste ID or slug",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Slug already taken",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Unable to rename slug",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/pastes": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve all pastes for the authenticated user with pagination. Content is not included; fetch a paste by ID or slug for it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Get all pastes for user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of pastes to return (default: 10, max: 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of pastes to skip (default: 0)",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paginated list of pastes",
+                        "schema": {
+                            "$ref": "#/definitions/models.PaginatedPastesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid pagination parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Unable to get pastes",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/raw/{slug}": {
+            "get": {
+                "description": "Retrieve raw text content of a public paste by its URL slug. Compressed pastes are sent with Content-Encoding when the client accepts it. Client-encrypted pastes are sent as application/octet-stream ciphertext with X-Encryption-Algorithm and X-Encryption-Nonce headers. Slugs a paste was renamed from redirect to its current slug.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/plain",
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Get raw paste content by slug",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Paste slug",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Send as an attachment named after the slug and language",
+                        "name": "download",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Raw paste content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "301": {
+                        "description": "Redirect to the paste's current slug",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid slug",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Unable to get paste",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Checks the database, the schema migration version and background workers. Returns 503 while shutting down or when a critical dependency fails.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "Ready",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    },
+                    "503": {
+                        "description": "Not ready",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    }
+                }
+            }
+        },
+        "/register": {
+            "post": {
+                "description": "Register a new user with email and password",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Register a new user",
+                "parameters": [
+                    {
+                        "description": "User registration data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RegisterInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "User registered successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to register",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/stars": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the pastes the caller starred, most recently starred first unless sort_by says otherwise. Pastes that expired, were deleted or were made private by their owner since are left out. Content is not included.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stars"
+                ],
+                "summary": "List starred pastes",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only pastes in these languages",
+                        "name": "languages",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only pastes created at or after this time (RFC 3339)",
+                        "name": "date_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only pastes created at or before this time (RFC 3339)",
+                        "name": "date_to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created_at",
+                            "updated_at",
+                            "views",
+                            "stars",
+                            "title"
+                        ],
+                        "type": "string",
+                        "description": "Sort column",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order (default: desc)",
+                        "name": "sort_order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of pastes to return (default: 10, max: 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of pastes to skip (default: 0)",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paginated list of starred pastes",
+                        "schema": {
+                            "$ref": "#/definitions/models.PaginatedPastesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter or pagination parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Unable to list stars",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/trending": {
+            "get": {
+                "description": "List the pastes of the explore feed viewed within the window, ranked by their views in it rather than their lifetime views. Views are counted in hourly buckets, the current hour included.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "explore"
+                ],
+                "summary": "Trending public pastes",
+                "parameters": [
+                    {
+                        "enum": [
+                            "24h",
+                            "7d"
+                        ],
+                        "type": "string",
+                        "description": "Period to rank views over (default: 24h)",
+                        "name": "window",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only pastes in these languages, as accepted by GET /languages",
+                        "name": "language",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of pastes to return (default: 10, max: 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of pastes to skip (default: 0)",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Trending pastes",
+                        "schema": {
+                            "$ref": "#/definitions/models.TrendingPastesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid window, unknown language or invalid pagination parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Unable to list trending pastes",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "health.CheckResult": {
+            "type": "object",
+            "properties": {
+                "current_version": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "expected_version": {
+                    "type": "integer"
+                },
+                "latency_ms": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "workers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/worker.Status"
+                    }
+                }
+            }
+        },
+        "health.Report": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/health.CheckResult"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "language.Language": {
+            "type": "object",
+            "properties": {
+                "aliases": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "extensions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string",
+                    "example": "go"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Go"
+                }
+            }
+        },
+        "models.Analytics": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "paste_id": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "description": "URL is built per request from Slug.",
+                    "type": "string"
+                },
+                "views": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.AnalyticsInput": {
+            "type": "object",
+            "properties": {
+                "paste_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AnalyticsSummary": {
+            "type": "object",
+            "properties": {
+                "expiring_next_24h": {
+                    "type": "integer"
+                },
+                "languages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LanguageBreakdown"
+                    }
+                },
+                "private_pastes": {
+                    "type": "integer"
+                },
+                "public_pastes": {
+                    "type": "integer"
+                },
+                "top_pastes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TopPaste"
+                    }
+                },
+                "total_pastes": {
+                    "type": "integer"
+                },
+                "total_views": {
+                    "type": "integer"
+                },
+                "views_last_30_days": {
+                    "type": "integer"
+                },
+                "views_last_7_days": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ClientEncryption": {
+            "type": "object",
+            "properties": {
+                "algorithm": {
+                    "type": "string",
+                    "example": "aes-256-gcm"
+                },
+                "nonce": {
+                    "description": "Nonce is base64 encoded.",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Comment": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "body": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted": {
+                    "description": "Deleted comments keep their place in the thread without a body.",
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "line_end": {
+                    "type": "integer",
+                    "example": 14
+                },
+                "line_start": {
+                    "description": "LineStart and LineEnd are the 1-based, inclusive lines the comment is\nabout.",
+                    "type": "integer",
+                    "example": 12
+                },
+                "parent_id": {
+                    "type": "string"
+                },
                 "paste_id": {
                     "type": "string"
                 },
+                "replies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Comment"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CommentInput": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string",
+                    "example": "This timeout is too short for the migration job."
+                },
+                "line_end": {
+                    "type": "integer",
+                    "example": 14
+                },
+                "line_start": {
+                    "description": "LineStart anchors the comment to a line, or with LineEnd to a range.",
+                    "type": "integer",
+                    "example": 12
+                },
+                "parent_id": {
+                    "description": "ParentID makes the comment a reply.",
+                    "type": "string"
+                }
+            }
+        },
+        "models.CommentUpdate": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ForkInput": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "description": "Password opens a password-protected source paste and protects the fork.",
+                    "type": "string"
+                },
+                "slug": {
+                    "description": "Slug requests a custom slug for the fork.",
+                    "type": "string",
+                    "example": "deploy-runbook-v2"
+                },
+                "title": {
+                    "description": "Title defaults to the source paste's title.",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ForkRef": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LanguageBreakdown": {
+            "type": "object",
+            "properties": {
+                "language": {
+                    "type": "string"
+                },
+                "pastes": {
+                    "type": "integer"
+                },
+                "views": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.LoginInput": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 6
+                }
+            }
+        },
+        "models.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
+                }
+            }
+        },
+        "models.PaginatedPastesResponse": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "pastes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PasteOutput"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PasteInput": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "encryption": {
+                    "description": "Encryption marks Content as base64 ciphertext produced by the client.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ClientEncryption"
+                        }
+                    ]
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "expires_in": {
+                    "type": "string"
+                },
+                "is_private": {
+                    "description": "IsPrivate keeps the paste to its owner. Pastes with a password are\nalways private.",
+                    "type": "boolean"
+                },
+                "language": {
+                    "description": "Language is an ID, name, alias or extension from GET /languages. It is\ndetected from Content, with Title as a file name hint, when omitted.",
+                    "type": "string",
+                    "example": "go"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "redact": {
+                    "description": "Redact masks emails, IP addresses, bearer tokens, JWTs, card numbers\nand matches of RedactPatterns in Content with placeholders such as\n<IP_1> before it is stored.",
+                    "type": "boolean"
+                },
+                "redact_patterns": {
+                    "description": "RedactPatterns are extra regular expressions to redact. When one has a\ngroup named \"value\", only that group is redacted.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "password=(?P<value>\\S+)"
+                    ]
+                },
+                "secret_allowlist": {
+                    "description": "SecretAllowlist holds rule IDs and finding fingerprints the secret\nscanner should accept in this paste as false positives.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "slug": {
+                    "description": "Slug requests a custom slug, as in /p/deploy-runbook, instead of a\ngenerated one.",
+                    "type": "string",
+                    "example": "deploy-runbook"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "unlisted": {
+                    "description": "Unlisted keeps a public paste out of GET /explore and GET /trending.",
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.PasteOutput": {
+            "type": "object",
+            "properties": {
+                "comments_enabled": {
+                    "description": "CommentsEnabled is false when the owner turned comments off.",
+                    "type": "boolean"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "content_locked": {
+                    "description": "ContentLocked is set when the content is encrypted and was requested\nwithout the paste password, leaving Content empty.",
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "encryption": {
+                    "description": "Encryption is set when Content is ciphertext encrypted by the client.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ClientEncryption"
+                        }
+                    ]
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "forked_from": {
+                    "description": "ForkedFrom is the ID of the paste this one was forked from.",
+                    "type": "string"
+                },
+                "forks": {
+                    "description": "Forks counts the live forks of this paste, private ones included.",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_private": {
+                    "type": "boolean"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "lineage": {
+                    "description": "Lineage lists the pastes this one descends from, its source first,\nleaving out those the caller cannot see.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ForkRef"
+                    }
+                },
+                "redactions": {
+                    "description": "Redactions counts the values redacted from the content when it was\ncreated, by category.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "rendered_html": {
+                    "description": "RenderedHTML is the sanitized HTML of a Markdown paste, set only when\nasked for with include=rendered_html.",
+                    "type": "string"
+                },
+                "secret_findings": {
+                    "description": "SecretFindings lists credentials found in the content when it was\ncreated.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/secretscan.Finding"
+                    }
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "stars": {
+                    "description": "Stars counts the users who starred this paste.",
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "unlisted": {
+                    "description": "Unlisted keeps a public paste out of the explore and trending feeds.",
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
                 "url": {
+                    "description": "URL is built per request from Slug and the origin the request came in on.",
+                    "type": "string"
+                },
+                "user_id": {
                     "type": "string"
+                },
+                "views": {
+                    "type": "integer"
                 }
             }
         },
-        "models.LoginInput": {
+        "models.PasteUpdateOutput": {
+            "type": "object",
+            "properties": {
+                "secret_findings": {
+                    "description": "SecretFindings lists credentials found in the updated paste.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/secretscan.Finding"
+                    }
+                }
+            }
+        },
+        "models.PatchPaste": {
+            "type": "object",
+            "properties": {
+                "comments_enabled": {
+                    "description": "CommentsEnabled turns new comments on the paste on or off.",
+                    "type": "boolean"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "current_password": {
+                    "description": "CurrentPassword unlocks encrypted content when the password changes\nwithout new content being supplied.",
+                    "type": "string"
+                },
+                "encryption": {
+                    "description": "Encryption must accompany new content of a client-encrypted paste.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ClientEncryption"
+                        }
+                    ]
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_private": {
+                    "type": "boolean"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "secret_allowlist": {
+                    "description": "SecretAllowlist replaces the paste's accepted secret scanner findings.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                },
+                "unlisted": {
+                    "description": "Unlisted keeps a public paste out of the explore and trending feeds.",
+                    "type": "boolean"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RegisterInput": {
             "type": "object",
             "required": [
                 "email",
+                "name",
                 "password"
             ],
             "properties": {
                 "email": {
                     "type": "string"
                 },
+                "name": {
+                    "type": "string",
+                    "minLength": 2
+                },
                 "password": {
                     "type": "string",
                     "minLength": 6
                 }
             }
         },
-        "models.LoginResponse": {
+        "models.SecretRuleInput": {
             "type": "object",
             "properties": {
-                "token": {
-                    "type": "string"
+                "action": {
+                    "description": "Action overrides the configured policy: warn, private or block.",
+                    "type": "string",
+                    "example": "block"
                 },
-                "user": {
-                    "$ref": "#/definitions/models.User"
+                "description": {
+                    "type": "string",
+                    "example": "Internal deploy token"
+                },
+                "enabled": {
+                    "description": "Enabled defaults to true.",
+                    "type": "boolean"
+                },
+                "min_entropy": {
+                    "description": "MinEntropy is the Shannon entropy in bits per character a match needs.",
+                    "type": "number",
+                    "example": 0
+                },
+                "pattern": {
+                    "type": "string",
+                    "example": "\\bdpl_[A-Za-z0-9]{32}\\b"
                 }
             }
         },
-        "models.PasteInput": {
+        "models.SlugInput": {
             "type": "object",
             "properties": {
-                "content": {
+                "slug": {
+                    "type": "string",
+                    "example": "deploy-runbook"
+                }
+            }
+        },
+        "models.SlugOutput": {
+            "type": "object",
+            "properties": {
+                "slug": {
                     "type": "string"
                 },
-                "expires_at": {
+                "url": {
                     "type": "string"
+                }
+            }
+        },
+        "models.StorageQuotaInput": {
+            "type": "object",
+            "properties": {
+                "quota_bytes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.StorageUsage": {
+            "type": "object",
+            "properties": {
+                "custom_quota": {
+                    "description": "CustomQuota is true when an admin has overridden the default quota.",
+                    "type": "boolean"
+                },
+                "quota_bytes": {
+                    "description": "QuotaBytes is 0 when the user has no storage limit.",
+                    "type": "integer"
                 },
+                "used_bytes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TopPaste": {
+            "type": "object",
+            "properties": {
                 "language": {
                     "type": "string"
                 },
-                "password": {
+                "paste_id": {
+                    "type": "string"
+                },
+                "slug": {
                     "type": "string"
                 },
                 "title": {
                     "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "views": {
+                    "type": "integer"
                 }
             }
         },
-        "models.PasteOutput": {
+        "models.TrendingPaste": {
             "type": "object",
             "properties": {
+                "comments_enabled": {
+                    "description": "CommentsEnabled is false when the owner turned comments off.",
+                    "type": "boolean"
+                },
                 "content": {
                     "type": "string"
                 },
+                "content_locked": {
+                    "description": "ContentLocked is set when the content is encrypted and was requested\nwithout the paste password, leaving Content empty.",
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "encryption": {
+                    "description": "Encryption is set when Content is ciphertext encrypted by the client.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ClientEncryption"
+                        }
+                    ]
+                },
                 "expires_at": {
                     "type": "string"
                 },
+                "forked_from": {
+                    "description": "ForkedFrom is the ID of the paste this one was forked from.",
+                    "type": "string"
+                },
+                "forks": {
+                    "description": "Forks counts the live forks of this paste, private ones included.",
+                    "type": "integer"
+                },
                 "id": {
                     "type": "string"
                 },
@@ -818,10 +3083,50 @@ const docTemplate = `{
                 "language": {
                     "type": "string"
                 },
+                "lineage": {
+                    "description": "Lineage lists the pastes this one descends from, its source first,\nleaving out those the caller cannot see.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ForkRef"
+                    }
+                },
+                "redactions": {
+                    "description": "Redactions counts the values redacted from the content when it was\ncreated, by category.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "rendered_html": {
+                    "description": "RenderedHTML is the sanitized HTML of a Markdown paste, set only when\nasked for with include=rendered_html.",
+                    "type": "string"
+                },
+                "secret_findings": {
+                    "description": "SecretFindings lists credentials found in the content when it was\ncreated.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/secretscan.Finding"
+                    }
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "stars": {
+                    "description": "Stars counts the users who starred this paste.",
+                    "type": "integer"
+                },
                 "title": {
                     "type": "string"
                 },
+                "unlisted": {
+                    "description": "Unlisted keeps a public paste out of the explore and trending feeds.",
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
                 "url": {
+                    "description": "URL is built per request from Slug and the origin the request came in on.",
                     "type": "string"
                 },
                 "user_id": {
@@ -829,63 +3134,186 @@ const docTemplate = `{
                 },
                 "views": {
                     "type": "integer"
+                },
+                "window_views": {
+                    "type": "integer"
                 }
             }
         },
-        "models.PatchPaste": {
+        "models.TrendingPastesResponse": {
             "type": "object",
             "properties": {
-                "content": {
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "pastes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TrendingPaste"
+                    }
+                },
+                "window": {
+                    "description": "Window is the period views were counted over.",
+                    "type": "string",
+                    "example": "24h"
+                }
+            }
+        },
+        "models.User": {
+            "type": "object",
+            "properties": {
+                "avatar": {
                     "type": "string"
                 },
-                "expires_at": {
+                "email": {
                     "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
-                "is_private": {
+                "name": {
+                    "type": "string"
+                },
+                "storage": {
+                    "description": "Storage is only filled in for the user's own profile.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.StorageUsage"
+                        }
+                    ]
+                }
+            }
+        },
+        "secretscan.Action": {
+            "type": "string",
+            "enum": [
+                "warn",
+                "private",
+                "block"
+            ],
+            "x-enum-varnames": [
+                "ActionWarn",
+                "ActionPrivate",
+                "ActionBlock"
+            ]
+        },
+        "secretscan.Finding": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/secretscan.Action"
+                },
+                "column": {
+                    "type": "integer",
+                    "example": 17
+                },
+                "description": {
+                    "type": "string",
+                    "example": "AWS access key ID"
+                },
+                "fingerprint": {
+                    "description": "Fingerprint identifies this secret under this rule. Listing it in a\npaste's secret_allowlist accepts it as a false positive.",
+                    "type": "string",
+                    "example": "3f1c9a7e52d04b86"
+                },
+                "line": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "match": {
+                    "description": "Match is the secret with all but its first characters masked.",
+                    "type": "string",
+                    "example": "AKIA****************"
+                },
+                "rule_id": {
+                    "type": "string",
+                    "example": "aws-access-key-id"
+                }
+            }
+        },
+        "secretscan.Rule": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "Action overrides the configured policy for this rule when set.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/secretscan.Action"
+                        }
+                    ]
+                },
+                "builtin": {
+                    "description": "Builtin rules ship with the service; admins can change or disable them\nbut deleting one only resets it.",
                     "type": "boolean"
                 },
-                "language": {
-                    "type": "string"
+                "description": {
+                    "type": "string",
+                    "example": "AWS access key ID"
                 },
-                "password": {
-                    "type": "string"
+                "enabled": {
+                    "type": "boolean"
                 },
-                "title": {
-                    "type": "string"
+                "id": {
+                    "type": "string",
+                    "example": "aws-access-key-id"
                 },
-                "user_id": {
+                "min_entropy": {
+                    "description": "MinEntropy is the Shannon entropy in bits per character a secret needs\nto count, which keeps placeholders such as \"changeme\" from matching.",
+                    "type": "number",
+                    "example": 3.5
+                },
+                "pattern": {
                     "type": "string"
                 }
             }
         },
-        "models.RegisterInput": {
+        "utils.APIResponse": {
             "type": "object",
             "properties": {
-                "email": {
+                "data": {},
+                "error": {
                     "type": "string"
                 },
-                "password": {
+                "message": {
                     "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
                 }
             }
         },
-        "models.User": {
+        "worker.State": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "running",
+                "stopped",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "StatePending",
+                "StateRunning",
+                "StateStopped",
+                "StateFailed"
+            ]
+        },
+        "worker.Status": {
             "type": "object",
             "properties": {
-                "created_at": {
+                "error": {
                     "type": "string"
                 },
-                "email": {
+                "name": {
                     "type": "string"
                 },
-                "id": {
+                "started_at": {
                     "type": "string"
                 },
-                "updated_at": {
-                    "type": "string"
+                "state": {
+                    "$ref": "#/definitions/worker.State"
                 }
             }
         }
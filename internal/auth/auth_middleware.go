@@ -7,6 +7,9 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+
+	"pastebin/internal/logging"
+	"pastebin/internal/metrics"
 )
 
 // ContextKey is a typed key for storing values in context.Context to avoid collisions.
@@ -25,11 +28,13 @@ func AuthMiddleware(jwtManager *JWTManager) echo.MiddlewareFunc {
 		return func(c echo.Context) error {
 			token, err := extractToken(c.Request().Header.Get("Authorization"))
 			if err != nil {
+				metrics.AuthFailed("missing_token")
 				return echo.NewHTTPError(401, "missing or invalid authorization")
 			}
 
 			claims, err := jwtManager.VerifyToken(token)
 			if err != nil {
+				metrics.AuthFailed("invalid_token")
 				return echo.NewHTTPError(401, "invalid token")
 			}
 
@@ -37,6 +42,7 @@ func AuthMiddleware(jwtManager *JWTManager) echo.MiddlewareFunc {
 			req := c.Request()
 			ctx := context.WithValue(req.Context(), userIDCtxKey, claims.UserID)
 			ctx = context.WithValue(ctx, userEmailCtxKey, claims.Email)
+			ctx = logging.WithUserID(ctx, claims.UserID)
 			c.SetRequest(req.WithContext(ctx))
 
 			return next(c)
@@ -44,6 +50,29 @@ func AuthMiddleware(jwtManager *JWTManager) echo.MiddlewareFunc {
 	}
 }
 
+// RequireAdmin lets a request through only when isAdmin confirms the
+// authenticated user is an admin. It must run after AuthMiddleware. The check
+// hits the caller-supplied lookup on every request, so revoking admin rights
+// takes effect without waiting for tokens to expire.
+func RequireAdmin(isAdmin func(ctx context.Context, userID uuid.UUID) (bool, error)) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			userID, err := GetUserIDFromEchoContext(c)
+			if err != nil {
+				return echo.NewHTTPError(401, "missing or invalid authorization")
+			}
+			ok, err := isAdmin(c.Request().Context(), userID)
+			if err != nil {
+				return echo.NewHTTPError(500, "unable to check permissions").SetInternal(err)
+			}
+			if !ok {
+				return echo.NewHTTPError(403, "admin access required")
+			}
+			return next(c)
+		}
+	}
+}
+
 // extractToken extracts a bearer token from an Authorization header value.
 func extractToken(authHeader string) (string, error) {
 	if authHeader == "" {
@@ -0,0 +1,46 @@
+// Package blobstore keeps paste content by the SHA-256 of its plain text, so
+// identical content is stored once. Reference counts live in the blobs table
+// and are maintained by the paste repository; a Store only holds the bytes.
+package blobstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"pastebin/internal/config"
+)
+
+// ErrNotFound is returned by Get when no blob has the hash.
+var ErrNotFound = errors.New("blob not found")
+
+// Store holds blob bytes by hash.
+type Store interface {
+	// Put stores data under hash, replacing any previous data.
+	Put(ctx context.Context, hash string, data []byte) error
+	// Get returns the data stored under hash or ErrNotFound.
+	Get(ctx context.Context, hash string) ([]byte, error)
+	// Delete removes hash. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, hash string) error
+}
+
+// Hash returns the hex SHA-256 of content, the key blobs are stored under.
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Open returns the Store selected by cfg.
+func Open(cfg *config.BlobConfig, db *pgxpool.Pool) (Store, error) {
+	switch cfg.Store {
+	case "postgres":
+		return NewPostgresStore(db), nil
+	case "filesystem":
+		return NewFileStore(cfg.Dir)
+	}
+	return nil, fmt.Errorf("unknown blob store %q", cfg.Store)
+}
@@ -0,0 +1,55 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"pastebin/internal/tracing"
+)
+
+// PostgresStore keeps blobs in the blob_data table as bytea.
+type PostgresStore struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresStore(db *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Put(ctx context.Context, hash string, data []byte) error {
+	ctx, span := tracing.Start(ctx, "PostgresStore.Put")
+	defer span.End()
+	query := `INSERT INTO blob_data (hash, data) VALUES ($1, $2)
+		ON CONFLICT (hash) DO UPDATE SET data = EXCLUDED.data`
+	if _, err := s.db.Exec(ctx, query, hash, data); err != nil {
+		return fmt.Errorf("failed to put blob: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) Get(ctx context.Context, hash string) ([]byte, error) {
+	ctx, span := tracing.Start(ctx, "PostgresStore.Get")
+	defer span.End()
+	var data []byte
+	err := s.db.QueryRow(ctx, `SELECT data FROM blob_data WHERE hash = $1`, hash).Scan(&data)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get blob: %w", err)
+	}
+	return data, nil
+}
+
+func (s *PostgresStore) Delete(ctx context.Context, hash string) error {
+	ctx, span := tracing.Start(ctx, "PostgresStore.Delete")
+	defer span.End()
+	if _, err := s.db.Exec(ctx, `DELETE FROM blob_data WHERE hash = $1`, hash); err != nil {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	return nil
+}
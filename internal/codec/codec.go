@@ -0,0 +1,144 @@
+// Package codec compresses paste content at rest. The codec name is stored
+// next to the data so rows written with any codec stay readable.
+package codec
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Codec names how stored content is encoded. The names double as HTTP
+// Content-Encoding tokens.
+type Codec string
+
+const (
+	Identity Codec = "identity"
+	Gzip     Codec = "gzip"
+	Zstd     Codec = "zstd"
+)
+
+// Parse accepts "zstd", "gzip" or "none"/"identity".
+func Parse(name string) (Codec, error) {
+	switch strings.ToLower(name) {
+	case "zstd":
+		return Zstd, nil
+	case "gzip":
+		return Gzip, nil
+	case "none", "identity", "":
+		return Identity, nil
+	}
+	return "", fmt.Errorf("unknown codec %q", name)
+}
+
+// The zstd encoder and decoder are safe for concurrent EncodeAll/DecodeAll
+// calls and expensive to build, so one of each is shared.
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+	zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+)
+
+// Compressor compresses content of at least threshold bytes with codec.
+type Compressor struct {
+	codec     Codec
+	threshold int64
+}
+
+func NewCompressor(codec Codec, threshold int64) *Compressor {
+	return &Compressor{
+		codec:     codec,
+		threshold: threshold,
+	}
+}
+
+// Encode returns the codec and bytes to store for content. Content below the
+// threshold, or that does not shrink, is returned as-is with Identity.
+func (c *Compressor) Encode(content []byte) (Codec, []byte, error) {
+	if c == nil || c.codec == Identity || int64(len(content)) < c.threshold {
+		return Identity, content, nil
+	}
+	data, err := compress(c.codec, content)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(data) >= len(content) {
+		return Identity, content, nil
+	}
+	return c.codec, data, nil
+}
+
+func compress(codec Codec, content []byte) ([]byte, error) {
+	switch codec {
+	case Zstd:
+		return zstdEncoder.EncodeAll(content, make([]byte, 0, len(content)/4)), nil
+	case Gzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(content); err != nil {
+			return nil, fmt.Errorf("gzip: %w", err)
+		}
+		if err := w.Close(); err != nil {
+			return nil, fmt.Errorf("gzip: %w", err)
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("cannot compress with codec %q", codec)
+}
+
+// Decode reverses Encode.
+func Decode(codec Codec, data []byte) ([]byte, error) {
+	switch codec {
+	case Identity, "":
+		return data, nil
+	case Zstd:
+		out, err := zstdDecoder.DecodeAll(data, nil)
+		if err != nil {
+			return nil, fmt.Errorf("zstd: %w", err)
+		}
+		return out, nil
+	case Gzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("gzip: %w", err)
+		}
+		defer r.Close()
+		out, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("gzip: %w", err)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("unknown codec %q", codec)
+}
+
+// Accepts reports whether an Accept-Encoding header value allows codec. An
+// explicit entry for codec wins over "*"; a q-value of 0 refuses it. Identity
+// is always acceptable.
+func Accepts(acceptEncoding string, codec Codec) bool {
+	if codec == Identity {
+		return true
+	}
+	wildcard := false
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.TrimSpace(name)
+		allowed := true
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				allowed = false
+			}
+		}
+		switch {
+		case strings.EqualFold(name, string(codec)):
+			return allowed
+		case name == "*":
+			wildcard = allowed
+		}
+	}
+	return wildcard
+}
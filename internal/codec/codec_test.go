@@ -0,0 +1,121 @@
+package codec
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Codec
+		wantErr bool
+	}{
+		{"zstd", Zstd, false},
+		{"GZIP", Gzip, false},
+		{"none", Identity, false},
+		{"identity", Identity, false},
+		{"", Identity, false},
+		{"brotli", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("Parse(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	text := bytes.Repeat([]byte("2024-01-01T00:00:00Z INFO request served in 12ms\n"), 200)
+	random := make([]byte, 8192)
+	rand.Read(random)
+
+	tests := []struct {
+		name       string
+		compressor *Compressor
+		content    []byte
+		wantCodec  Codec
+	}{
+		{"zstd", NewCompressor(Zstd, 1024), text, Zstd},
+		{"gzip", NewCompressor(Gzip, 1024), text, Gzip},
+		{"below threshold", NewCompressor(Zstd, int64(len(text))+1), text, Identity},
+		{"identity codec", NewCompressor(Identity, 0), text, Identity},
+		{"nil compressor", nil, text, Identity},
+		{"incompressible", NewCompressor(Zstd, 0), random, Identity},
+		{"empty", NewCompressor(Gzip, 0), []byte{}, Identity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec, data, err := tt.compressor.Encode(tt.content)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if codec != tt.wantCodec {
+				t.Fatalf("Encode() codec = %q, want %q", codec, tt.wantCodec)
+			}
+			if codec != Identity && len(data) >= len(tt.content) {
+				t.Fatalf("Encode() stored %d bytes for %d bytes of content", len(data), len(tt.content))
+			}
+			got, err := Decode(codec, data)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !bytes.Equal(got, tt.content) {
+				t.Fatal("Decode() did not return the encoded content")
+			}
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		codec Codec
+		data  []byte
+	}{
+		{"corrupt zstd", Zstd, []byte("not zstd")},
+		{"corrupt gzip", Gzip, []byte("not gzip")},
+		{"unknown codec", Codec("brotli"), []byte("data")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode(tt.codec, tt.data); err == nil {
+				t.Fatal("Decode() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestAccepts(t *testing.T) {
+	tests := []struct {
+		header string
+		codec  Codec
+		want   bool
+	}{
+		{"", Identity, true},
+		{"", Zstd, false},
+		{"gzip, deflate, br", Gzip, true},
+		{"gzip, deflate, br", Zstd, false},
+		{"GZIP", Gzip, true},
+		{"zstd;q=0.5", Zstd, true},
+		{"zstd;q=0", Zstd, false},
+		{"*", Zstd, true},
+		{"*;q=0", Gzip, false},
+		{"*, zstd;q=0", Zstd, false},
+		{"*;q=0, gzip", Gzip, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header+"/"+string(tt.codec), func(t *testing.T) {
+			if got := Accepts(tt.header, tt.codec); got != tt.want {
+				t.Fatalf("Accepts(%q, %q) = %v, want %v", tt.header, tt.codec, got, tt.want)
+			}
+		})
+	}
+}
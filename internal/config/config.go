@@ -1,16 +1,230 @@
+// Package config loads the service configuration. Values are layered as
+// defaults, then an optional YAML or TOML file, then environment variables,
+// then command-line flags, and the result is validated once at startup.
 package config
 
-import "os"
+import (
+	"time"
+)
 
+// Config is the complete, typed configuration of the service.
+type Config struct {
+	App        AppConfig        `yaml:"app" toml:"app"`
+	Server     ServerConfig     `yaml:"server" toml:"server"`
+	Database   DatabaseConfig   `yaml:"database" toml:"database"`
+	Auth       AuthConfig       `yaml:"auth" toml:"auth"`
+	Paste      PasteConfig      `yaml:"paste" toml:"paste"`
+	Logger     LoggerConfig     `yaml:"log" toml:"log"`
+	Metrics    MetricsConfig    `yaml:"metrics" toml:"metrics"`
+	Tracing    TracingConfig    `yaml:"tracing" toml:"tracing"`
+	Shutdown   ShutdownConfig   `yaml:"shutdown" toml:"shutdown"`
+	Health     HealthConfig     `yaml:"health" toml:"health"`
+	RateLimit  RateLimitConfig  `yaml:"rate_limit" toml:"rate_limit"`
+	Quota      QuotaConfig      `yaml:"quota" toml:"quota"`
+	Blob       BlobConfig       `yaml:"blob" toml:"blob"`
+	Encryption EncryptionConfig `yaml:"encryption" toml:"encryption"`
+	SecretScan SecretScanConfig `yaml:"secret_scan" toml:"secret_scan"`
+}
+
+type AppConfig struct {
+	// Env is "development" or "production"; development switches to console logging.
+	Env string `yaml:"env" toml:"env" env:"APP_ENV"`
+}
+
+type ServerConfig struct {
+	// Addr is the API listen address. A bare port such as "8080" is accepted.
+	Addr         string        `yaml:"addr" toml:"addr" env:"PORT"`
+	ReadTimeout  time.Duration `yaml:"read_timeout" toml:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	WriteTimeout time.Duration `yaml:"write_timeout" toml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	// BodyLimit caps request bodies, e.g. "2MB" or "512KiB".
+	BodyLimit ByteSize `yaml:"body_limit" toml:"body_limit" env:"SERVER_BODY_LIMIT"`
+	// TrustProxy takes the client IP from X-Forwarded-For and the host links
+	// are built on from X-Forwarded-Host. Only enable it behind a proxy that
+	// sets the headers, or clients can spoof their IP.
+	TrustProxy bool `yaml:"trust_proxy" toml:"trust_proxy" env:"TRUST_PROXY"`
+}
+
+type DatabaseConfig struct {
+	URL               Secret        `yaml:"url" toml:"url" env:"DATABASE_URL"`
+	MaxConns          int32         `yaml:"max_conns" toml:"max_conns" env:"DB_MAX_CONNS"`
+	MinConns          int32         `yaml:"min_conns" toml:"min_conns" env:"DB_MIN_CONNS"`
+	MaxConnLifetime   time.Duration `yaml:"max_conn_lifetime" toml:"max_conn_lifetime" env:"DB_MAX_CONN_LIFETIME"`
+	MaxConnIdleTime   time.Duration `yaml:"max_conn_idle_time" toml:"max_conn_idle_time" env:"DB_MAX_CONN_IDLE_TIME"`
+	HealthCheckPeriod time.Duration `yaml:"health_check_period" toml:"health_check_period" env:"DB_HEALTH_CHECK_PERIOD"`
+	ConnectTimeout    time.Duration `yaml:"connect_timeout" toml:"connect_timeout" env:"DB_CONNECT_TIMEOUT"`
+}
+
+type AuthConfig struct {
+	JWTSecret Secret        `yaml:"jwt_secret" toml:"jwt_secret" env:"JWT_SECRET"`
+	TokenTTL  time.Duration `yaml:"token_ttl" toml:"token_ttl" env:"AUTH_TOKEN_TTL"`
+}
+
+type PasteConfig struct {
+	// BaseURL is the public origin shareable paste URLs are built on by
+	// default. URLs are built per request, so changing it moves every paste.
+	BaseURL string `yaml:"base_url" toml:"base_url" env:"BASE_URL"`
+	// AllowedOrigins are further origins the service is reached under. A
+	// request to one of their hosts gets links on that origin instead.
+	AllowedOrigins []string `yaml:"allowed_origins" toml:"allowed_origins" env:"PASTE_ALLOWED_ORIGINS"`
+	// MaxSize caps the content of a single paste. It must fit within server.body_limit.
+	MaxSize     ByteSize          `yaml:"max_size" toml:"max_size" env:"PASTE_MAX_SIZE"`
+	Compression CompressionConfig `yaml:"compression" toml:"compression"`
+	Slug        SlugConfig        `yaml:"slug" toml:"slug"`
+}
+
+// CompressionConfig controls compression of paste content at rest. Codec is
+// "zstd", "gzip" or "none"; content smaller than Threshold is stored as-is.
+type CompressionConfig struct {
+	Codec     string   `yaml:"codec" toml:"codec" env:"PASTE_COMPRESSION_CODEC"`
+	Threshold ByteSize `yaml:"threshold" toml:"threshold" env:"PASTE_COMPRESSION_THRESHOLD"`
+}
+
+// SlugConfig shapes generated paste slugs, drawn at random from Alphabet.
+// Reserved adds to the built-in words that custom slugs may not use.
+type SlugConfig struct {
+	Alphabet string   `yaml:"alphabet" toml:"alphabet" env:"PASTE_SLUG_ALPHABET"`
+	Length   int      `yaml:"length" toml:"length" env:"PASTE_SLUG_LENGTH"`
+	Reserved []string `yaml:"reserved" toml:"reserved" env:"PASTE_RESERVED_SLUGS"`
+}
 
 type LoggerConfig struct {
-	Level string
+	Level string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+}
+
+// MetricsConfig controls where /metrics is served. It gets its own listener on
+// Addr, loopback by default, so it stays private; ExposeOnAPI mounts it on the
+// public API server instead and must be chosen explicitly.
+type MetricsConfig struct {
+	Enabled     bool   `yaml:"enabled" toml:"enabled" env:"METRICS_ENABLED"`
+	Addr        string `yaml:"addr" toml:"addr" env:"METRICS_ADDR"`
+	ExposeOnAPI bool   `yaml:"expose_on_api" toml:"expose_on_api" env:"METRICS_EXPOSE_ON_API"`
+}
+
+// TracingConfig selects the span exporter. Exporter is one of "otlp", "stdout"
+// or "none"; the OTLP exporter reads its endpoint and headers from the standard
+// OTEL_EXPORTER_OTLP_* variables.
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER"`
+	ServiceName string  `yaml:"service_name" toml:"service_name" env:"OTEL_SERVICE_NAME"`
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+// ShutdownConfig controls graceful shutdown. ReadinessGrace is how long to keep
+// serving after readiness flips to not-ready, giving load balancers time to
+// stop routing traffic; DrainTimeout bounds how long in-flight requests and
+// workers get to finish after that.
+type ShutdownConfig struct {
+	ReadinessGrace time.Duration `yaml:"readiness_grace" toml:"readiness_grace" env:"SHUTDOWN_READINESS_GRACE"`
+	DrainTimeout   time.Duration `yaml:"drain_timeout" toml:"drain_timeout" env:"SHUTDOWN_DRAIN_TIMEOUT"`
+}
+
+// HealthConfig bounds how long a readiness probe may spend on dependency checks.
+type HealthConfig struct {
+	CheckTimeout time.Duration `yaml:"check_timeout" toml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT"`
+}
+
+// RateLimitConfig configures token buckets per route group. Store is "memory"
+// (per replica) or "postgres" (shared by every replica).
+type RateLimitConfig struct {
+	Enabled    bool          `yaml:"enabled" toml:"enabled" env:"RATE_LIMIT_ENABLED"`
+	Store      string        `yaml:"store" toml:"store" env:"RATE_LIMIT_STORE"`
+	PublicRead RateLimitRule `yaml:"public_read" toml:"public_read" envPrefix:"RATE_LIMIT_PUBLIC_READ_"`
+	Auth       RateLimitRule `yaml:"auth" toml:"auth" envPrefix:"RATE_LIMIT_AUTH_"`
+	Read       RateLimitRule `yaml:"read" toml:"read" envPrefix:"RATE_LIMIT_READ_"`
+	Write      RateLimitRule `yaml:"write" toml:"write" envPrefix:"RATE_LIMIT_WRITE_"`
+	// PasswordCheck additionally limits requests giving a paste password,
+	// whose checks are deliberately expensive.
+	PasswordCheck RateLimitRule `yaml:"password_check" toml:"password_check" envPrefix:"RATE_LIMIT_PASSWORD_CHECK_"`
+}
+
+// RateLimitRule allows Burst requests at once, refilled at PerMinute per minute.
+type RateLimitRule struct {
+	PerMinute int `yaml:"per_minute" toml:"per_minute" env:"PER_MINUTE"`
+	Burst     int `yaml:"burst" toml:"burst" env:"BURST"`
+}
+
+// QuotaConfig caps what a single user may create per UTC day and store in
+// total. Zero disables a limit. Admins can override Storage per user.
+type QuotaConfig struct {
+	DailyPastes int      `yaml:"daily_pastes" toml:"daily_pastes" env:"QUOTA_DAILY_PASTES"`
+	DailyBytes  ByteSize `yaml:"daily_bytes" toml:"daily_bytes" env:"QUOTA_DAILY_BYTES"`
+	Storage     ByteSize `yaml:"storage" toml:"storage" env:"QUOTA_STORAGE"`
+}
+
+// BlobConfig selects where paste content is kept. Store is "postgres" or
+// "filesystem" (which needs Dir). Blobs no paste references are deleted by a
+// collector every GCInterval once they have been unreferenced for GCGrace.
+type BlobConfig struct {
+	Store      string        `yaml:"store" toml:"store" env:"BLOB_STORE"`
+	Dir        string        `yaml:"dir" toml:"dir" env:"BLOB_DIR"`
+	GCInterval time.Duration `yaml:"gc_interval" toml:"gc_interval" env:"BLOB_GC_INTERVAL"`
+	GCGrace    time.Duration `yaml:"gc_grace" toml:"gc_grace" env:"BLOB_GC_GRACE"`
+}
+
+// EncryptionConfig enables envelope encryption of paste content at rest.
+// MasterKey holds base64-encoded 32-byte keys separated by commas and KeyFile
+// names a file with one such key per line; keys from both are used, MasterKey
+// first. The first key wraps the data keys of new content, the others only
+// unwrap existing data keys until rotate-keys has re-wrapped them. With no
+// keys, content is stored unencrypted.
+type EncryptionConfig struct {
+	MasterKey Secret `yaml:"master_key" toml:"master_key" env:"ENCRYPTION_MASTER_KEY"`
+	KeyFile   string `yaml:"key_file" toml:"key_file" env:"ENCRYPTION_KEY_FILE"`
+}
+
+// SecretScanConfig controls scanning paste content for credentials. Policy is
+// what happens to a public paste containing one: "warn" lists the findings in
+// the response, "private" also makes the paste private and "block" rejects
+// it; "off" disables scanning. Rules admins edit can set their own action.
+// Rule changes reach other replicas within RefreshInterval.
+type SecretScanConfig struct {
+	Policy          string        `yaml:"policy" toml:"policy" env:"SECRET_SCAN_POLICY"`
+	RefreshInterval time.Duration `yaml:"refresh_interval" toml:"refresh_interval" env:"SECRET_SCAN_REFRESH_INTERVAL"`
 }
 
-func LoadLoggerConfig() *LoggerConfig {
-	level := os.Getenv("LOG_LEVEL")
-	if level == "" {
-		level = "info"
+// Default returns the configuration used when nothing overrides a value.
+func Default() *Config {
+	return &Config{
+		App: AppConfig{Env: "production"},
+		Server: ServerConfig{
+			Addr:         ":8080",
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: 30 * time.Second,
+			IdleTimeout:  120 * time.Second,
+			BodyLimit:    2 * MiB,
+		},
+		Database: DatabaseConfig{
+			MaxConns:          10,
+			MinConns:          0,
+			MaxConnLifetime:   time.Hour,
+			MaxConnIdleTime:   30 * time.Minute,
+			HealthCheckPeriod: time.Minute,
+			ConnectTimeout:    5 * time.Second,
+		},
+		Auth: AuthConfig{TokenTTL: 24 * time.Hour},
+		Paste: PasteConfig{
+			BaseURL:     "http://localhost:8080",
+			MaxSize:     1 * MiB,
+			Compression: CompressionConfig{Codec: "zstd", Threshold: 4 * KiB},
+			Slug:        SlugConfig{Alphabet: "23456789abcdefghjkmnpqrstuvwxyz", Length: 8},
+		},
+		Logger:   LoggerConfig{Level: "info"},
+		Metrics:  MetricsConfig{Enabled: true, Addr: "127.0.0.1:9091"},
+		Tracing:  TracingConfig{Exporter: "none", ServiceName: "pastebin-api", SampleRatio: 1},
+		Shutdown: ShutdownConfig{DrainTimeout: 15 * time.Second},
+		Health:   HealthConfig{CheckTimeout: 2 * time.Second},
+		RateLimit: RateLimitConfig{
+			Enabled:       true,
+			Store:         "memory",
+			PublicRead:    RateLimitRule{PerMinute: 120, Burst: 60},
+			Auth:          RateLimitRule{PerMinute: 10, Burst: 10},
+			Read:          RateLimitRule{PerMinute: 300, Burst: 100},
+			Write:         RateLimitRule{PerMinute: 30, Burst: 10},
+			PasswordCheck: RateLimitRule{PerMinute: 10, Burst: 5},
+		},
+		Quota:      QuotaConfig{DailyPastes: 1000, DailyBytes: 100 * MiB, Storage: 500 * MiB},
+		Blob:       BlobConfig{Store: "postgres", GCInterval: 10 * time.Minute, GCGrace: time.Hour},
+		SecretScan: SecretScanConfig{Policy: "warn", RefreshInterval: time.Minute},
 	}
-	return &LoggerConfig{Level: level}
 }
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"strconv"
+
+	"github.com/labstack/gommon/bytes"
+)
+
+const redacted = "[REDACTED]"
+
+// Secret is a string that never prints its value. It decodes like a plain
+// string from files and the environment but renders as [REDACTED] through
+// fmt, JSON and YAML, so a printed Config cannot leak credentials.
+type Secret string
+
+// Value returns the underlying secret.
+func (s Secret) Value() string {
+	return string(s)
+}
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) GoString() string {
+	return strconv.Quote(s.String())
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(s.String())), nil
+}
+
+func (s Secret) MarshalYAML() (any, error) {
+	return s.String(), nil
+}
+
+// Byte size units accepted by ByteSize, for use in defaults.
+const (
+	KiB ByteSize = 1 << 10
+	MiB ByteSize = 1 << 20
+	GiB ByteSize = 1 << 30
+)
+
+// ByteSize is a number of bytes that can be written as "2MB", "512KiB" or a
+// plain integer in files, the environment and flags.
+type ByteSize int64
+
+func (b ByteSize) Int64() int64 {
+	return int64(b)
+}
+
+func (b ByteSize) String() string {
+	return bytes.Format(int64(b))
+}
+
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	n, err := bytes.Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*b = ByteSize(n)
+	return nil
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+)
+
+// normalize fills in shorthand forms before validation.
+func (c *Config) normalize() {
+	if c.Server.Addr != "" && !strings.Contains(c.Server.Addr, ":") {
+		c.Server.Addr = ":" + c.Server.Addr
+	}
+	if c.Metrics.Addr != "" && !strings.Contains(c.Metrics.Addr, ":") {
+		c.Metrics.Addr = ":" + c.Metrics.Addr
+	}
+	c.Paste.BaseURL = strings.TrimRight(c.Paste.BaseURL, "/")
+}
+
+// Validate reports every invalid value at once so a broken deployment can be
+// fixed in one pass.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.App.Env == "development" || c.App.Env == "production", "app.env must be development or production, got %q", c.App.Env)
+
+	check(c.Server.Addr != "", "server.addr is required")
+	check(c.Server.ReadTimeout > 0, "server.read_timeout must be positive")
+	check(c.Server.WriteTimeout > 0, "server.write_timeout must be positive")
+	check(c.Server.IdleTimeout > 0, "server.idle_timeout must be positive")
+	check(c.Server.BodyLimit > 0, "server.body_limit must be positive")
+
+	if c.Database.URL == "" {
+		errs = append(errs, errors.New("database.url (DATABASE_URL) is required"))
+	} else if _, err := pgxpool.ParseConfig(c.Database.URL.Value()); err != nil {
+		errs = append(errs, errors.New("database.url is not a valid connection string"))
+	}
+	check(c.Database.MaxConns >= 1, "database.max_conns must be at least 1")
+	check(c.Database.MinConns >= 0 && c.Database.MinConns <= c.Database.MaxConns, "database.min_conns must be between 0 and max_conns")
+	check(c.Database.MaxConnLifetime > 0, "database.max_conn_lifetime must be positive")
+	check(c.Database.MaxConnIdleTime > 0, "database.max_conn_idle_time must be positive")
+	check(c.Database.HealthCheckPeriod > 0, "database.health_check_period must be positive")
+	check(c.Database.ConnectTimeout > 0, "database.connect_timeout must be positive")
+
+	check(c.Auth.JWTSecret != "", "auth.jwt_secret (JWT_SECRET) is required")
+	check(c.Auth.TokenTTL > 0, "auth.token_ttl must be positive")
+
+	if u, err := url.Parse(c.Paste.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		errs = append(errs, fmt.Errorf("paste.base_url must be an absolute URL, got %q", c.Paste.BaseURL))
+	}
+	for _, o := range c.Paste.AllowedOrigins {
+		if u, err := url.Parse(strings.TrimRight(o, "/")); err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" {
+			errs = append(errs, fmt.Errorf("paste.allowed_origins must hold origins like https://paste.example.com, got %q", o))
+		}
+	}
+	check(c.Paste.MaxSize > 0, "paste.max_size must be positive")
+	switch c.Paste.Compression.Codec {
+	case "zstd", "gzip", "none":
+	default:
+		errs = append(errs, fmt.Errorf("paste.compression.codec must be zstd, gzip or none, got %q", c.Paste.Compression.Codec))
+	}
+	check(c.Paste.Compression.Threshold >= 0, "paste.compression.threshold must not be negative")
+	check(len(c.Paste.Slug.Alphabet) >= 2, "paste.slug.alphabet needs at least two characters")
+	check(c.Paste.Slug.Length >= 4 && c.Paste.Slug.Length <= 64, "paste.slug.length must be between 4 and 64")
+	check(c.Paste.MaxSize <= c.Server.BodyLimit, "paste.max_size (%s) must not exceed server.body_limit (%s)", c.Paste.MaxSize, c.Server.BodyLimit)
+
+	if _, err := zerolog.ParseLevel(c.Logger.Level); err != nil || c.Logger.Level == "" {
+		errs = append(errs, fmt.Errorf("log.level %q is not a valid level", c.Logger.Level))
+	}
+
+	if c.Metrics.Enabled && !c.Metrics.ExposeOnAPI {
+		check(c.Metrics.Addr != "", "metrics.addr is required; set metrics.expose_on_api to serve /metrics on the API listener")
+		check(c.Metrics.Addr != c.Server.Addr, "metrics.addr must differ from server.addr")
+	}
+
+	switch c.Tracing.Exporter {
+	case "otlp", "stdout", "none":
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter must be otlp, stdout or none, got %q", c.Tracing.Exporter))
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1")
+	check(c.Tracing.ServiceName != "", "tracing.service_name is required")
+
+	check(c.Shutdown.ReadinessGrace >= 0, "shutdown.readiness_grace must not be negative")
+	check(c.Shutdown.DrainTimeout > 0, "shutdown.drain_timeout must be positive")
+	check(c.Health.CheckTimeout > 0, "health.check_timeout must be positive")
+
+	check(c.RateLimit.Store == "memory" || c.RateLimit.Store == "postgres", "rate_limit.store must be memory or postgres, got %q", c.RateLimit.Store)
+	for name, rule := range map[string]RateLimitRule{
+		"public_read":    c.RateLimit.PublicRead,
+		"auth":           c.RateLimit.Auth,
+		"read":           c.RateLimit.Read,
+		"write":          c.RateLimit.Write,
+		"password_check": c.RateLimit.PasswordCheck,
+	} {
+		check(rule.PerMinute >= 0 && rule.Burst >= 0, "rate_limit.%s values must not be negative", name)
+		check(rule.Burst == 0 || rule.PerMinute > 0, "rate_limit.%s.per_minute must be positive when burst is set", name)
+	}
+	check(c.Quota.DailyPastes >= 0, "quota.daily_pastes must not be negative")
+	check(c.Quota.DailyBytes >= 0, "quota.daily_bytes must not be negative")
+	check(c.Quota.Storage >= 0, "quota.storage must not be negative")
+
+	switch c.Blob.Store {
+	case "postgres":
+	case "filesystem":
+		check(c.Blob.Dir != "", "blob.dir (BLOB_DIR) is required for the filesystem store")
+	default:
+		errs = append(errs, fmt.Errorf("blob.store must be postgres or filesystem, got %q", c.Blob.Store))
+	}
+	check(c.Blob.GCInterval > 0, "blob.gc_interval must be positive")
+	check(c.Blob.GCGrace > 0, "blob.gc_grace must be positive")
+
+	switch c.SecretScan.Policy {
+	case "off", "warn", "private", "block":
+	default:
+		errs = append(errs, fmt.Errorf("secret_scan.policy must be off, warn, private or block, got %q", c.SecretScan.Policy))
+	}
+	check(c.SecretScan.RefreshInterval > 0, "secret_scan.refresh_interval must be positive")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -3,23 +3,27 @@ package database
 import (
 	"context"
 	"fmt"
-	"os"
 
 	"github.com/jackc/pgx/v5/pgxpool"
-)
-
-func InitDB() (pool *pgxpool.Pool, err error) {
-	dbURL := os.Getenv("DATABASE_URL")
-	if dbURL == "" {
-		return nil, fmt.Errorf("DATABASE_URL is not set")
-	}
 
-	config, err := pgxpool.ParseConfig(dbURL)
+	"pastebin/internal/config"
+	"pastebin/internal/tracing"
+)
 
+func InitDB(cfg *config.DatabaseConfig) (pool *pgxpool.Pool, err error) {
+	poolConfig, err := pgxpool.ParseConfig(cfg.URL.Value())
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse database config: %w", err)
 	}
-	pool, err = pgxpool.NewWithConfig(context.Background(), config)
+	poolConfig.MaxConns = cfg.MaxConns
+	poolConfig.MinConns = cfg.MinConns
+	poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+	poolConfig.HealthCheckPeriod = cfg.HealthCheckPeriod
+	poolConfig.ConnConfig.ConnectTimeout = cfg.ConnectTimeout
+	poolConfig.ConnConfig.Tracer = tracing.QueryTracer{}
+
+	pool, err = pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create database pool: %w", err)
 	}
@@ -0,0 +1,254 @@
+// Package envelope encrypts paste content at rest. Every item gets its own
+// random data key, and the data key is stored next to the content wrapped by
+// a master key. Rotating the master key only re-wraps data keys, so content
+// never has to be rewritten.
+//
+// Content and data keys are sealed with AES-256-GCM. Master keys are
+// identified by a fingerprint stored with each wrapped data key, which lets a
+// keyring hold old keys while their data keys are being rotated.
+package envelope
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"pastebin/internal/config"
+)
+
+// KeySize is the size in bytes of master and data keys.
+const KeySize = 32
+
+var (
+	// ErrUnknownKey is returned when a data key was wrapped by a master key
+	// that is not in the keyring.
+	ErrUnknownKey = errors.New("unknown master key")
+	// ErrDecrypt is returned when content or a data key fails to decrypt.
+	ErrDecrypt = errors.New("unable to decrypt content")
+)
+
+type masterKey struct {
+	id   string
+	aead cipher.AEAD
+	// nameKey keys the hashes content is stored under in the blob store.
+	nameKey []byte
+}
+
+// Keyring holds the master keys. The first key wraps new data keys; the
+// others only unwrap existing ones until they have been rotated.
+type Keyring struct {
+	current *masterKey
+	keys    map[string]*masterKey
+}
+
+// Sealed is content encrypted under a data key, together with that data key
+// wrapped by the master key KeyID.
+type Sealed struct {
+	Data  []byte
+	Key   []byte
+	KeyID string
+}
+
+// NewKeyring builds a keyring from raw keys, the current one first.
+func NewKeyring(keys ...[]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no master keys")
+	}
+	k := &Keyring{keys: make(map[string]*masterKey, len(keys))}
+	for i, raw := range keys {
+		if len(raw) != KeySize {
+			return nil, fmt.Errorf("master key %d is %d bytes, want %d", i+1, len(raw), KeySize)
+		}
+		aead, err := newAEAD(raw)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(raw)
+		name := sha256.Sum256(append([]byte("pastebin blob name\x00"), raw...))
+		mk := &masterKey{id: hex.EncodeToString(sum[:8]), aead: aead, nameKey: name[:]}
+		if _, ok := k.keys[mk.id]; ok {
+			return nil, fmt.Errorf("master key %d is listed twice", i+1)
+		}
+		k.keys[mk.id] = mk
+		if k.current == nil {
+			k.current = mk
+		}
+	}
+	return k, nil
+}
+
+// Load returns the keyring configured by cfg: the keys in MasterKey followed
+// by those in KeyFile. It returns nil when neither is set.
+func Load(cfg *config.EncryptionConfig) (*Keyring, error) {
+	var encoded []string
+	for _, key := range strings.Split(cfg.MasterKey.Value(), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			encoded = append(encoded, key)
+		}
+	}
+	if cfg.KeyFile != "" {
+		fromFile, err := readKeyFile(cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, fromFile...)
+	}
+	if len(encoded) == 0 {
+		return nil, nil
+	}
+	keys := make([][]byte, 0, len(encoded))
+	for i, key := range encoded {
+		raw, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("master key %d is not valid base64", i+1)
+		}
+		keys = append(keys, raw)
+	}
+	return NewKeyring(keys...)
+}
+
+// readKeyFile returns the keys in path, one per line. Blank lines and lines
+// starting with # are skipped.
+func readKeyFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open key file: %w", err)
+	}
+	defer f.Close()
+	var keys []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keys = append(keys, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read key file: %w", err)
+	}
+	return keys, nil
+}
+
+// GenerateKey returns a new random master key, base64 encoded.
+func GenerateKey() (string, error) {
+	raw := make([]byte, KeySize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("generate key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(raw), nil
+}
+
+// CurrentID returns the fingerprint of the key new data keys are wrapped with.
+func (k *Keyring) CurrentID() string {
+	return k.current.id
+}
+
+// Seal encrypts plaintext under a new data key wrapped by the current key.
+func (k *Keyring) Seal(plaintext []byte) (*Sealed, error) {
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("generate data key: %w", err)
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	data, err := seal(aead, plaintext, nil)
+	if err != nil {
+		return nil, err
+	}
+	// The key id is authenticated so a wrapped key cannot be relabelled.
+	wrapped, err := seal(k.current.aead, dataKey, []byte(k.current.id))
+	if err != nil {
+		return nil, err
+	}
+	return &Sealed{Data: data, Key: wrapped, KeyID: k.current.id}, nil
+}
+
+// Open decrypts content sealed by Seal.
+func (k *Keyring) Open(s Sealed) ([]byte, error) {
+	dataKey, err := k.unwrap(s.Key, s.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return open(aead, s.Data, nil)
+}
+
+// Rewrap unwraps a data key and wraps it again with the current key,
+// returning the new wrapped key and its key id.
+func (k *Keyring) Rewrap(key []byte, keyID string) ([]byte, string, error) {
+	dataKey, err := k.unwrap(key, keyID)
+	if err != nil {
+		return nil, "", err
+	}
+	wrapped, err := seal(k.current.aead, dataKey, []byte(k.current.id))
+	if err != nil {
+		return nil, "", err
+	}
+	return wrapped, k.current.id, nil
+}
+
+// BlobName returns the name content is stored under in the blob store: an
+// HMAC rather than a plain hash, so stored names do not reveal whether a
+// known text has been pasted. Names change with the current key, which only
+// means content stored before a rotation is not shared with content after.
+func (k *Keyring) BlobName(content []byte) string {
+	mac := hmac.New(sha256.New, k.current.nameKey)
+	mac.Write(content)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (k *Keyring) unwrap(key []byte, keyID string) ([]byte, error) {
+	mk, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownKey, keyID)
+	}
+	return open(mk.aead, key, []byte(keyID))
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create gcm: %w", err)
+	}
+	return aead, nil
+}
+
+// seal returns nonce | ciphertext+tag.
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	nonce := sealed[:aead.NonceSize()]
+	plaintext, err := aead.Open(nil, nonce, sealed[aead.NonceSize():], additionalData)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
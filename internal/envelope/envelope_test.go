@@ -0,0 +1,202 @@
+package envelope
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pastebin/internal/config"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, KeySize)
+}
+
+func mustKeyring(t *testing.T, keys ...[]byte) *Keyring {
+	t.Helper()
+	k, err := NewKeyring(keys...)
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	return k
+}
+
+func TestNewKeyring(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    [][]byte
+		wantErr string
+	}{
+		{"one key", [][]byte{testKey(1)}, ""},
+		{"current and old key", [][]byte{testKey(1), testKey(2)}, ""},
+		{"no keys", nil, "no master keys"},
+		{"short key", [][]byte{testKey(1)[:16]}, "master key 1 is 16 bytes"},
+		{"duplicate key", [][]byte{testKey(1), testKey(1)}, "master key 2 is listed twice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeyring(tt.keys...)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("NewKeyring() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("NewKeyring() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	k := mustKeyring(t, testKey(1))
+	for _, plaintext := range [][]byte{[]byte("paste content"), {}, bytes.Repeat([]byte("x"), 1<<16)} {
+		sealed, err := k.Seal(plaintext)
+		if err != nil {
+			t.Fatalf("Seal() error = %v", err)
+		}
+		if sealed.KeyID != k.CurrentID() {
+			t.Fatalf("KeyID = %q, want %q", sealed.KeyID, k.CurrentID())
+		}
+		got, err := k.Open(*sealed)
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Fatal("Open() did not return the sealed content")
+		}
+	}
+}
+
+func TestOpenRejects(t *testing.T) {
+	k := mustKeyring(t, testKey(1))
+	other := mustKeyring(t, testKey(2))
+	sealed, err := k.Seal([]byte("paste content"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	flip := func(b []byte) []byte {
+		b = bytes.Clone(b)
+		b[len(b)-1] ^= 1
+		return b
+	}
+	tests := []struct {
+		name    string
+		keyring *Keyring
+		sealed  Sealed
+		wantErr error
+	}{
+		{"tampered data", k, Sealed{Data: flip(sealed.Data), Key: sealed.Key, KeyID: sealed.KeyID}, ErrDecrypt},
+		{"tampered key", k, Sealed{Data: sealed.Data, Key: flip(sealed.Key), KeyID: sealed.KeyID}, ErrDecrypt},
+		{"truncated data", k, Sealed{Data: sealed.Data[:4], Key: sealed.Key, KeyID: sealed.KeyID}, ErrDecrypt},
+		{"key from another keyring", k, Sealed{Data: sealed.Data, Key: sealed.Key, KeyID: other.CurrentID()}, ErrUnknownKey},
+		{"keyring without the key", other, *sealed, ErrUnknownKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.keyring.Open(tt.sealed); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Open() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestRotation follows the rotate-keys procedure: content sealed under an old
+// key is re-wrapped once a new key is listed first, after which the old key
+// can be dropped.
+func TestRotation(t *testing.T) {
+	oldKey, newKey := testKey(1), testKey(2)
+	before := mustKeyring(t, oldKey)
+	during := mustKeyring(t, newKey, oldKey)
+	after := mustKeyring(t, newKey)
+
+	sealed, err := before.Seal([]byte("paste content"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := during.Open(*sealed); err != nil || string(got) != "paste content" {
+		t.Fatalf("Open() with old key still listed = %q, %v", got, err)
+	}
+
+	wrapped, keyID, err := during.Rewrap(sealed.Key, sealed.KeyID)
+	if err != nil {
+		t.Fatalf("Rewrap() error = %v", err)
+	}
+	if keyID != during.CurrentID() || keyID == sealed.KeyID {
+		t.Fatalf("Rewrap() key id = %q, want the new key %q", keyID, during.CurrentID())
+	}
+	rotated := Sealed{Data: sealed.Data, Key: wrapped, KeyID: keyID}
+	if got, err := after.Open(rotated); err != nil || string(got) != "paste content" {
+		t.Fatalf("Open() after dropping the old key = %q, %v", got, err)
+	}
+	if _, err := after.Open(*sealed); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("Open() of a key that was not re-wrapped = %v, want ErrUnknownKey", err)
+	}
+
+	// Re-wrapping again is harmless, so an interrupted rotation can be rerun
+	if _, again, err := during.Rewrap(wrapped, keyID); err != nil || again != keyID {
+		t.Fatalf("second Rewrap() = %q, %v", again, err)
+	}
+	// A wrapped key cannot be relabelled with another key id
+	if _, _, err := during.Rewrap(wrapped, before.CurrentID()); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("Rewrap() of a relabelled key = %v, want ErrDecrypt", err)
+	}
+}
+
+func TestBlobName(t *testing.T) {
+	k := mustKeyring(t, testKey(1))
+	a, b := k.BlobName([]byte("content")), k.BlobName([]byte("content"))
+	if a != b {
+		t.Fatal("BlobName() is not deterministic")
+	}
+	if k.BlobName([]byte("other")) == a {
+		t.Fatal("different content got the same name")
+	}
+	if mustKeyring(t, testKey(2)).BlobName([]byte("content")) == a {
+		t.Fatal("different keys gave the same name")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	encode := base64.StdEncoding.EncodeToString
+	keyFile := filepath.Join(t.TempDir(), "keys")
+	if err := os.WriteFile(keyFile, []byte("# old keys\n\n"+encode(testKey(3))+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		cfg     config.EncryptionConfig
+		wantNil bool
+		wantErr bool
+		wantKey []byte
+	}{
+		{name: "nothing configured", wantNil: true},
+		{name: "master keys", cfg: config.EncryptionConfig{MasterKey: config.Secret(encode(testKey(1)) + ", " + encode(testKey(2)))}, wantKey: testKey(1)},
+		{name: "key file only", cfg: config.EncryptionConfig{KeyFile: keyFile}, wantKey: testKey(3)},
+		{name: "master key before key file", cfg: config.EncryptionConfig{MasterKey: config.Secret(encode(testKey(1))), KeyFile: keyFile}, wantKey: testKey(1)},
+		{name: "bad base64", cfg: config.EncryptionConfig{MasterKey: "not base64!"}, wantErr: true},
+		{name: "missing key file", cfg: config.EncryptionConfig{KeyFile: keyFile + ".missing"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := Load(&tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (k == nil) != tt.wantNil {
+				t.Fatalf("Load() = %v, want nil %v", k, tt.wantNil)
+			}
+			if tt.wantKey != nil && k.CurrentID() != mustKeyring(t, tt.wantKey).CurrentID() {
+				t.Fatal("Load() made the wrong key current")
+			}
+		})
+	}
+}
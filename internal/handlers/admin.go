@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"pastebin/internal/logging"
+	"pastebin/internal/models"
+	"pastebin/internal/services"
+	"pastebin/pkg/utils"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+type AdminHandler struct {
+	adminSvc      *services.AdminService
+	secretRuleSvc *services.SecretRuleService
+	logger        zerolog.Logger
+}
+
+func NewAdminHandler(adminSvc *services.AdminService, secretRuleSvc *services.SecretRuleService, logger zerolog.Logger) *AdminHandler {
+	return &AdminHandler{
+		adminSvc:      adminSvc,
+		secretRuleSvc: secretRuleSvc,
+		logger:        logger,
+	}
+}
+
+// SetStorageQuota godoc
+//
+//	@Summary		Override a user's storage quota
+//	@Description	Set a per-user storage quota in bytes. 0 removes the limit and null restores the configured default. Admin only.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string						true	"User ID"
+//	@Param			request	body		models.StorageQuotaInput	true	"Quota override"
+//	@Success		200		{object}	models.StorageUsage			"Updated storage usage"
+//	@Failure		400		{object}	map[string]string			"Invalid request"
+//	@Failure		403		{object}	map[string]string			"Admin access required"
+//	@Failure		404		{object}	map[string]string			"User not found"
+//	@Failure		500		{object}	map[string]string			"Unable to set storage quota"
+//	@Security		BearerAuth
+//	@Router			/admin/users/{id}/storage-quota [put]
+func (a *AdminHandler) SetStorageQuota(c echo.Context) error {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.SendError(c, http.StatusBadRequest, "invalid user id")
+	}
+	var input models.StorageQuotaInput
+	if err := c.Bind(&input); err != nil {
+		return utils.SendError(c, http.StatusBadRequest, "invalid request")
+	}
+	if input.QuotaBytes != nil && *input.QuotaBytes < 0 {
+		return utils.SendError(c, http.StatusBadRequest, "quota_bytes must not be negative")
+	}
+
+	usage, err := a.adminSvc.SetStorageQuota(c.Request().Context(), userID, &input)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			return utils.SendError(c, http.StatusNotFound, err.Error())
+		}
+		logging.FromContext(c.Request().Context(), a.logger).Error().Err(err).Msg("failed to set storage quota")
+		return utils.SendError(c, http.StatusInternalServerError, "failed to set storage quota")
+	}
+	return utils.SendSuccess(c, http.StatusOK, usage, "storage quota updated successfully")
+}
+
+// ListSecretRules godoc
+//
+//	@Summary		List secret scanning rules
+//	@Description	List the built-in and admin-defined rules pastes are scanned with, including disabled ones. Admin only.
+//	@Tags			admin
+//	@Produce		json
+//	@Success		200	{array}		secretscan.Rule		"Secret scanning rules"
+//	@Failure		403	{object}	map[string]string	"Admin access required"
+//	@Failure		500	{object}	map[string]string	"Unable to list secret rules"
+//	@Security		BearerAuth
+//	@Router			/admin/secret-rules [get]
+func (a *AdminHandler) ListSecretRules(c echo.Context) error {
+	rules, err := a.secretRuleSvc.ListRules(c.Request().Context())
+	if err != nil {
+		return utils.SendError(c, http.StatusInternalServerError, "failed to list secret rules")
+	}
+	return utils.SendSuccess(c, http.StatusOK, rules, "")
+}
+
+// SaveSecretRule godoc
+//
+//	@Summary		Create or change a secret scanning rule
+//	@Description	Create the rule with this ID, or replace it. A built-in rule can be changed or disabled; an empty pattern or description keeps the built-in one. pattern is an RE2 regular expression whose named group "secret", when present, marks the secret within the match. The rule applies to new scans within the configured refresh interval. Admin only.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string					true	"Rule ID, lower-case letters, digits and dashes"
+//	@Param			request	body		models.SecretRuleInput	true	"Rule"
+//	@Success		200		{object}	secretscan.Rule			"Saved rule"
+//	@Failure		400		{object}	map[string]string		"Invalid rule ID, pattern or action"
+//	@Failure		403		{object}	map[string]string		"Admin access required"
+//	@Failure		500		{object}	map[string]string		"Unable to save secret rule"
+//	@Security		BearerAuth
+//	@Router			/admin/secret-rules/{id} [put]
+func (a *AdminHandler) SaveSecretRule(c echo.Context) error {
+	var input models.SecretRuleInput
+	if err := c.Bind(&input); err != nil {
+		return utils.SendError(c, http.StatusBadRequest, "invalid request")
+	}
+	rule, err := a.secretRuleSvc.SaveRule(c.Request().Context(), c.Param("id"), &input)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSecretRule) {
+			return utils.SendError(c, http.StatusBadRequest, err.Error())
+		}
+		return utils.SendError(c, http.StatusInternalServerError, "failed to save secret rule")
+	}
+	return utils.SendSuccess(c, http.StatusOK, rule, "secret rule saved successfully")
+}
+
+// DeleteSecretRule godoc
+//
+//	@Summary		Delete a secret scanning rule
+//	@Description	Delete an admin-defined rule, or reset a changed built-in rule to its defaults. Admin only.
+//	@Tags			admin
+//	@Produce		json
+//	@Param			id	path		string				true	"Rule ID"
+//	@Success		200	{object}	map[string]string	"Rule deleted or reset"
+//	@Failure		403	{object}	map[string]string	"Admin access required"
+//	@Failure		404	{object}	map[string]string	"No stored rule with this ID"
+//	@Failure		500	{object}	map[string]string	"Unable to delete secret rule"
+//	@Security		BearerAuth
+//	@Router			/admin/secret-rules/{id} [delete]
+func (a *AdminHandler) DeleteSecretRule(c echo.Context) error {
+	if err := a.secretRuleSvc.DeleteRule(c.Request().Context(), c.Param("id")); err != nil {
+		if errors.Is(err, services.ErrSecretRuleNotFound) {
+			return utils.SendError(c, http.StatusNotFound, err.Error())
+		}
+		return utils.SendError(c, http.StatusInternalServerError, "failed to delete secret rule")
+	}
+	return utils.SendSuccess(c, http.StatusOK, nil, "secret rule deleted successfully")
+}
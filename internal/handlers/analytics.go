@@ -3,6 +3,8 @@ package handlers
 import (
 	"fmt"
 	"net/http"
+	"pastebin/internal/auth"
+	"pastebin/internal/logging"
 	"pastebin/internal/models"
 	"pastebin/internal/services"
 	"pastebin/pkg/utils"
@@ -41,11 +43,11 @@ func NewAnalyticsHandler(analyticsSvc *services.AnalyticsService, logger zerolog
 func (h *AnalyticsHandler) CreateAnalytics(c echo.Context) error {
 	var createAnalytics models.AnalyticsInput
 	if err := c.Bind(&createAnalytics); err != nil {
-		h.logger.Error().Err(err).Msg("failed to bind create analytics")
+		logging.FromContext(c.Request().Context(), h.logger).Error().Err(err).Msg("failed to bind create analytics")
 		return utils.SendError(c, http.StatusBadRequest, "invalid request body")
 	}
 	ctx := c.Request().Context()
-	if err := h.analyticsSvc.CreateAnalytics(ctx, createAnalytics.PasteID, createAnalytics.URL); err != nil {
+	if err := h.analyticsSvc.CreateAnalytics(ctx, createAnalytics.PasteID); err != nil {
 		return utils.SendError(c, http.StatusInternalServerError, "failed to create analytics")
 	}
 	return utils.SendSuccess(c, http.StatusCreated, nil, "analytics created successfully")
@@ -205,3 +207,27 @@ func (h *AnalyticsHandler) GetAnalyticsByPasteID(c echo.Context) error {
 	}
 	return utils.SendSuccess(c, http.StatusOK, analytic, "paste analytics retrieved successfully")
 }
+
+// GetAnalyticsSummary godoc
+//
+//	@Summary		Get analytics summary
+//	@Description	Retrieve dashboard totals for the authenticated user's pastes: paste and view counts, 7/30 day views, top pastes, language breakdown, visibility split and pastes expiring in the next 24h
+//	@Tags			analytics
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	models.AnalyticsSummary	"Analytics summary"
+//	@Failure		401	{object}	map[string]string		"Unauthorized"
+//	@Failure		500	{object}	map[string]string		"Unable to get analytics summary"
+//	@Security		BearerAuth
+//	@Router			/analytics/summary [get]
+func (h *AnalyticsHandler) GetAnalyticsSummary(c echo.Context) error {
+	userID, err := auth.GetUserIDFromEchoContext(c)
+	if err != nil {
+		return utils.SendError(c, http.StatusUnauthorized, "missing or invalid authorization")
+	}
+	summary, err := h.analyticsSvc.GetSummary(c.Request().Context(), userID)
+	if err != nil {
+		return utils.SendError(c, http.StatusInternalServerError, "failed to retrieve analytics summary")
+	}
+	return utils.SendSuccess(c, http.StatusOK, summary, "analytics summary retrieved successfully")
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"pastebin/internal/logging"
 	"pastebin/internal/models"
 	"pastebin/internal/services"
 	"pastebin/pkg/utils"
@@ -60,7 +61,7 @@ func (h *AuthHandler) Register(c echo.Context) error {
 
 	ctx := c.Request().Context()
 	if err := h.authSvc.Register(ctx, &RegisterInput); err != nil {
-		h.logger.Error().Err(err).Msg("failed to register user")
+		logging.FromContext(c.Request().Context(), h.logger).Error().Err(err).Msg("failed to register user")
 		return utils.SendError(c, http.StatusInternalServerError, "failed to register user")
 	}
 	return utils.SendSuccess(c, http.StatusCreated, nil, "user registered successfully")
@@ -98,7 +99,7 @@ func (h *AuthHandler) Login(c echo.Context) error {
 	ctx := c.Request().Context()
 	resp, err := h.authSvc.Login(ctx, &loginInput)
 	if err != nil {
-		h.logger.Error().Err(err).Msg("failed to login")
+		logging.FromContext(c.Request().Context(), h.logger).Error().Err(err).Msg("failed to login")
 		return utils.SendError(c, http.StatusUnauthorized, "invalid email or password")
 	}
 	return utils.SendSuccess(c, http.StatusOK, resp, "login successful")
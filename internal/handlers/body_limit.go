@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"pastebin/pkg/utils"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/bytes"
+)
+
+// BodyLimit rejects request bodies larger than limit with a 413 JSON error.
+// Bodies that announce their size are rejected up front; chunked bodies are
+// cut off while reading, and handlers turn that into the same 413 through
+// bodyTooLarge.
+func BodyLimit(limit int64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if req.ContentLength > limit {
+				return utils.SendError(c, http.StatusRequestEntityTooLarge, bodyTooLargeMessage(limit))
+			}
+			req.Body = http.MaxBytesReader(c.Response(), req.Body, limit)
+			return next(c)
+		}
+	}
+}
+
+// bodyTooLarge reports whether err came from reading past the BodyLimit and
+// sends the matching 413 response if so.
+func bodyTooLarge(c echo.Context, err error) (bool, error) {
+	var maxErr *http.MaxBytesError
+	if !errors.As(err, &maxErr) {
+		return false, nil
+	}
+	return true, utils.SendError(c, http.StatusRequestEntityTooLarge, bodyTooLargeMessage(maxErr.Limit))
+}
+
+func bodyTooLargeMessage(limit int64) string {
+	return fmt.Sprintf("request body exceeds the %s limit", bytes.Format(limit))
+}
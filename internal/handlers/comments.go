@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"pastebin/internal/logging"
+	"pastebin/internal/models"
+	"pastebin/internal/services"
+	"pastebin/pkg/utils"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+type CommentHandler struct {
+	commentSvc *services.CommentService
+	logger     zerolog.Logger
+}
+
+func NewCommentHandler(commentSvc *services.CommentService, logger zerolog.Logger) *CommentHandler {
+	return &CommentHandler{
+		commentSvc: commentSvc,
+		logger:     logger,
+	}
+}
+
+// ListComments godoc
+//
+//	@Summary		List comments on a paste
+//	@Description	List the comment threads on the paste shared under slug, oldest first, with replies nested under their parent. Deleted comments are kept with an empty body only where they have replies. Anyone allowed to view the paste may read its comments, giving its password when it has one.
+//	@Tags			comments
+//	@Produce		json
+//	@Param			slug		path		string				true	"Paste slug"
+//	@Param			password	query		string				false	"Password for password-protected pastes"
+//	@Success		200			{array}		models.Comment		"Comment threads"
+//	@Failure		404			{object}	map[string]string	"Paste not found or not visible to the caller"
+//	@Failure		500			{object}	map[string]string	"Unable to list comments"
+//	@Router			/p/{slug}/comments [get]
+func (h *CommentHandler) ListComments(c echo.Context) error {
+	comments, err := h.commentSvc.ListComments(c.Request().Context(), c.Param("slug"), c.QueryParam("password"))
+	if err != nil {
+		if errors.Is(err, services.ErrPasteNotFound) {
+			return utils.SendError(c, http.StatusNotFound, "paste not found")
+		}
+		return utils.SendError(c, http.StatusInternalServerError, "failed to list comments")
+	}
+	return utils.SendSuccess(c, http.StatusOK, comments, "comments retrieved successfully")
+}
+
+// CreateComment godoc
+//
+//	@Summary		Comment on a paste
+//	@Description	Add a comment to the paste shared under slug, optionally replying to another comment on it or anchored to a range of its lines. line_end defaults to line_start. The caller must be allowed to view the paste, giving its password when it has one, and its owner must not have disabled comments.
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			slug		path		string				true	"Paste slug"
+//	@Param			password	query		string				false	"Password for password-protected pastes"
+//	@Param			request		body		models.CommentInput	true	"Comment"
+//	@Success		201			{object}	models.Comment		"Created comment"
+//	@Failure		400			{object}	map[string]string	"Invalid body, line range or parent"
+//	@Failure		403			{object}	map[string]string	"Comments are disabled on this paste"
+//	@Failure		404			{object}	map[string]string	"Paste not found or not visible to the caller"
+//	@Failure		500			{object}	map[string]string	"Unable to create comment"
+//	@Security		BearerAuth
+//	@Router			/p/{slug}/comments [post]
+func (h *CommentHandler) CreateComment(c echo.Context) error {
+	var input models.CommentInput
+	if err := c.Bind(&input); err != nil {
+		return utils.SendError(c, http.StatusBadRequest, "invalid request")
+	}
+
+	comment, err := h.commentSvc.CreateComment(c.Request().Context(), c.Param("slug"), c.QueryParam("password"), &input)
+	if err != nil {
+		return h.commentFailed(c, err, "failed to create comment")
+	}
+	return utils.SendSuccess(c, http.StatusCreated, comment, "comment created successfully")
+}
+
+// UpdateComment godoc
+//
+//	@Summary		Edit a comment
+//	@Description	Replace the body of one of the caller's comments. Comments can't be edited once the paste owner disables comments, or by callers no longer allowed to view the paste.
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		string					true	"Comment ID"
+//	@Param			password	query		string					false	"Password for password-protected pastes"
+//	@Param			request		body		models.CommentUpdate	true	"New body"
+//	@Success		200			{object}	models.Comment			"Updated comment"
+//	@Failure		400			{object}	map[string]string		"Invalid comment ID or body"
+//	@Failure		403			{object}	map[string]string		"Not the author, or comments are disabled"
+//	@Failure		404			{object}	map[string]string		"Comment not found, or its paste is not visible to the caller"
+//	@Failure		500			{object}	map[string]string		"Unable to update comment"
+//	@Security		BearerAuth
+//	@Router			/comments/{id} [put]
+func (h *CommentHandler) UpdateComment(c echo.Context) error {
+	commentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.SendError(c, http.StatusBadRequest, "invalid comment id")
+	}
+	var input models.CommentUpdate
+	if err := c.Bind(&input); err != nil {
+		return utils.SendError(c, http.StatusBadRequest, "invalid request")
+	}
+
+	comment, err := h.commentSvc.UpdateComment(c.Request().Context(), commentID, c.QueryParam("password"), &input)
+	if err != nil {
+		return h.commentFailed(c, err, "failed to update comment")
+	}
+	return utils.SendSuccess(c, http.StatusOK, comment, "comment updated successfully")
+}
+
+// DeleteComment godoc
+//
+//	@Summary		Delete a comment
+//	@Description	Delete one of the caller's comments on a paste the caller is still allowed to view, or any comment on one of the caller's pastes. Replies to it are kept.
+//	@Tags			comments
+//	@Produce		json
+//	@Param			id			path		string				true	"Comment ID"
+//	@Param			password	query		string				false	"Password for password-protected pastes"
+//	@Success		200			{object}	map[string]string	"Comment deleted"
+//	@Failure		400			{object}	map[string]string	"Invalid comment ID"
+//	@Failure		403			{object}	map[string]string	"Neither the author nor the paste owner"
+//	@Failure		404			{object}	map[string]string	"Comment not found, or its paste is not visible to the caller"
+//	@Failure		500			{object}	map[string]string	"Unable to delete comment"
+//	@Security		BearerAuth
+//	@Router			/comments/{id} [delete]
+func (h *CommentHandler) DeleteComment(c echo.Context) error {
+	commentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.SendError(c, http.StatusBadRequest, "invalid comment id")
+	}
+
+	if err := h.commentSvc.DeleteComment(c.Request().Context(), commentID, c.QueryParam("password")); err != nil {
+		return h.commentFailed(c, err, "failed to delete comment")
+	}
+	return utils.SendSuccess(c, http.StatusOK, nil, "comment deleted successfully")
+}
+
+// commentFailed maps a comment service error to its response, falling back
+// to a 500 with msg.
+func (h *CommentHandler) commentFailed(c echo.Context, err error, msg string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidComment):
+		return utils.SendError(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrCommentsDisabled), errors.Is(err, services.ErrCommentForbidden):
+		return utils.SendError(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrPasteNotFound):
+		return utils.SendError(c, http.StatusNotFound, "paste not found")
+	case errors.Is(err, services.ErrCommentNotFound):
+		return utils.SendError(c, http.StatusNotFound, err.Error())
+	}
+	logging.FromContext(c.Request().Context(), h.logger).Error().Err(err).Msg(msg)
+	return utils.SendError(c, http.StatusInternalServerError, msg)
+}
@@ -1,6 +1,11 @@
 package handlers
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"pastebin/internal/ratelimit"
+
 	"github.com/labstack/echo/v4"
 	echoSwagger "github.com/swaggo/echo-swagger"
 )
@@ -10,25 +15,47 @@ type Handlers struct {
 	pasteHandler     *PasteHandler
 	analyticsHandler *AnalyticsHandler
 	profileHandler   *ProfileHandler
+	healthHandler    *HealthHandler
+	adminHandler     *AdminHandler
+	commentHandler   *CommentHandler
 }
 
-func NewHandlers(authHandler *AuthHandler, pasteHandler *PasteHandler, analyticsHandler *AnalyticsHandler, profileHandler *ProfileHandler) *Handlers {
+func NewHandlers(authHandler *AuthHandler, pasteHandler *PasteHandler, analyticsHandler *AnalyticsHandler, profileHandler *ProfileHandler, healthHandler *HealthHandler, adminHandler *AdminHandler, commentHandler *CommentHandler) *Handlers {
 	return &Handlers{
 		authHandler:      authHandler,
 		pasteHandler:     pasteHandler,
 		analyticsHandler: analyticsHandler,
 		profileHandler:   profileHandler,
+		healthHandler:    healthHandler,
+		adminHandler:     adminHandler,
+		commentHandler:   commentHandler,
 	}
 
 }
 
-func (h *Handlers) RegisterRoutes(e *echo.Echo, authMiddleware echo.MiddlewareFunc) {
+func (h *Handlers) RegisterRoutes(e *echo.Echo, authMiddleware, adminMiddleware echo.MiddlewareFunc, limiter *ratelimit.Limiter) {
+	publicRead := limiter.Middleware(ratelimit.GroupPublicRead)
+	authLimit := limiter.Middleware(ratelimit.GroupAuth)
+	read := limiter.Middleware(ratelimit.GroupRead)
+	write := limiter.Middleware(ratelimit.GroupWrite)
+	passwordCheck := limiter.MiddlewareIf(ratelimit.GroupPasswordCheck, givesPassword)
+	forkPasswordCheck := limiter.MiddlewareIf(ratelimit.GroupPasswordCheck, givesBodyPassword("password"))
+	updatePasswordCheck := limiter.MiddlewareIf(ratelimit.GroupPasswordCheck, givesBodyPassword("current_password"))
+
 	// Public routes (no authentication required)
-	e.POST("/register", h.authHandler.Register)
-	e.POST("/login", h.authHandler.Login)
-	e.GET("/paste/:id", h.pasteHandler.GetPasteByID) // Allow public viewing by UUID
-	e.GET("/p/:slug", h.pasteHandler.GetPublicPaste) // Public sharing by slug
-	e.GET("/raw/:slug", h.pasteHandler.GetRawPaste)  // Raw content by slug
+	e.POST("/register", h.authHandler.Register, authLimit)
+	e.POST("/login", h.authHandler.Login, authLimit)
+	e.GET("/paste/:id", h.pasteHandler.GetPasteByID, publicRead, passwordCheck) // Allow public viewing by UUID
+	e.GET("/p/:slug", h.pasteHandler.GetPublicPaste, publicRead, passwordCheck) // Public sharing by slug
+	e.GET("/raw/:slug", h.pasteHandler.GetRawPaste, publicRead, passwordCheck)  // Raw content by slug
+	e.GET("/languages", h.pasteHandler.ListLanguages, publicRead)
+	e.GET("/explore", h.pasteHandler.Explore, publicRead)
+	e.GET("/trending", h.pasteHandler.Trending, publicRead)
+	e.GET("/p/:slug/comments", h.commentHandler.ListComments, publicRead, passwordCheck)
+
+	// Liveness and readiness probes
+	e.GET("/healthz", h.healthHandler.Liveness)
+	e.GET("/readyz", h.healthHandler.Readiness)
 
 	// Swagger documentation
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
@@ -40,16 +67,62 @@ func (h *Handlers) RegisterRoutes(e *echo.Echo, authMiddleware echo.MiddlewareFu
 
 	// Protected routes (require authentication)
 	protected := e.Group("", authMiddleware)
-	protected.POST("/paste", h.pasteHandler.CreatePaste)
-	protected.PUT("/paste/:id", h.pasteHandler.UpdatePaste)
-	protected.DELETE("/paste/:id", h.pasteHandler.DeletePasteByID)
-	protected.GET("/pastes", h.pasteHandler.GetAllPastes)
-	protected.GET("/paste/filter", h.pasteHandler.FilterPastes)
-	protected.GET("/analytics", h.analyticsHandler.GetAllAnalytics)
-	protected.GET("/analytics/user", h.analyticsHandler.GetAllAnalyticsByUser)
-	protected.GET("/analytics/paste", h.analyticsHandler.GetAnalyticsByPasteID)
-	protected.POST("/create-analytics", h.analyticsHandler.CreateAnalytics)
-	protected.GET("/analytics/:id", h.analyticsHandler.GetAnalyticsByID)
-	protected.GET("/profile", h.profileHandler.GetProfileHandler)
-	protected.PUT("/profile", h.profileHandler.UpdateProfileHandler)
+	protected.POST("/paste", h.pasteHandler.CreatePaste, write)
+	protected.PUT("/paste/:id", h.pasteHandler.UpdatePaste, write, updatePasswordCheck)
+	protected.PUT("/paste/:id/slug", h.pasteHandler.RenameSlug, write)
+	protected.GET("/paste/:id/forks", h.pasteHandler.ListForks, read)
+	protected.POST("/p/:slug/fork", h.pasteHandler.ForkPaste, write, forkPasswordCheck)
+	protected.PUT("/p/:slug/star", h.pasteHandler.StarPaste, write, passwordCheck)
+	protected.DELETE("/p/:slug/star", h.pasteHandler.UnstarPaste, write)
+	protected.GET("/stars", h.pasteHandler.ListStars, read)
+	protected.DELETE("/paste/:id", h.pasteHandler.DeletePasteByID, write)
+	protected.POST("/p/:slug/comments", h.commentHandler.CreateComment, write, passwordCheck)
+	protected.PUT("/comments/:id", h.commentHandler.UpdateComment, write, passwordCheck)
+	protected.DELETE("/comments/:id", h.commentHandler.DeleteComment, write, passwordCheck)
+	protected.GET("/pastes", h.pasteHandler.GetAllPastes, read)
+	protected.GET("/paste/filter", h.pasteHandler.FilterPastes, read)
+	protected.GET("/analytics", h.analyticsHandler.GetAllAnalytics, read)
+	protected.GET("/analytics/user", h.analyticsHandler.GetAllAnalyticsByUser, read)
+	protected.GET("/analytics/paste", h.analyticsHandler.GetAnalyticsByPasteID, read)
+	protected.GET("/analytics/summary", h.analyticsHandler.GetAnalyticsSummary, read)
+	protected.POST("/create-analytics", h.analyticsHandler.CreateAnalytics, write)
+	protected.GET("/analytics/:id", h.analyticsHandler.GetAnalyticsByID, read)
+	protected.GET("/profile", h.profileHandler.GetProfileHandler, read)
+	protected.PUT("/profile", h.profileHandler.UpdateProfileHandler, write)
+
+	// Admin routes (require an authenticated admin)
+	admin := protected.Group("/admin", adminMiddleware)
+	admin.PUT("/users/:id/storage-quota", h.adminHandler.SetStorageQuota, write)
+	admin.GET("/secret-rules", h.adminHandler.ListSecretRules, read)
+	admin.PUT("/secret-rules/:id", h.adminHandler.SaveSecretRule, write)
+	admin.DELETE("/secret-rules/:id", h.adminHandler.DeleteSecretRule, write)
+}
+
+// givesPassword reports whether a request gives a paste password to check in
+// its query.
+func givesPassword(c echo.Context) bool {
+	return c.QueryParam("password") != ""
+}
+
+// givesBodyPassword returns a check for requests whose JSON body gives a paste
+// password in field. The body is put back for the handler to bind, including
+// any error reading it hit.
+func givesBodyPassword(field string) func(echo.Context) bool {
+	return func(c echo.Context) bool {
+		req := c.Request()
+		if req.Body == nil {
+			return false
+		}
+		body, _ := io.ReadAll(req.Body)
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return false
+		}
+		var password string
+		return json.Unmarshal(fields[field], &password) == nil && password != ""
+	}
 }
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestGivesBodyPassword(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		body  string
+		want  bool
+	}{
+		{"fork password", "password", `{"password":"guess","title":"copy"}`, true},
+		{"current password", "current_password", `{"current_password":"guess","password":"new"}`, true},
+		{"new password only", "current_password", `{"password":"new"}`, false},
+		{"empty password", "password", `{"password":""}`, false},
+		{"no body", "password", ``, false},
+		{"not json", "password", `password=guess`, false},
+		{"not a string", "password", `{"password":123}`, false},
+	}
+	e := echo.New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/p/abc/fork", strings.NewReader(tt.body))
+			c := e.NewContext(req, httptest.NewRecorder())
+			if got := givesBodyPassword(tt.field)(c); got != tt.want {
+				t.Fatalf("givesBodyPassword(%q) = %v, want %v", tt.field, got, tt.want)
+			}
+			// The handler still reads the whole body
+			rest, err := io.ReadAll(c.Request().Body)
+			if err != nil || string(rest) != tt.body {
+				t.Fatalf("body after check = %q, %v; want %q", rest, err, tt.body)
+			}
+		})
+	}
+}
+
+func TestGivesBodyPasswordKeepsBodyLimitError(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/p/abc/fork", strings.NewReader(`{"password":"guess"}`))
+	rec := httptest.NewRecorder()
+	req.Body = http.MaxBytesReader(rec, req.Body, 8)
+	c := e.NewContext(req, rec)
+	if givesBodyPassword("password")(c) {
+		t.Fatal("givesBodyPassword() = true for a truncated body")
+	}
+	_, err := io.ReadAll(c.Request().Body)
+	var maxErr *http.MaxBytesError
+	if !errors.As(err, &maxErr) {
+		t.Fatalf("reading the body error = %v, want *http.MaxBytesError", err)
+	}
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+	"pastebin/internal/health"
+	"pastebin/pkg/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{
+		checker: checker,
+	}
+}
+
+// Liveness godoc
+//
+//	@Summary		Liveness probe
+//	@Description	Reports that the process is up and serving HTTP. It does not check dependencies.
+//	@Tags			health
+//	@Produce		json
+//	@Success		200	{object}	utils.APIResponse	"Alive"
+//	@Router			/healthz [get]
+func (h *HealthHandler) Liveness(c echo.Context) error {
+	return utils.SendSuccess(c, http.StatusOK, map[string]string{"status": health.StatusOK}, "alive")
+}
+
+// Readiness godoc
+//
+//	@Summary		Readiness probe
+//	@Description	Checks the database, the schema migration version and background workers. Returns 503 while shutting down or when a critical dependency fails.
+//	@Tags			health
+//	@Produce		json
+//	@Success		200	{object}	health.Report		"Ready"
+//	@Failure		503	{object}	health.Report		"Not ready"
+//	@Router			/readyz [get]
+func (h *HealthHandler) Readiness(c echo.Context) error {
+	report := h.checker.Check(c.Request().Context())
+	if report.Status == health.StatusFail {
+		return c.JSON(http.StatusServiceUnavailable, utils.APIResponse{
+			Success: false,
+			Data:    report,
+			Error:   "not ready",
+		})
+	}
+	return utils.SendSuccess(c, http.StatusOK, report, "ready")
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// wantsHTML reports whether the client prefers HTML to JSON, going by the
+// quality values in Accept. Browsers list text/html first; API clients that
+// send no Accept header, */* or application/json keep getting JSON.
+func wantsHTML(c echo.Context) bool {
+	html, json := acceptQuality(c.Request().Header.Get(echo.HeaderAccept))
+	return html > 0 && html > json
+}
+
+// acceptQuality returns the quality Accept gives text/html and application/json,
+// taking the most specific range that matches each.
+func acceptQuality(accept string) (html, json float64) {
+	if strings.TrimSpace(accept) == "" {
+		return 0, 1
+	}
+	// Specificity of the range each quality came from: 3 exact, 2 type/*, 1 */*
+	var htmlRank, jsonRank int
+	for _, part := range strings.Split(accept, ",") {
+		mediaRange, params, _ := strings.Cut(part, ";")
+		mediaRange = strings.ToLower(strings.TrimSpace(mediaRange))
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if rank := rangeRank(mediaRange, "text/html"); rank > htmlRank {
+			html, htmlRank = q, rank
+		}
+		if rank := rangeRank(mediaRange, "application/json"); rank > jsonRank {
+			json, jsonRank = q, rank
+		}
+	}
+	// A bare */* is an API client that takes anything, so JSON wins ties
+	if htmlRank <= 1 && jsonRank <= 1 {
+		return 0, json
+	}
+	return html, json
+}
+
+func rangeRank(mediaRange, mediaType string) int {
+	switch mediaRange {
+	case mediaType:
+		return 3
+	case mediaType[:strings.Index(mediaType, "/")] + "/*":
+		return 2
+	case "*/*":
+		return 1
+	}
+	return 0
+}
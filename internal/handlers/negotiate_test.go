@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestWantsHTML(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		want   bool
+	}{
+		{"no header", "", false},
+		{"any type", "*/*", false},
+		{"json", "application/json", false},
+		{"html", "text/html", true},
+		{"browser", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8", true},
+		{"curl", "*/*", false},
+		{"json preferred", "application/json, text/html;q=0.5", false},
+		{"html preferred", "application/json;q=0.5, text/html", true},
+		{"equal quality", "text/html, application/json", false},
+		{"html refused", "text/html;q=0", false},
+		{"text wildcard", "text/*", true},
+		{"exact range beats wildcard", "text/*;q=0.9, text/html;q=0", false},
+		{"case and spacing", " TEXT/HTML ; q=1 ", true},
+		{"malformed quality", "text/html;q=high", true},
+		{"other types only", "image/png", false},
+	}
+	e := echo.New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/p/abc", nil)
+			if tt.accept != "" {
+				req.Header.Set(echo.HeaderAccept, tt.accept)
+			}
+			c := e.NewContext(req, httptest.NewRecorder())
+			if got := wantsHTML(c); got != tt.want {
+				t.Fatalf("wantsHTML(%q) = %v, want %v", tt.accept, got, tt.want)
+			}
+		})
+	}
+}
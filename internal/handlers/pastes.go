@@ -1,12 +1,22 @@
 package handlers
 
 import (
+	"errors"
+	"html/template"
+	"mime"
 	"net/http"
+	"net/url"
 	"pastebin/internal/auth"
+	"pastebin/internal/codec"
+	"pastebin/internal/logging"
+	"pastebin/internal/markdown"
 	"pastebin/internal/models"
 	"pastebin/internal/services"
+	"pastebin/internal/viewer"
+	"pastebin/pkg/clientcrypt"
 	"pastebin/pkg/utils"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,14 +24,34 @@ import (
 	"github.com/rs/zerolog"
 )
 
+// Headers carrying the metadata of client-encrypted pastes served raw.
+const (
+	headerEncryptionAlgorithm = "X-Encryption-Algorithm"
+	headerEncryptionNonce     = "X-Encryption-Nonce"
+)
+
+const (
+	// renderHTML is the render mode that shows Markdown pastes as HTML.
+	renderHTML = "html"
+	// includeRenderedHTML asks for PasteOutput.RenderedHTML.
+	includeRenderedHTML = "rendered_html"
+	markdownLanguage    = "markdown"
+)
+
+var errNotMarkdown = errors.New("only Markdown pastes can be rendered")
+
 type PasteHandler struct {
 	pasteSvc *services.PasteService
+	viewer   *viewer.Viewer
+	markdown *markdown.Renderer
 	logger   zerolog.Logger
 }
 
-func NewPasteHandler(pasteSvc *services.PasteService, logger zerolog.Logger) *PasteHandler {
+func NewPasteHandler(pasteSvc *services.PasteService, viewer *viewer.Viewer, markdown *markdown.Renderer, logger zerolog.Logger) *PasteHandler {
 	return &PasteHandler{
 		pasteSvc: pasteSvc,
+		viewer:   viewer,
+		markdown: markdown,
 		logger:   logger,
 	}
 }
@@ -29,21 +59,28 @@ func NewPasteHandler(pasteSvc *services.PasteService, logger zerolog.Logger) *Pa
 // CreatePaste godoc
 //
 //	@Summary		Create a new paste
-//	@Description	Create a new paste with optional expiration
+//	@Description	Create a new paste with optional expiration. slug requests a custom slug instead of a generated one. With encryption set, content is base64 ciphertext encrypted by the client (see pkg/clientcrypt); it is stored as-is, never highlighted, and its language is ignored. language is normalized against GET /languages, so "golang" is stored as "go"; when omitted it is detected from the content and the title as a file name. Content is scanned for credentials and any found are listed in secret_findings. For public pastes the secret scan policy may instead make the paste private or reject it with 422; list a finding's fingerprint or rule ID in secret_allowlist to accept it as a false positive. redact masks emails, IP addresses, bearer tokens, JWTs, card numbers and matches of redact_patterns with placeholders such as <IP_1> before storing the content, and redactions counts what was masked by category.
 //	@Tags			pastes
 //	@Accept			json
 //	@Produce		json
 //	@Param			request		body		models.PasteInput		true	"Paste data"
 //	@Param			expires_in	query		string					false	"Expiration duration (e.g., '24h', '7d')"
 //	@Success		201			{object}	models.PasteOutput		"Created paste with shareable URL"
-//	@Failure		400			{object}	map[string]string		"Invalid request"
+//	@Failure		400			{object}	map[string]string		"Invalid request, invalid slug, unknown language, invalid secret allowlist or redact pattern, or malformed encrypted content"
+//	@Failure		409			{object}	map[string]string		"Slug already taken"
+//	@Failure		413			{object}	map[string]string		"Paste too large or storage quota exceeded"
+//	@Failure		422			{object}	utils.APIResponse		"Secrets found, listed in data"
+//	@Failure		429			{object}	map[string]string		"Rate limit or daily quota exceeded"
 //	@Failure		500			{object}	map[string]string		"Unable to create paste"
 //	@Security		BearerAuth
 //	@Router			/paste [post]
 func (p *PasteHandler) CreatePaste(c echo.Context) error {
 	var createPaste models.PasteInput
 	if err := c.Bind(&createPaste); err != nil {
-		p.logger.Error().Err(err).Msg("failed to bind create paste")
+		if tooLarge, sendErr := bodyTooLarge(c, err); tooLarge {
+			return sendErr
+		}
+		logging.FromContext(c.Request().Context(), p.logger).Error().Err(err).Msg("failed to bind create paste")
 		return utils.SendError(c, http.StatusBadRequest, "invalid request")
 	}
 
@@ -64,25 +101,48 @@ func (p *PasteHandler) CreatePaste(c echo.Context) error {
 	ctx := c.Request().Context()
 	paste, err := p.pasteSvc.CreatePaste(ctx, &createPaste)
 	if err != nil {
-		p.logger.Error().Err(err).Msg("failed to create paste")
-		return utils.SendError(c, http.StatusInternalServerError, "failed to create paste")
+		return p.createFailed(c, err)
 	}
 
 	return utils.SendSuccess(c, http.StatusCreated, paste, "paste created successfully")
 }
 
+// createFailed answers a request that failed to create a paste.
+func (p *PasteHandler) createFailed(c echo.Context, err error) error {
+	if errors.Is(err, services.ErrDailyQuotaExceeded) {
+		return utils.SendError(c, http.StatusTooManyRequests, err.Error())
+	}
+	if errors.Is(err, services.ErrEncryptionRejected) || errors.Is(err, services.ErrInvalidSlug) || errors.Is(err, services.ErrUnknownLanguage) || errors.Is(err, services.ErrInvalidSecretAllowlist) || errors.Is(err, services.ErrInvalidRedactPattern) {
+		return utils.SendError(c, http.StatusBadRequest, err.Error())
+	}
+	if rejected, sendErr := secretsRejected(c, err); rejected {
+		return sendErr
+	}
+	if errors.Is(err, services.ErrSlugTaken) {
+		return utils.SendError(c, http.StatusConflict, err.Error())
+	}
+	if errors.Is(err, services.ErrPasteTooLarge) || errors.Is(err, services.ErrStorageQuotaExceeded) {
+		return utils.SendError(c, http.StatusRequestEntityTooLarge, err.Error())
+	}
+	logging.FromContext(c.Request().Context(), p.logger).Error().Err(err).Msg("failed to create paste")
+	return utils.SendError(c, http.StatusInternalServerError, "failed to create paste")
+}
+
 // UpdatePaste godoc
 //
 //	@Summary		Update a paste
-//	@Description	Update an existing paste by ID
+//	@Description	Update an existing paste by ID. Changing the password of a paste whose content is encrypted without sending new content needs current_password. An empty language asks for it to be detected from the content. The paste is scanned for credentials again when its content or secret_allowlist changes or it becomes public, as on creation. comments_enabled set to false stops new comments and edits; existing comments stay readable.
 //	@Tags			pastes
 //	@Accept			json
 //	@Produce		json
-//	@Param			id		path		string				true	"Paste ID"
-//	@Param			request	body		models.PatchPaste	true	"Paste update data"
-//	@Success		200		{object}	map[string]string	"Paste updated successfully"
-//	@Failure		400		{object}	map[string]string	"Invalid request"
-//	@Failure		500		{object}	map[string]string	"Unable to update paste"
+//	@Param			id		path		string						true	"Paste ID"
+//	@Param			request	body		models.PatchPaste			true	"Paste update data"
+//	@Success		200		{object}	models.PasteUpdateOutput	"Paste updated successfully, with any secrets found"
+//	@Failure		400		{object}	map[string]string			"Invalid request, current password required, unknown language, invalid secret allowlist or malformed encrypted content"
+//	@Failure		403		{object}	map[string]string			"Invalid current password"
+//	@Failure		413		{object}	map[string]string			"Paste too large or storage quota exceeded"
+//	@Failure		422		{object}	utils.APIResponse			"Secrets found, listed in data"
+//	@Failure		500		{object}	map[string]string			"Unable to update paste"
 //	@Security		BearerAuth
 //	@Router			/paste/{id} [put]
 func (p *PasteHandler) UpdatePaste(c echo.Context) error {
@@ -98,20 +158,256 @@ func (p *PasteHandler) UpdatePaste(c echo.Context) error {
 
 	var patchPaste models.PatchPaste
 	if err := c.Bind(&patchPaste); err != nil {
+		if tooLarge, sendErr := bodyTooLarge(c, err); tooLarge {
+			return sendErr
+		}
 		return utils.SendError(c, http.StatusBadRequest, "invalid request")
 	}
 
 	ctx := c.Request().Context()
-	if err := p.pasteSvc.UpdatePaste(ctx, pasteID, &patchPaste); err != nil {
+	findings, err := p.pasteSvc.UpdatePaste(ctx, pasteID, &patchPaste)
+	if err != nil {
+		if errors.Is(err, services.ErrPasteTooLarge) || errors.Is(err, services.ErrStorageQuotaExceeded) {
+			return utils.SendError(c, http.StatusRequestEntityTooLarge, err.Error())
+		}
+		if errors.Is(err, services.ErrCurrentPasswordRequired) || errors.Is(err, services.ErrEncryptionRejected) || errors.Is(err, services.ErrUnknownLanguage) || errors.Is(err, services.ErrInvalidSecretAllowlist) {
+			return utils.SendError(c, http.StatusBadRequest, err.Error())
+		}
+		if rejected, sendErr := secretsRejected(c, err); rejected {
+			return sendErr
+		}
+		if errors.Is(err, services.ErrInvalidCurrentPassword) {
+			return utils.SendError(c, http.StatusForbidden, err.Error())
+		}
 		return utils.SendError(c, http.StatusInternalServerError, "failed to update paste")
 	}
-	return utils.SendSuccess(c, http.StatusOK, nil, "paste updated successfully")
+	return utils.SendSuccess(c, http.StatusOK, models.PasteUpdateOutput{SecretFindings: findings}, "paste updated successfully")
+}
+
+// secretsRejected answers with 422 and the findings when err is a
+// services.SecretsError. It reports whether it did.
+func secretsRejected(c echo.Context, err error) (bool, error) {
+	var secretsErr *services.SecretsError
+	if !errors.As(err, &secretsErr) {
+		return false, nil
+	}
+	return true, c.JSON(http.StatusUnprocessableEntity, utils.APIResponse{
+		Success: false,
+		Error:   secretsErr.Error(),
+		Data:    secretsErr.Findings,
+	})
+}
+
+// RenameSlug godoc
+//
+//	@Summary		Rename a paste's slug
+//	@Description	Give a paste a new slug. The old slug keeps working and redirects to the new one.
+//	@Tags			pastes
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string				true	"Paste ID"
+//	@Param			request	body		models.SlugInput	true	"New slug"
+//	@Success		200		{object}	models.SlugOutput	"New slug and URL"
+//	@Failure		400		{object}	map[string]string	"Invalid paste ID or slug"
+//	@Failure		409		{object}	map[string]string	"Slug already taken"
+//	@Failure		500		{object}	map[string]string	"Unable to rename slug"
+//	@Security		BearerAuth
+//	@Router			/paste/{id}/slug [put]
+func (p *PasteHandler) RenameSlug(c echo.Context) error {
+	pasteID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.SendError(c, http.StatusBadRequest, "invalid paste id")
+	}
+
+	var input models.SlugInput
+	if err := c.Bind(&input); err != nil {
+		return utils.SendError(c, http.StatusBadRequest, "invalid request")
+	}
+
+	ctx := c.Request().Context()
+	renamed, err := p.pasteSvc.RenameSlug(ctx, pasteID, input.Slug)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSlug) {
+			return utils.SendError(c, http.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, services.ErrSlugTaken) {
+			return utils.SendError(c, http.StatusConflict, err.Error())
+		}
+		return utils.SendError(c, http.StatusInternalServerError, "failed to rename slug")
+	}
+	return utils.SendSuccess(c, http.StatusOK, renamed, "slug renamed successfully")
+}
+
+// pagination parses the limit and offset query parameters, defaulting to 10
+// and 0.
+func pagination(c echo.Context) (limit, offset int, err error) {
+	limit = 10
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			return 0, 0, errors.New("invalid limit parameter")
+		}
+	}
+	if offsetStr := c.QueryParam("offset"); offsetStr != "" {
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			return 0, 0, errors.New("invalid offset parameter")
+		}
+	}
+	return limit, offset, nil
+}
+
+// ForkPaste godoc
+//
+//	@Summary		Fork a paste
+//	@Description	Create a paste owned by the caller with the content, language and title of the paste shared under slug, recording it in forked_from. The caller must be allowed to view the source, giving its password when it has one, and forks of private or password-protected pastes are private. A fork of a password-protected paste is protected by the same password. Client-encrypted pastes are forked as ciphertext. The fork counts against the caller's quotas and is scanned for secrets like any new paste.
+//	@Tags			pastes
+//	@Accept			json
+//	@Produce		json
+//	@Param			slug	path		string				true	"Slug of the paste to fork"
+//	@Param			request	body		models.ForkInput	false	"Source password and fork options"
+//	@Success		201		{object}	models.PasteOutput	"Created fork"
+//	@Failure		400		{object}	map[string]string	"Invalid request or slug"
+//	@Failure		404		{object}	map[string]string	"Paste not found or not visible to the caller"
+//	@Failure		409		{object}	map[string]string	"Slug already taken"
+//	@Failure		413		{object}	map[string]string	"Storage quota exceeded"
+//	@Failure		422		{object}	utils.APIResponse	"Secrets found, listed in data"
+//	@Failure		429		{object}	map[string]string	"Rate limit or daily quota exceeded"
+//	@Failure		500		{object}	map[string]string	"Unable to fork paste"
+//	@Security		BearerAuth
+//	@Router			/p/{slug}/fork [post]
+func (p *PasteHandler) ForkPaste(c echo.Context) error {
+	var input models.ForkInput
+	if err := c.Bind(&input); err != nil {
+		return utils.SendError(c, http.StatusBadRequest, "invalid request")
+	}
+
+	fork, err := p.pasteSvc.ForkPaste(c.Request().Context(), c.Param("slug"), &input)
+	if err != nil {
+		if errors.Is(err, services.ErrPasteNotFound) {
+			return utils.SendError(c, http.StatusNotFound, "paste not found")
+		}
+		return p.createFailed(c, err)
+	}
+	return utils.SendSuccess(c, http.StatusCreated, fork, "paste forked successfully")
+}
+
+// ListForks godoc
+//
+//	@Summary		List forks of a paste
+//	@Description	List the forks of one of the caller's pastes, newest first. Private forks of other users are counted in the paste's forks but not listed.
+//	@Tags			pastes
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string							true	"Paste ID"
+//	@Param			limit	query		int								false	"Number of forks to return (default: 10, max: 100)"
+//	@Param			offset	query		int								false	"Number of forks to skip (default: 0)"
+//	@Success		200		{object}	models.PaginatedPastesResponse	"Paginated list of forks"
+//	@Failure		400		{object}	map[string]string				"Invalid paste ID or pagination parameters"
+//	@Failure		404		{object}	map[string]string				"Paste not found"
+//	@Failure		500		{object}	map[string]string				"Unable to list forks"
+//	@Security		BearerAuth
+//	@Router			/paste/{id}/forks [get]
+func (p *PasteHandler) ListForks(c echo.Context) error {
+	pasteID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return utils.SendError(c, http.StatusBadRequest, "invalid paste id")
+	}
+	limit, offset, err := pagination(c)
+	if err != nil {
+		return utils.SendError(c, http.StatusBadRequest, err.Error())
+	}
+
+	forks, err := p.pasteSvc.ListForks(c.Request().Context(), pasteID, limit, offset)
+	if err != nil {
+		if errors.Is(err, services.ErrPasteNotFound) {
+			return utils.SendError(c, http.StatusNotFound, err.Error())
+		}
+		return utils.SendError(c, http.StatusInternalServerError, "failed to list forks")
+	}
+	return utils.SendSuccess(c, http.StatusOK, forks, "forks retrieved successfully")
+}
+
+// StarPaste godoc
+//
+//	@Summary		Star a paste
+//	@Description	Star the paste shared under slug so it shows in GET /stars. The caller must be allowed to view the paste, giving its password when it has one. Starring a paste again changes nothing.
+//	@Tags			stars
+//	@Produce		json
+//	@Param			slug		path		string				true	"Paste slug"
+//	@Param			password	query		string				false	"Password for password-protected pastes"
+//	@Success		200			{object}	map[string]string	"Paste starred"
+//	@Failure		404			{object}	map[string]string	"Paste not found or not visible to the caller"
+//	@Failure		500			{object}	map[string]string	"Unable to star paste"
+//	@Security		BearerAuth
+//	@Router			/p/{slug}/star [put]
+func (p *PasteHandler) StarPaste(c echo.Context) error {
+	if err := p.pasteSvc.StarPaste(c.Request().Context(), c.Param("slug"), c.QueryParam("password")); err != nil {
+		if errors.Is(err, services.ErrPasteNotFound) {
+			return utils.SendError(c, http.StatusNotFound, "paste not found")
+		}
+		return utils.SendError(c, http.StatusInternalServerError, "failed to star paste")
+	}
+	return utils.SendSuccess(c, http.StatusOK, nil, "paste starred successfully")
+}
+
+// UnstarPaste godoc
+//
+//	@Summary		Unstar a paste
+//	@Description	Remove the caller's star from the paste shared under slug. Succeeds when the paste was not starred.
+//	@Tags			stars
+//	@Produce		json
+//	@Param			slug	path		string				true	"Paste slug"
+//	@Success		200		{object}	map[string]string	"Star removed"
+//	@Failure		500		{object}	map[string]string	"Unable to unstar paste"
+//	@Security		BearerAuth
+//	@Router			/p/{slug}/star [delete]
+func (p *PasteHandler) UnstarPaste(c echo.Context) error {
+	if err := p.pasteSvc.UnstarPaste(c.Request().Context(), c.Param("slug")); err != nil {
+		return utils.SendError(c, http.StatusInternalServerError, "failed to unstar paste")
+	}
+	return utils.SendSuccess(c, http.StatusOK, nil, "paste unstarred successfully")
+}
+
+// ListStars godoc
+//
+//	@Summary		List starred pastes
+//	@Description	List the pastes the caller starred, most recently starred first unless sort_by says otherwise. Pastes that expired, were deleted or were made private by their owner since are left out. Content is not included.
+//	@Tags			stars
+//	@Produce		json
+//	@Param			languages	query		[]string						false	"Only pastes in these languages"	collectionFormat(multi)
+//	@Param			date_from	query		string							false	"Only pastes created at or after this time (RFC 3339)"
+//	@Param			date_to		query		string							false	"Only pastes created at or before this time (RFC 3339)"
+//	@Param			sort_by		query		string							false	"Sort column"	Enums(created_at, updated_at, views, stars, title)
+//	@Param			sort_order	query		string							false	"Sort order (default: desc)"	Enums(asc, desc)
+//	@Param			limit		query		int								false	"Number of pastes to return (default: 10, max: 100)"
+//	@Param			offset		query		int								false	"Number of pastes to skip (default: 0)"
+//	@Success		200			{object}	models.PaginatedPastesResponse	"Paginated list of starred pastes"
+//	@Failure		400			{object}	map[string]string				"Invalid filter or pagination parameters"
+//	@Failure		500			{object}	map[string]string				"Unable to list stars"
+//	@Security		BearerAuth
+//	@Router			/stars [get]
+func (p *PasteHandler) ListStars(c echo.Context) error {
+	var filter models.PasteFilters
+	if err := c.Bind(&filter); err != nil {
+		return utils.SendError(c, http.StatusBadRequest, "invalid request parameters")
+	}
+	limit, offset, err := pagination(c)
+	if err != nil {
+		return utils.SendError(c, http.StatusBadRequest, err.Error())
+	}
+
+	stars, err := p.pasteSvc.ListStars(c.Request().Context(), &filter, limit, offset)
+	if err != nil {
+		return utils.SendError(c, http.StatusInternalServerError, "failed to list stars")
+	}
+	return utils.SendSuccess(c, http.StatusOK, stars, "starred pastes retrieved successfully")
 }
 
 // GetAllPastes godoc
 //
 //	@Summary		Get all pastes for user
-//	@Description	Retrieve all pastes for the authenticated user with pagination
+//	@Description	Retrieve all pastes for the authenticated user with pagination. Content is not included; fetch a paste by ID or slug for it.
 //	@Tags			pastes
 //	@Accept			json
 //	@Produce		json
@@ -125,26 +421,9 @@ func (p *PasteHandler) UpdatePaste(c echo.Context) error {
 func (p *PasteHandler) GetAllPastes(c echo.Context) error {
 	userID, _ := auth.GetUserIDFromEchoContext(c) // Middleware ensures this succeeds
 
-	// Parse pagination parameters
-	limitStr := c.QueryParam("limit")
-	offsetStr := c.QueryParam("offset")
-
-	limit := 10 // default limit
-	if limitStr != "" {
-		var err error
-		limit, err = strconv.Atoi(limitStr)
-		if err != nil || limit < 1 {
-			return utils.SendError(c, http.StatusBadRequest, "invalid limit parameter")
-		}
-	}
-
-	offset := 0 // default offset
-	if offsetStr != "" {
-		var err error
-		offset, err = strconv.Atoi(offsetStr)
-		if err != nil || offset < 0 {
-			return utils.SendError(c, http.StatusBadRequest, "invalid offset parameter")
-		}
+	limit, offset, err := pagination(c)
+	if err != nil {
+		return utils.SendError(c, http.StatusBadRequest, err.Error())
 	}
 
 	result, err := p.pasteSvc.GetAllPastes(c.Request().Context(), userID, limit, offset)
@@ -158,15 +437,17 @@ func (p *PasteHandler) GetAllPastes(c echo.Context) error {
 // GetPasteByID godoc
 //
 //	@Summary		Get paste by ID
-//	@Description	Retrieve a specific paste by its ID. Password required for password-protected pastes if user is not the owner.
+//	@Description	Retrieve a specific paste by its ID. Password required for password-protected pastes if user is not the owner. Owners reading an encrypted paste without the password get empty content with content_locked set.
 //	@Tags			pastes
 //	@Accept			json
 //	@Produce		json
 //	@Param			id		path		string				true	"Paste ID"
 //	@Param			password	query		string				false	"Password for password-protected pastes"
+//	@Param			include		query		string				false	"rendered_html adds the sanitized HTML of a Markdown paste"
 //	@Success		200		{object}	models.PasteOutput	"Paste data"
-//	@Failure		400		{object}	map[string]string	"Invalid paste ID or missing password"
+//	@Failure		400		{object}	map[string]string	"Invalid paste ID, missing password or paste is not Markdown"
 //	@Failure		401		{object}	map[string]string	"Invalid password"
+//	@Failure		413		{object}	map[string]string	"Markdown too large to render"
 //	@Failure		500		{object}	map[string]string	"Unable to get paste"
 //	@Router			/paste/{id} [get]
 func (p *PasteHandler) GetPasteByID(c echo.Context) error {
@@ -206,6 +487,11 @@ func (p *PasteHandler) GetPasteByID(c echo.Context) error {
 		}
 		return utils.SendError(c, http.StatusInternalServerError, "failed to retrieve paste")
 	}
+	if includes(c, includeRenderedHTML) {
+		if paste.RenderedHTML, err = p.renderMarkdown(paste); err != nil {
+			return p.renderFailed(c, err, false)
+		}
+	}
 	return utils.SendSuccess(c, http.StatusOK, paste, "paste retrieved successfully")
 }
 
@@ -243,14 +529,19 @@ func (p *PasteHandler) DeletePasteByID(c echo.Context) error {
 // GetPublicPaste godoc
 //
 //	@Summary		Get public paste by slug
-//	@Description	Retrieve a public paste by its URL slug
+//	@Description	Retrieve a public paste by its URL slug. Clients preferring text/html in Accept, such as browsers, get a syntax-highlighted page instead of JSON. render=html always returns a page with a Markdown paste rendered to sanitized HTML. Slugs a paste was renamed from redirect to its current slug.
 //	@Tags			pastes
 //	@Accept			json
 //	@Produce		json
+//	@Produce		html
 //	@Param			slug	path		string				true	"Paste slug"
+//	@Param			render	query		string				false	"html renders a Markdown paste as a page"	Enums(html)
+//	@Param			include	query		string				false	"rendered_html adds the sanitized HTML of a Markdown paste"
 //	@Success		200		{object}	models.PasteOutput	"Paste data"
-//	@Failure		400		{object}	map[string]string	"Invalid slug"
+//	@Success		301		{string}	string				"Redirect to the paste's current slug"
+//	@Failure		400		{object}	map[string]string	"Invalid slug or render mode, or paste is not Markdown"
 //	@Failure		404		{object}	map[string]string	"Paste not found"
+//	@Failure		413		{object}	map[string]string	"Markdown too large to render"
 //	@Failure		500		{object}	map[string]string	"Unable to get paste"
 //	@Router			/p/{slug} [get]
 func (p *PasteHandler) GetPublicPaste(c echo.Context) error {
@@ -260,25 +551,51 @@ func (p *PasteHandler) GetPublicPaste(c echo.Context) error {
 	if slug == "" {
 		return utils.SendError(c, http.StatusBadRequest, "paste slug is required")
 	}
+	render := c.QueryParam("render")
+	if render != "" && render != renderHTML {
+		return utils.SendError(c, http.StatusBadRequest, "invalid render mode, use 'html'")
+	}
 
 	ctx := c.Request().Context()
+	c.Response().Header().Add(echo.HeaderVary, echo.HeaderAccept)
 	paste, err := p.pasteSvc.GetPasteBySlug(ctx, slug, password)
 	if err != nil {
-		return utils.SendError(c, http.StatusNotFound, "paste not found")
+		if errors.Is(err, services.ErrPasteNotFound) {
+			return p.redirectSlug(c, "/p/", slug)
+		}
+		return p.pasteNotFound(c)
 	}
 
+	if render == renderHTML {
+		rendered, err := p.renderMarkdown(paste)
+		if err != nil {
+			return p.renderFailed(c, err, true)
+		}
+		return p.sendPage(c, paste, password, template.HTML(rendered))
+	}
+	if wantsHTML(c) {
+		return p.sendPage(c, paste, password, "")
+	}
+	if includes(c, includeRenderedHTML) {
+		if paste.RenderedHTML, err = p.renderMarkdown(paste); err != nil {
+			return p.renderFailed(c, err, false)
+		}
+	}
 	return utils.SendSuccess(c, http.StatusOK, paste, "paste retrieved successfully")
 }
 
 // GetRawPaste godoc
 //
 //	@Summary		Get raw paste content by slug
-//	@Description	Retrieve raw text content of a public paste by its URL slug
+//	@Description	Retrieve raw text content of a public paste by its URL slug. Compressed pastes are sent with Content-Encoding when the client accepts it. Client-encrypted pastes are sent as application/octet-stream ciphertext with X-Encryption-Algorithm and X-Encryption-Nonce headers. Slugs a paste was renamed from redirect to its current slug.
 //	@Tags			pastes
 //	@Accept			json
 //	@Produce		text/plain
-//	@Param			slug	path		string				true	"Paste slug"
+//	@Produce		application/octet-stream
+//	@Param			slug		path		string				true	"Paste slug"
+//	@Param			download	query		bool				false	"Send as an attachment named after the slug and language"
 //	@Success		200		{string}	string				"Raw paste content"
+//	@Success		301		{string}	string				"Redirect to the paste's current slug"
 //	@Failure		400		{object}	map[string]string	"Invalid slug"
 //	@Failure		404		{object}	map[string]string	"Paste not found"
 //	@Failure		500		{object}	map[string]string	"Unable to get paste"
@@ -292,15 +609,176 @@ func (p *PasteHandler) GetRawPaste(c echo.Context) error {
 	ctx := c.Request().Context()
 	password := c.QueryParam("password")
 
-	paste, err := p.pasteSvc.GetPasteBySlug(ctx, slug, password)
+	stored, err := p.pasteSvc.GetStoredPasteBySlug(ctx, slug, password)
 	if err != nil {
+		if errors.Is(err, services.ErrPasteNotFound) {
+			return p.redirectSlug(c, "/raw/", slug)
+		}
 		return utils.SendError(c, http.StatusNotFound, "paste not found")
 	}
 
-	c.Response().Header().Set("Content-Type", "text/plain")
-	return c.String(http.StatusOK, paste.Content)
+	if c.QueryParam("download") != "" {
+		filename := viewer.Filename(slug, stored.Language)
+		if stored.Encryption != nil {
+			filename = slug + ".bin"
+		}
+		c.Response().Header().Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	}
+
+	// Client-encrypted pastes are only ever served as the ciphertext
+	if stored.Encryption != nil {
+		return p.sendCiphertext(c, stored)
+	}
+
+	// Serve compressed pastes as stored when the client can decode them
+	c.Response().Header().Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+	contentCodec := codec.Codec(stored.Codec)
+	if contentCodec != codec.Identity && codec.Accepts(c.Request().Header.Get(echo.HeaderAcceptEncoding), contentCodec) {
+		c.Response().Header().Set(echo.HeaderContentEncoding, string(contentCodec))
+		return c.Blob(http.StatusOK, echo.MIMETextPlainCharsetUTF8, stored.Data)
+	}
+	content, err := codec.Decode(contentCodec, stored.Data)
+	if err != nil {
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to decode paste content")
+		return utils.SendError(c, http.StatusInternalServerError, "failed to get paste")
+	}
+	return c.Blob(http.StatusOK, echo.MIMETextPlainCharsetUTF8, content)
 }
 
+// redirectSlug sends a permanent redirect to prefix plus the current slug of
+// the paste that used to be shared under oldSlug, keeping the query string,
+// or 404 when oldSlug was never one.
+func (p *PasteHandler) redirectSlug(c echo.Context, prefix, oldSlug string) error {
+	current, err := p.pasteSvc.ResolveSlugRedirect(c.Request().Context(), oldSlug)
+	if err != nil {
+		return p.pasteNotFound(c)
+	}
+	target := prefix + url.PathEscape(current)
+	if query := c.Request().URL.RawQuery; query != "" {
+		target += "?" + query
+	}
+	return c.Redirect(http.StatusMovedPermanently, target)
+}
+
+// pasteNotFound sends a 404 as an HTML page or JSON, whichever the client prefers.
+func (p *PasteHandler) pasteNotFound(c echo.Context) error {
+	if wantsHTML(c) {
+		return p.viewer.WriteError(c.Response(), http.StatusNotFound, "This paste does not exist, has expired or needs a password.")
+	}
+	return utils.SendError(c, http.StatusNotFound, "paste not found")
+}
+
+// sendPage renders paste as a syntax-highlighted HTML page, or shows rendered
+// Markdown when it is set. The raw, download and view links carry the
+// password the page was opened with.
+func (p *PasteHandler) sendPage(c echo.Context, paste *models.PasteOutput, password string, rendered template.HTML) error {
+	query := url.Values{}
+	if password != "" {
+		query.Set("password", password)
+	}
+	rawURL := "/raw/" + url.PathEscape(paste.Slug)
+	page := viewer.Page{Paste: paste, RawURL: rawURL, DownloadURL: rawURL, Rendered: rendered}
+	if len(query) > 0 {
+		page.RawURL += "?" + query.Encode()
+	}
+	if renderable(paste) {
+		viewQuery := url.Values{}
+		if password != "" {
+			viewQuery.Set("password", password)
+		}
+		if rendered == "" {
+			viewQuery.Set("render", renderHTML)
+		}
+		page.ViewURL = "/p/" + url.PathEscape(paste.Slug)
+		if len(viewQuery) > 0 {
+			page.ViewURL += "?" + viewQuery.Encode()
+		}
+	}
+	query.Set("download", "1")
+	page.DownloadURL += "?" + query.Encode()
+	if err := p.viewer.Write(c.Response(), http.StatusOK, page); err != nil {
+		logging.FromContext(c.Request().Context(), p.logger).Error().Err(err).Msg("failed to render paste page")
+		return utils.SendError(c, http.StatusInternalServerError, "failed to render paste")
+	}
+	return nil
+}
+
+// renderable reports whether paste is Markdown whose content can be read.
+func renderable(paste *models.PasteOutput) bool {
+	return paste.Language == markdownLanguage && paste.Encryption == nil && !paste.ContentLocked
+}
+
+// renderMarkdown renders a Markdown paste to sanitized HTML.
+func (p *PasteHandler) renderMarkdown(paste *models.PasteOutput) (string, error) {
+	if !renderable(paste) {
+		return "", errNotMarkdown
+	}
+	return p.markdown.Render(paste.Content)
+}
+
+// renderFailed sends why a paste could not be rendered, as an HTML page when
+// asPage is set and JSON otherwise.
+func (p *PasteHandler) renderFailed(c echo.Context, err error, asPage bool) error {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, markdown.ErrTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	case !errors.Is(err, errNotMarkdown):
+		logging.FromContext(c.Request().Context(), p.logger).Error().Err(err).Msg("failed to render markdown")
+		status = http.StatusInternalServerError
+		err = errors.New("failed to render paste")
+	}
+	if asPage {
+		return p.viewer.WriteError(c.Response(), status, err.Error())
+	}
+	return utils.SendError(c, status, err.Error())
+}
+
+// includes reports whether the comma-separated include query parameter
+// names field.
+func includes(c echo.Context, field string) bool {
+	for _, name := range strings.Split(c.QueryParam("include"), ",") {
+		if strings.TrimSpace(name) == field {
+			return true
+		}
+	}
+	return false
+}
+
+// sendCiphertext writes the raw ciphertext of a client-encrypted paste, with
+// the metadata needed to decrypt it in headers.
+func (p *PasteHandler) sendCiphertext(c echo.Context, stored *models.StoredContent) error {
+	content, err := codec.Decode(codec.Codec(stored.Codec), stored.Data)
+	if err != nil {
+		logging.FromContext(c.Request().Context(), p.logger).Error().Err(err).Msg("failed to decode paste content")
+		return utils.SendError(c, http.StatusInternalServerError, "failed to get paste")
+	}
+	ciphertext, err := clientcrypt.Ciphertext(string(content))
+	if err != nil {
+		logging.FromContext(c.Request().Context(), p.logger).Error().Err(err).Msg("stored ciphertext is malformed")
+		return utils.SendError(c, http.StatusInternalServerError, "failed to get paste")
+	}
+	c.Response().Header().Set(headerEncryptionAlgorithm, stored.Encryption.Algorithm)
+	c.Response().Header().Set(headerEncryptionNonce, stored.Encryption.Nonce)
+	return c.Blob(http.StatusOK, echo.MIMEOctetStream, ciphertext)
+}
+
+// FilterPastes godoc
+//
+//	@Summary		Filter the caller's pastes
+//	@Description	List the caller's live pastes in the given languages and creation dates, sorted as asked. Content is not included; fetch a paste by ID or slug for it.
+//	@Tags			pastes
+//	@Produce		json
+//	@Param			languages	query		[]string			false	"Only pastes in these languages"	collectionFormat(multi)
+//	@Param			date_from	query		string				false	"Only pastes created at or after this time (RFC 3339)"
+//	@Param			date_to		query		string				false	"Only pastes created at or before this time (RFC 3339)"
+//	@Param			sort_by		query		string				false	"Sort column (default: created_at)"	Enums(created_at, updated_at, views, stars, title)
+//	@Param			sort_order	query		string				false	"Sort order (default: desc)"	Enums(asc, desc)
+//	@Success		200			{array}		models.PasteOutput	"Matching pastes"
+//	@Failure		400			{object}	map[string]string	"Invalid filter parameters"
+//	@Failure		500			{object}	map[string]string	"Unable to filter pastes"
+//	@Security		BearerAuth
+//	@Router			/paste/filter [get]
 func (p *PasteHandler) FilterPastes(c echo.Context) error {
 	var filter models.PasteFilters
 	if err := c.Bind(&filter); err != nil {
@@ -313,3 +791,74 @@ func (p *PasteHandler) FilterPastes(c echo.Context) error {
 	}
 	return utils.SendSuccess(c, http.StatusOK, pastes, "filtered pastes retrieved successfully")
 }
+
+// Explore godoc
+//
+//	@Summary		Explore public pastes
+//	@Description	List recent public pastes, newest first. Pastes with a password, private pastes and pastes their owner marked unlisted never appear. Content is not included.
+//	@Tags			explore
+//	@Produce		json
+//	@Param			language	query		[]string						false	"Only pastes in these languages, as accepted by GET /languages"	collectionFormat(multi)
+//	@Param			limit		query		int								false	"Number of pastes to return (default: 10, max: 100)"
+//	@Param			offset		query		int								false	"Number of pastes to skip (default: 0)"
+//	@Success		200			{object}	models.PaginatedPastesResponse	"Paginated list of public pastes"
+//	@Failure		400			{object}	map[string]string				"Unknown language or invalid pagination parameters"
+//	@Failure		500			{object}	map[string]string				"Unable to list public pastes"
+//	@Router			/explore [get]
+func (p *PasteHandler) Explore(c echo.Context) error {
+	limit, offset, err := pagination(c)
+	if err != nil {
+		return utils.SendError(c, http.StatusBadRequest, err.Error())
+	}
+
+	pastes, err := p.pasteSvc.Explore(c.Request().Context(), c.QueryParams()["language"], limit, offset)
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownLanguage) {
+			return utils.SendError(c, http.StatusBadRequest, err.Error())
+		}
+		return utils.SendError(c, http.StatusInternalServerError, "failed to list public pastes")
+	}
+	return utils.SendSuccess(c, http.StatusOK, pastes, "public pastes retrieved successfully")
+}
+
+// Trending godoc
+//
+//	@Summary		Trending public pastes
+//	@Description	List the pastes of the explore feed viewed within the window, ranked by their views in it rather than their lifetime views. Views are counted in hourly buckets, the current hour included.
+//	@Tags			explore
+//	@Produce		json
+//	@Param			window		query		string							false	"Period to rank views over (default: 24h)"	Enums(24h, 7d)
+//	@Param			language	query		[]string						false	"Only pastes in these languages, as accepted by GET /languages"	collectionFormat(multi)
+//	@Param			limit		query		int								false	"Number of pastes to return (default: 10, max: 100)"
+//	@Param			offset		query		int								false	"Number of pastes to skip (default: 0)"
+//	@Success		200			{object}	models.TrendingPastesResponse	"Trending pastes"
+//	@Failure		400			{object}	map[string]string				"Invalid window, unknown language or invalid pagination parameters"
+//	@Failure		500			{object}	map[string]string				"Unable to list trending pastes"
+//	@Router			/trending [get]
+func (p *PasteHandler) Trending(c echo.Context) error {
+	limit, offset, err := pagination(c)
+	if err != nil {
+		return utils.SendError(c, http.StatusBadRequest, err.Error())
+	}
+
+	trending, err := p.pasteSvc.Trending(c.Request().Context(), c.QueryParam("window"), c.QueryParams()["language"], limit, offset)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTrendingWindow) || errors.Is(err, services.ErrUnknownLanguage) {
+			return utils.SendError(c, http.StatusBadRequest, err.Error())
+		}
+		return utils.SendError(c, http.StatusInternalServerError, "failed to list trending pastes")
+	}
+	return utils.SendSuccess(c, http.StatusOK, trending, "trending pastes retrieved successfully")
+}
+
+// ListLanguages godoc
+//
+//	@Summary		List supported languages
+//	@Description	List the languages pastes can be written in, with the aliases and file extensions accepted for each in the language field
+//	@Tags			pastes
+//	@Produce		json
+//	@Success		200	{array}	language.Language	"Supported languages"
+//	@Router			/languages [get]
+func (p *PasteHandler) ListLanguages(c echo.Context) error {
+	return utils.SendSuccess(c, http.StatusOK, p.pasteSvc.Languages(), "languages retrieved successfully")
+}
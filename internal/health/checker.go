@@ -0,0 +1,115 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"pastebin/internal/worker"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusFail     = "fail"
+)
+
+// CheckResult is the outcome of a single dependency check.
+type CheckResult struct {
+	Status    string          `json:"status"`
+	LatencyMS int64           `json:"latency_ms,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	Expected  *int64          `json:"expected_version,omitempty"`
+	Current   *int64          `json:"current_version,omitempty"`
+	Workers   []worker.Status `json:"workers,omitempty"`
+}
+
+// Report is the readiness breakdown served by /readyz.
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Checker runs the readiness checks. A failed database or migration check, or
+// a process that is shutting down, makes the report fail; a failed worker only
+// degrades it, since taking the instance out of rotation would not fix it.
+type Checker struct {
+	db              *pgxpool.Pool
+	readiness       *Readiness
+	workers         *worker.Group
+	expectedVersion int64
+	timeout         time.Duration
+}
+
+func NewChecker(db *pgxpool.Pool, readiness *Readiness, workers *worker.Group, expectedVersion int64, timeout time.Duration) *Checker {
+	return &Checker{
+		db:              db,
+		readiness:       readiness,
+		workers:         workers,
+		expectedVersion: expectedVersion,
+		timeout:         timeout,
+	}
+}
+
+func (c *Checker) Check(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	report := Report{Status: StatusOK, Checks: map[string]CheckResult{}}
+	record := func(name string, result CheckResult) {
+		report.Checks[name] = result
+		switch {
+		case result.Status == StatusFail:
+			report.Status = StatusFail
+		case result.Status == StatusDegraded && report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+
+	if c.readiness.Ready() {
+		record("lifecycle", CheckResult{Status: StatusOK})
+	} else {
+		record("lifecycle", CheckResult{Status: StatusFail, Error: "not accepting traffic (starting or shutting down)"})
+	}
+	record("database", c.checkDatabase(ctx))
+	record("migrations", c.checkMigrations(ctx))
+	record("workers", c.checkWorkers())
+	return report
+}
+
+func (c *Checker) checkDatabase(ctx context.Context) CheckResult {
+	start := time.Now()
+	if err := c.db.Ping(ctx); err != nil {
+		return CheckResult{Status: StatusFail, LatencyMS: time.Since(start).Milliseconds(), Error: err.Error()}
+	}
+	return CheckResult{Status: StatusOK, LatencyMS: time.Since(start).Milliseconds()}
+}
+
+func (c *Checker) checkMigrations(ctx context.Context) CheckResult {
+	expected := c.expectedVersion
+	var current int64
+	err := c.db.QueryRow(ctx, `SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied`).Scan(&current)
+	if err != nil {
+		return CheckResult{Status: StatusFail, Expected: &expected, Error: fmt.Sprintf("read schema version: %v", err)}
+	}
+	result := CheckResult{Status: StatusOK, Expected: &expected, Current: &current}
+	if current != expected {
+		result.Status = StatusFail
+		result.Error = "schema version does not match this build"
+	}
+	return result
+}
+
+func (c *Checker) checkWorkers() CheckResult {
+	statuses := c.workers.Statuses()
+	result := CheckResult{Status: StatusOK, Workers: statuses}
+	for _, s := range statuses {
+		if s.State == worker.StateFailed {
+			result.Status = StatusDegraded
+			result.Error = fmt.Sprintf("worker %s failed", s.Name)
+		}
+	}
+	return result
+}
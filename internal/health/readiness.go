@@ -0,0 +1,22 @@
+// Package health tracks whether the process should receive traffic.
+package health
+
+import "sync/atomic"
+
+// Readiness is a process-wide ready flag. It starts not-ready, is set once the
+// server is listening and is cleared as soon as shutdown begins.
+type Readiness struct {
+	ready atomic.Bool
+}
+
+func NewReadiness() *Readiness {
+	return &Readiness{}
+}
+
+func (r *Readiness) SetReady(ready bool) {
+	r.ready.Store(ready)
+}
+
+func (r *Readiness) Ready() bool {
+	return r.ready.Load()
+}
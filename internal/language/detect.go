@@ -0,0 +1,244 @@
+package language
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const (
+	// maxDetectSize bounds how much content the classifier looks at.
+	maxDetectSize = 64 << 10
+	// modelineLines is how many lines at either end are searched for modelines.
+	modelineLines = 5
+	// minScore and minMargin keep the classifier from guessing: the best
+	// language needs enough evidence and a clear lead over the runner-up.
+	minScore  = 6
+	minMargin = 1.3
+)
+
+// Detect guesses the language of content, using in order a shebang, an
+// editor modeline, the file name hint (which may be empty) and finally a
+// token-frequency classifier. It returns "" when there is no confident guess.
+func Detect(content, filename string) string {
+	if id := fromShebang(content); id != "" {
+		return id
+	}
+	if id := fromModeline(content); id != "" {
+		return id
+	}
+	if id := fromFilename(filename); id != "" {
+		return id
+	}
+	if len(content) > maxDetectSize {
+		content = content[:maxDetectSize]
+	}
+	return classify(content)
+}
+
+// fromShebang returns the language of the interpreter named by a #! line,
+// looking through env and trailing versions such as python3.12.
+func fromShebang(content string) string {
+	if !strings.HasPrefix(content, "#!") {
+		return ""
+	}
+	line, _, _ := strings.Cut(content[2:], "\n")
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	command := path.Base(fields[0])
+	if command == "env" {
+		command = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") && !strings.Contains(field, "=") {
+				command = path.Base(field)
+				break
+			}
+		}
+	}
+	command = strings.ToLower(command)
+	if id, ok := byInterpreter[command]; ok {
+		return id
+	}
+	return byInterpreter[strings.TrimRight(command, "0123456789.")]
+}
+
+var (
+	vimModeline      = regexp.MustCompile(`(?:^|\s)(?:vi|vim|ex)(?:[<=>]?\d+)?:.*?\b(?:ft|filetype|syn|syntax)=([\w+#-]+)`)
+	emacsModeline    = regexp.MustCompile(`-\*-.*?\bmode:\s*([\w+#-]+)`)
+	emacsModeOnly    = regexp.MustCompile(`-\*-\s*([\w+#-]+)\s*-\*-`)
+	modelinePatterns = []*regexp.Regexp{vimModeline, emacsModeline, emacsModeOnly}
+)
+
+// fromModeline returns the language set by a vim or emacs modeline in the
+// first or last few lines.
+func fromModeline(content string) string {
+	lines := strings.SplitN(content, "\n", modelineLines+1)
+	if len(lines) > modelineLines {
+		lines = lines[:modelineLines]
+		tail := strings.Split(content[max(0, len(content)-1024):], "\n")
+		lines = append(lines, tail[max(0, len(tail)-modelineLines):]...)
+	}
+	for _, line := range lines {
+		for _, pattern := range modelinePatterns {
+			if m := pattern.FindStringSubmatch(line); m != nil {
+				if id, ok := Normalize(m[1]); ok {
+					return id
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// tokenPattern splits content into the tokens the classifier weighs: words,
+// tags, preprocessor directives, sigils and multi-character operators.
+var tokenPattern = regexp.MustCompile(`<\?php|<\?xml|<!DOCTYPE|</?[A-Za-z][\w-]*|#include|#define|\.PHONY|\$\{|\$\(|\$[A-Za-z_]\w*|@[A-Za-z_]\w*|[A-Z][a-z]+-[A-Z][A-Za-z]+|[A-Za-z_]\w*|:=|=>|->|<-|::|===|!==|\|>`)
+
+var (
+	cmdletPattern = regexp.MustCompile(`^[A-Z][a-z]+-[A-Z][A-Za-z]+$`)
+	yamlKeyLine   = regexp.MustCompile(`^\s*(?:- )?[\w.-]+:(?:\s|$)`)
+	iniKeyLine    = regexp.MustCompile(`^\s*[\w.-]+\s*=`)
+	iniSection    = regexp.MustCompile(`^\s*\[\[?[\w. "-]+\]\]?\s*$`)
+	markdownLine  = regexp.MustCompile("^(?:#{1,6} |```|\\s*[-*] \\[[ x]\\] |\\s*[-*] |\\d+\\. |> )")
+	markdownLink  = regexp.MustCompile(`\[[^\]]+\]\([^)]+\)`)
+)
+
+// classify scores content against each language's distinctive tokens and
+// line shapes and returns the best language if it is a clear winner.
+func classify(content string) string {
+	trimmed := strings.TrimSpace(content)
+	if trimmed == "" {
+		return ""
+	}
+	if (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid([]byte(trimmed)) {
+		return "json"
+	}
+
+	counts := map[string]int{}
+	for _, token := range tokenPattern.FindAllString(content, -1) {
+		switch {
+		case token == "$this":
+		case strings.HasPrefix(token, "$") && len(token) > 2 && token[1] != '{' && token[1] != '(':
+			token = "$var"
+		case cmdletPattern.MatchString(token):
+			token = "Verb-Noun"
+		}
+		counts[token]++
+	}
+
+	scores := map[string]float64{}
+	for id, weights := range tokenWeights {
+		for token, weight := range weights {
+			// Capping repeats stops one common word from deciding alone
+			scores[id] += weight * float64(min(counts[token], 4))
+		}
+	}
+	for id, score := range lineScores(content) {
+		scores[id] += score
+	}
+
+	best, bestScore, second := "", 0.0, 0.0
+	for id, score := range scores {
+		switch {
+		case score > bestScore || (score == bestScore && id < best):
+			best, bestScore, second = id, score, bestScore
+		case score > second:
+			second = score
+		}
+	}
+	if bestScore < minScore || bestScore < second*minMargin {
+		return ""
+	}
+	return best
+}
+
+// lineScores scores languages recognised by the shape of their lines rather
+// than by keywords.
+func lineScores(content string) map[string]float64 {
+	var lines, blank, yamlKeys, iniKeys, sections, quoted, markdown, diffHeaders, hunks, changes int
+	for _, line := range strings.Split(content, "\n") {
+		lines++
+		switch {
+		case strings.TrimSpace(line) == "":
+			blank++
+			continue
+		case strings.HasPrefix(line, "+++ ") || strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "diff --git "):
+			diffHeaders++
+		case strings.HasPrefix(line, "@@ "):
+			hunks++
+		case strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-"):
+			changes++
+		}
+		if yamlKeyLine.MatchString(line) {
+			yamlKeys++
+		}
+		if iniSection.MatchString(line) {
+			sections++
+		} else if iniKeyLine.MatchString(line) {
+			iniKeys++
+			_, value, _ := strings.Cut(line, "=")
+			if v := strings.TrimSpace(value); strings.HasPrefix(v, `"`) || strings.HasPrefix(v, "[") || v == "true" || v == "false" {
+				quoted++
+			}
+		}
+		if markdownLine.MatchString(line) || markdownLink.MatchString(line) {
+			markdown++
+		}
+	}
+	nonBlank := float64(lines - blank)
+	scores := map[string]float64{}
+	if hunks > 0 && diffHeaders > 0 {
+		scores["diff"] = 10 + float64(changes)
+	}
+	// Mostly "key: value" lines with no code punctuation read as YAML
+	if nonBlank > 0 && float64(yamlKeys)/nonBlank >= 0.5 && !strings.ContainsAny(content, ";{}") {
+		scores["yaml"] = 4 + float64(min(yamlKeys, 8))
+	}
+	if sections > 0 && iniKeys > 0 && float64(sections+iniKeys)/nonBlank >= 0.6 {
+		id := "ini"
+		if quoted*2 >= iniKeys {
+			id = "toml"
+		}
+		scores[id] = 4 + float64(min(sections+iniKeys, 8))
+	}
+	if markdown >= 2 {
+		scores["markdown"] = 2 * float64(min(markdown, 6))
+	}
+	return scores
+}
+
+// tokenWeights are the tokens that set each language apart, weighted by how
+// telling they are.
+var tokenWeights = map[string]map[string]float64{
+	"bash":       {"fi": 3, "esac": 3, "then": 1.5, "done": 1.5, "echo": 1.5, "export": 2, "sudo": 2, "${": 1.5, "$(": 1.5, "$var": 0.5, "elif": 1},
+	"c":          {"#include": 3, "#define": 2, "printf": 2, "malloc": 3, "sizeof": 2, "NULL": 2, "unsigned": 2, "char": 1.5, "int": 1, "void": 1, "struct": 1},
+	"cpp":        {"#include": 2, "std": 3, "::": 1.5, "cout": 3, "template": 2, "namespace": 1.5, "nullptr": 3, "virtual": 2, "auto": 1, "class": 1},
+	"csharp":     {"using": 2, "namespace": 1.5, "Console": 3, "public": 1, "void": 1, "string": 1, "get": 1, "set": 1, "Task": 2, "override": 1},
+	"css":        {"color": 1.5, "margin": 2, "padding": 2, "display": 2, "background": 2, "border": 1.5, "font": 1, "width": 1, "px": 1},
+	"dockerfile": {"FROM": 2, "RUN": 3, "COPY": 2, "WORKDIR": 4, "ENTRYPOINT": 4, "CMD": 2, "EXPOSE": 3, "ENV": 2, "ARG": 1},
+	"elixir":     {"defmodule": 5, "defp": 5, "def": 1, "do": 1, "end": 1.5, "|>": 3},
+	"go":         {"package": 2, "func": 3, ":=": 2, "fmt": 2, "chan": 2, "defer": 3, "nil": 1.5, "err": 1, "struct": 1, "interface": 1},
+	"haskell":    {"deriving": 4, "instance": 2, "where": 1.5, "Maybe": 3, "IO": 2, "::": 1.5, "<-": 1.5, "->": 1, "data": 1, "module": 1},
+	"html":       {"<!DOCTYPE": 5, "<html": 5, "<head": 4, "<body": 4, "<div": 3, "</div": 2, "<span": 2, "<script": 2, "<a": 1, "<p": 1},
+	"java":       {"@Override": 3, "throws": 3, "System": 2, "public": 1.5, "void": 1.5, "String": 1.5, "implements": 1.5, "final": 1.5, "private": 1, "static": 1, "class": 1, "extends": 1},
+	"javascript": {"===": 2, "!==": 2, "console": 2, "require": 2, "undefined": 2, "document": 2, "function": 1.5, "const": 1.5, "let": 1.5, "=>": 1, "var": 1, "module": 1},
+	"kotlin":     {"fun": 3, "val": 2, "when": 1, "override": 1, "println": 1},
+	"lua":        {"local": 3, "elseif": 3, "end": 1.5, "function": 1, "then": 1, "nil": 1},
+	"makefile":   {".PHONY": 5, "$(": 1.5, "all": 1, "clean": 1, "install": 1},
+	"nginx":      {"proxy_pass": 5, "server_name": 5, "location": 3, "listen": 3, "upstream": 3, "server": 1},
+	"perl":       {"strict": 3, "warnings": 2, "my": 2, "sub": 2, "use": 1, "$var": 0.5},
+	"php":        {"<?php": 10, "$this": 3, "echo": 2, "array": 1.5, "function": 1, "->": 1},
+	"powershell": {"Verb-Noun": 3, "param": 2, "$var": 1},
+	"python":     {"def": 3, "elif": 3, "__init__": 3, "self": 2, "None": 2, "except": 2, "lambda": 1.5, "pass": 1.5, "True": 1, "False": 1, "print": 1},
+	"r":          {"library": 3, "<-": 2, "TRUE": 1.5, "FALSE": 1.5, "function": 1},
+	"ruby":       {"puts": 3, "elsif": 3, "attr_accessor": 3, "unless": 2, "end": 2, "def": 1.5, "require": 1, "do": 1, "nil": 1},
+	"rust":       {"fn": 3, "mut": 3, "impl": 3, "crate": 3, "pub": 2, "Some": 2, "println": 2, "let": 1, "use": 1, "match": 1, "::": 1, "->": 1},
+	"scala":      {"trait": 3, "implicit": 3, "object": 2, "val": 2, "def": 1, "case": 1, "extends": 1},
+	"sql":        {"SELECT": 3, "INSERT": 3, "FROM": 2, "WHERE": 2, "INTO": 2, "UPDATE": 2, "CREATE": 2, "TABLE": 2, "JOIN": 2, "VALUES": 2, "select": 2, "from": 1, "where": 1.5, "insert": 2, "join": 1.5},
+	"swift":      {"guard": 3, "UIKit": 4, "Foundation": 2, "protocol": 2, "extension": 2, "func": 1.5, "let": 1},
+	"typescript": {"interface": 1.5, "readonly": 2, "number": 1.5, "boolean": 1.5, "string": 1, "implements": 1, "const": 1, "=>": 1, "export": 1},
+	"xml":        {"<?xml": 10},
+}
@@ -0,0 +1,100 @@
+package language
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+func TestRegistry(t *testing.T) {
+	all := All()
+	if !slices.IsSortedFunc(all, func(a, b Language) int { return strings.Compare(a.ID, b.ID) }) {
+		t.Error("All() is not ordered by ID")
+	}
+	for _, l := range all {
+		if lexers.Get(l.ID) == nil {
+			t.Errorf("%s has no chroma lexer", l.ID)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{"go", "go", true},
+		{"Golang", "go", true},
+		{"  Python3 ", "python", true},
+		{"C#", "csharp", true},
+		{"c++", "cpp", true},
+		{".rs", "rust", true},
+		{"yml", "yaml", true},
+		{"Plain text", "text", true},
+		{"Nginx configuration", "nginx", true},
+		{"cobol", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Normalize(tt.name)
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("Normalize(%q) = %q, %v; want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestName(t *testing.T) {
+	if got := Name("cpp"); got != "C++" {
+		t.Errorf("Name(cpp) = %q, want C++", got)
+	}
+	if got := Name("cobol"); got != "cobol" {
+		t.Errorf("Name(cobol) = %q, want the ID back", got)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		filename string
+		want     string
+	}{
+		{"shebang", "#!/bin/bash\nls\n", "", "bash"},
+		{"env shebang with version", "#!/usr/bin/env python3.12\nprint(1)\n", "", "python"},
+		{"env shebang with flags", "#!/usr/bin/env -S node --no-warnings\nx()\n", "", "javascript"},
+		{"shebang beats filename", "#!/usr/bin/env ruby\nputs 1\n", "script.py", "ruby"},
+		{"vim modeline", "x = 1\n# vim: set ft=python :\n", "", "python"},
+		{"emacs modeline", "; -*- mode: lua -*-\nx = 1\n", "", "lua"},
+		{"emacs mode only", "-*- go -*-\n", "", "go"},
+		{"modeline at the end", strings.Repeat("line\n", 20) + "// vim: ft=rust\n", "", "rust"},
+		{"unknown modeline", "# vim: ft=cobol\n", "", ""},
+		{"filename extension", "anything", "main.go", "go"},
+		{"whole filename", "anything", "Dockerfile", "dockerfile"},
+		{"windows path", "anything", `C:\src\deploy.YAML`, "yaml"},
+		{"dotfile", "anything", ".bashrc", "bash"},
+		{"unknown extension", "", "notes.xyz", ""},
+		{"json", `{"name": "pastebin", "private": true}`, "", "json"},
+		{"go", "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tx := 1\n\tdefer fmt.Println(x)\n\tif err != nil {\n\t\treturn\n\t}\n}\n", "", "go"},
+		{"python", "class Greeter:\n    def __init__(self, name):\n        self.name = name\n\n    def greet(self):\n        if self.name is None:\n            pass\n        elif self.name:\n            print(self.name)\n", "", "python"},
+		{"php", "<?php\necho $this->name;\n", "", "php"},
+		{"sql", "SELECT id, title FROM pastes WHERE is_private = false ORDER BY created_at;\nINSERT INTO pastes (title) VALUES ('x');\n", "", "sql"},
+		{"html", "<!DOCTYPE html>\n<html>\n<head></head>\n<body><div>hi</div></body>\n</html>\n", "", "html"},
+		{"diff", "diff --git a/x b/x\n--- a/x\n+++ b/x\n@@ -1,2 +1,2 @@\n-old\n+new\n", "", "diff"},
+		{"yaml", "server:\n  addr: :8080\n  read_timeout: 15s\nlog:\n  level: info\n", "", "yaml"},
+		{"toml", "[server]\naddr = \":8080\"\ntrust_proxy = false\n\n[log]\nlevel = \"info\"\n", "", "toml"},
+		{"prose", "Meeting notes: ship it on Tuesday.", "", ""},
+		{"empty", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(tt.content, tt.filename); got != tt.want {
+				t.Fatalf("Detect() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
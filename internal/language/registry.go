@@ -0,0 +1,132 @@
+// Package language is the registry of paste languages and detects the
+// language of paste content. Language IDs double as chroma lexer names, so
+// every registered language can be highlighted.
+package language
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Language is one supported language. ID is what pastes store.
+type Language struct {
+	ID         string   `json:"id" example:"go"`
+	Name       string   `json:"name" example:"Go"`
+	Aliases    []string `json:"aliases,omitempty"`
+	Extensions []string `json:"extensions,omitempty"`
+	// filenames are whole file names that imply the language, such as Makefile.
+	filenames []string
+	// interpreters are shebang commands that run the language.
+	interpreters []string
+}
+
+var registry = []Language{
+	{ID: "bash", Name: "Bash", Aliases: []string{"sh", "shell", "zsh", "shell-script", "shellscript"}, Extensions: []string{".sh", ".bash", ".zsh"}, filenames: []string{".bashrc", ".bash_profile", ".zshrc", ".profile"}, interpreters: []string{"sh", "bash", "zsh", "dash", "ksh"}},
+	{ID: "c", Name: "C", Extensions: []string{".c", ".h"}},
+	{ID: "cpp", Name: "C++", Aliases: []string{"c++", "cxx"}, Extensions: []string{".cpp", ".cc", ".cxx", ".hpp", ".hh"}},
+	{ID: "csharp", Name: "C#", Aliases: []string{"c#", "cs"}, Extensions: []string{".cs"}},
+	{ID: "css", Name: "CSS", Extensions: []string{".css"}},
+	{ID: "diff", Name: "Diff", Aliases: []string{"patch", "udiff"}, Extensions: []string{".diff", ".patch"}},
+	{ID: "dockerfile", Name: "Dockerfile", Aliases: []string{"docker", "containerfile"}, filenames: []string{"dockerfile", "containerfile"}},
+	{ID: "elixir", Name: "Elixir", Aliases: []string{"ex", "exs"}, Extensions: []string{".ex", ".exs"}, interpreters: []string{"elixir"}},
+	{ID: "go", Name: "Go", Aliases: []string{"golang"}, Extensions: []string{".go"}},
+	{ID: "haskell", Name: "Haskell", Aliases: []string{"hs"}, Extensions: []string{".hs"}, interpreters: []string{"runhaskell"}},
+	{ID: "html", Name: "HTML", Aliases: []string{"htm", "xhtml"}, Extensions: []string{".html", ".htm"}},
+	{ID: "ini", Name: "INI", Aliases: []string{"cfg", "dosini"}, Extensions: []string{".ini", ".cfg"}},
+	{ID: "java", Name: "Java", Extensions: []string{".java"}},
+	{ID: "javascript", Name: "JavaScript", Aliases: []string{"js", "node", "nodejs", "jsx"}, Extensions: []string{".js", ".mjs", ".cjs", ".jsx"}, interpreters: []string{"node", "nodejs"}},
+	{ID: "json", Name: "JSON", Extensions: []string{".json"}},
+	{ID: "kotlin", Name: "Kotlin", Aliases: []string{"kt"}, Extensions: []string{".kt", ".kts"}},
+	{ID: "lua", Name: "Lua", Extensions: []string{".lua"}, interpreters: []string{"lua", "luajit"}},
+	{ID: "makefile", Name: "Makefile", Aliases: []string{"make", "mk"}, Extensions: []string{".mk"}, filenames: []string{"makefile", "gnumakefile"}},
+	{ID: "markdown", Name: "Markdown", Aliases: []string{"md"}, Extensions: []string{".md", ".markdown"}},
+	{ID: "nginx", Name: "Nginx configuration", Aliases: []string{"nginxconf"}, filenames: []string{"nginx.conf"}},
+	{ID: "perl", Name: "Perl", Aliases: []string{"pl"}, Extensions: []string{".pl", ".pm"}, interpreters: []string{"perl"}},
+	{ID: "php", Name: "PHP", Extensions: []string{".php"}, interpreters: []string{"php"}},
+	{ID: "powershell", Name: "PowerShell", Aliases: []string{"ps1", "pwsh", "posh"}, Extensions: []string{".ps1", ".psm1"}, interpreters: []string{"pwsh"}},
+	{ID: "python", Name: "Python", Aliases: []string{"py", "python3", "py3"}, Extensions: []string{".py", ".pyw"}, interpreters: []string{"python"}},
+	{ID: "r", Name: "R", Aliases: []string{"rlang"}, Extensions: []string{".r"}, interpreters: []string{"rscript"}},
+	{ID: "ruby", Name: "Ruby", Aliases: []string{"rb"}, Extensions: []string{".rb"}, filenames: []string{"gemfile", "rakefile"}, interpreters: []string{"ruby"}},
+	{ID: "rust", Name: "Rust", Aliases: []string{"rs"}, Extensions: []string{".rs"}},
+	{ID: "scala", Name: "Scala", Extensions: []string{".scala", ".sc"}, interpreters: []string{"scala"}},
+	{ID: "sql", Name: "SQL", Aliases: []string{"postgres", "postgresql", "psql", "mysql", "sqlite"}, Extensions: []string{".sql"}},
+	{ID: "swift", Name: "Swift", Extensions: []string{".swift"}},
+	{ID: "text", Name: "Plain text", Aliases: []string{"plaintext", "plain", "txt"}, Extensions: []string{".txt", ".log"}},
+	{ID: "toml", Name: "TOML", Extensions: []string{".toml"}},
+	{ID: "typescript", Name: "TypeScript", Aliases: []string{"ts", "tsx"}, Extensions: []string{".ts", ".mts", ".cts", ".tsx"}, interpreters: []string{"deno", "ts-node"}},
+	{ID: "xml", Name: "XML", Aliases: []string{"svg", "xsl"}, Extensions: []string{".xml", ".xsd", ".xsl", ".svg"}},
+	{ID: "yaml", Name: "YAML", Aliases: []string{"yml"}, Extensions: []string{".yaml", ".yml"}},
+}
+
+var (
+	// byName maps lower-cased IDs, names, aliases and extensions, with and
+	// without the dot, to IDs.
+	byName        = map[string]string{}
+	byFilename    = map[string]string{}
+	byInterpreter = map[string]string{}
+)
+
+func init() {
+	add := func(index map[string]string, key, id string) {
+		key = strings.ToLower(key)
+		if other, ok := index[key]; ok && other != id {
+			panic(fmt.Sprintf("language: %q is claimed by both %s and %s", key, other, id))
+		}
+		index[key] = id
+	}
+	for _, l := range registry {
+		add(byName, l.ID, l.ID)
+		add(byName, l.Name, l.ID)
+		for _, alias := range l.Aliases {
+			add(byName, alias, l.ID)
+		}
+		for _, ext := range l.Extensions {
+			add(byName, ext, l.ID)
+			add(byName, strings.TrimPrefix(ext, "."), l.ID)
+		}
+		for _, name := range l.filenames {
+			add(byFilename, name, l.ID)
+		}
+		for _, interpreter := range l.interpreters {
+			add(byInterpreter, interpreter, l.ID)
+		}
+	}
+}
+
+// All returns the supported languages ordered by ID.
+func All() []Language {
+	return slices.Clone(registry)
+}
+
+// Normalize maps a language ID, name, alias or file extension, in any case,
+// to its canonical ID. It reports false for languages not in the registry.
+func Normalize(name string) (string, bool) {
+	id, ok := byName[strings.ToLower(strings.TrimSpace(name))]
+	return id, ok
+}
+
+// Name returns the display name of a language ID, or the ID itself when it
+// is not registered.
+func Name(id string) string {
+	for _, l := range registry {
+		if l.ID == id {
+			return l.Name
+		}
+	}
+	return id
+}
+
+// fromFilename returns the language a file name implies, by its whole name
+// or its extension.
+func fromFilename(filename string) string {
+	base := strings.ToLower(path.Base(strings.ReplaceAll(strings.TrimSpace(filename), `\`, "/")))
+	if id, ok := byFilename[base]; ok {
+		return id
+	}
+	if ext := path.Ext(base); ext != "" && ext != base {
+		return byName[ext]
+	}
+	return ""
+}
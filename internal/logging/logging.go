@@ -0,0 +1,71 @@
+// Package logging carries a request-scoped zerolog.Logger through context.Context
+// so that every log line written while serving a request shares its request ID,
+// user ID and trace ID.
+package logging
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// ContextKey is a typed key for storing values in context.Context to avoid collisions.
+type ContextKey string
+
+const (
+	loggerCtxKey    ContextKey = "logger"
+	requestIDCtxKey ContextKey = "requestID"
+)
+
+var defaultLogger = zerolog.Nop()
+
+// SetDefault sets the logger Ctx falls back to outside of a request.
+func SetDefault(logger zerolog.Logger) {
+	defaultLogger = logger
+}
+
+// Ctx is FromContext with the process-wide default logger as the fallback.
+// It is meant for code without a logger of its own, such as repositories.
+func Ctx(ctx context.Context) *zerolog.Logger {
+	return FromContext(ctx, defaultLogger)
+}
+
+// WithLogger returns a copy of ctx carrying logger.
+func WithLogger(ctx context.Context, logger zerolog.Logger) context.Context {
+	return context.WithValue(ctx, loggerCtxKey, logger)
+}
+
+// FromContext returns the request-scoped logger stored in ctx, or fallback when
+// there is none (startup, background work). The returned logger is bound to ctx
+// so hooks such as tracing.LogHook can read the active span.
+func FromContext(ctx context.Context, fallback zerolog.Logger) *zerolog.Logger {
+	logger := fallback
+	if ctx == nil {
+		return &logger
+	}
+	if l, ok := ctx.Value(loggerCtxKey).(zerolog.Logger); ok {
+		logger = l
+	}
+	logger = logger.With().Ctx(ctx).Logger()
+	return &logger
+}
+
+// WithUserID adds user_id to the request-scoped logger in ctx. It is a no-op
+// when ctx has no request-scoped logger.
+func WithUserID(ctx context.Context, userID uuid.UUID) context.Context {
+	l, ok := ctx.Value(loggerCtxKey).(zerolog.Logger)
+	if !ok {
+		return ctx
+	}
+	return WithLogger(ctx, l.With().Str("user_id", userID.String()).Logger())
+}
+
+// RequestIDFromContext returns the request ID assigned by Middleware, or "" if none.
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDCtxKey).(string)
+	return id
+}
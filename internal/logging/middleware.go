@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+// RequestIDHeader is read from incoming requests and echoed on every response.
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLength = 128
+
+// Middleware assigns or propagates X-Request-ID, stores a request-scoped logger
+// in the request's context.Context and writes one access log line per request.
+// The access line is written with whatever logger is in the context once the
+// handler chain returns, so fields added later (user_id) are included.
+func Middleware(base zerolog.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			req := c.Request()
+
+			requestID := req.Header.Get(RequestIDHeader)
+			if !validRequestID(requestID) {
+				requestID = uuid.NewString()
+			}
+			c.Response().Header().Set(RequestIDHeader, requestID)
+
+			ctx := context.WithValue(req.Context(), requestIDCtxKey, requestID)
+			ctx = WithLogger(ctx, base.With().Str("request_id", requestID).Logger())
+			c.SetRequest(req.WithContext(ctx))
+
+			err := next(c)
+			if err != nil {
+				// Write the error response now so the logged status matches what the client sees.
+				c.Error(err)
+			}
+
+			res := c.Response()
+			logger := FromContext(c.Request().Context(), base)
+			event := logger.Info()
+			switch {
+			case res.Status >= 500:
+				event = logger.Error().Err(err)
+			case res.Status >= 400:
+				event = logger.Warn()
+			}
+			event.
+				Str("method", req.Method).
+				Str("route", c.Path()).
+				Str("uri", req.RequestURI).
+				Int("status", res.Status).
+				Int64("bytes_out", res.Size).
+				Dur("latency", time.Since(start)).
+				Str("remote_ip", c.RealIP()).
+				Str("user_agent", req.UserAgent()).
+				Msg("request")
+			return nil
+		}
+	}
+}
+
+// validRequestID only accepts short IDs made of URL-safe characters, so a client
+// cannot inject arbitrary data into our logs through the header.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,258 @@
+// Package markdown renders Markdown pastes to sanitized HTML: GitHub
+// flavoured tables, task lists and strikethrough, highlighted fenced code
+// blocks and a table of contents linking to every heading.
+package markdown
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"pastebin/internal/language"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+const (
+	// MaxSize bounds the Markdown that is rendered so one paste cannot hog
+	// the CPU.
+	MaxSize = 512 << 10
+	// headingPrefix keeps heading IDs from clashing with the IDs of the page
+	// the document is embedded in.
+	headingPrefix = "md-"
+	// minTOCHeadings is how many headings a document needs for a table of
+	// contents to be worth it.
+	minTOCHeadings = 2
+)
+
+// ErrTooLarge is returned for sources larger than MaxSize.
+var ErrTooLarge = errors.New("markdown is too large to render")
+
+// Renderer converts Markdown to HTML that is safe to embed in a page.
+type Renderer struct {
+	markdown goldmark.Markdown
+	policy   *bluemonday.Policy
+}
+
+// New builds a Renderer. Raw HTML in the source is dropped while parsing and
+// the output is sanitized again, so only the markup Markdown produces and
+// http, https and mailto links survive.
+func New() *Renderer {
+	code := &codeBlockRenderer{
+		formatter: chromahtml.New(chromahtml.WithClasses(true)),
+		style:     styles.Get("github"),
+	}
+	return &Renderer{
+		markdown: goldmark.New(
+			goldmark.WithExtensions(
+				// GFM's tables align cells with style attributes, which are sanitized away
+				extension.NewTable(extension.WithTableCellAlignMethod(extension.TableCellAlignAttribute)),
+				extension.Strikethrough,
+				extension.Linkify,
+				extension.TaskList,
+			),
+			goldmark.WithRendererOptions(renderer.WithNodeRenderers(util.Prioritized(code, 100))),
+		),
+		policy: newPolicy(),
+	}
+}
+
+// chromaClass matches the class lists chroma writes, which the viewer's
+// stylesheet themes.
+var chromaClass = regexp.MustCompile(`^[a-z][a-z0-9]*(?: [a-z][a-z0-9]*)*$`)
+
+func newPolicy() *bluemonday.Policy {
+	policy := bluemonday.UGCPolicy()
+	policy.AllowURLSchemes("http", "https", "mailto")
+	policy.RequireParseableURLs(true)
+	policy.AllowAttrs("id").Matching(regexp.MustCompile(`^`+headingPrefix+`[\pL\pN_-]+$`)).OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+	policy.AllowAttrs("class").Matching(chromaClass).OnElements("pre", "code", "span")
+	policy.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	policy.AllowAttrs("checked", "disabled").OnElements("input")
+	policy.AllowAttrs("align").Matching(regexp.MustCompile(`^(?:left|center|right)$`)).OnElements("th", "td")
+	return policy
+}
+
+// Render returns source as sanitized HTML, preceded by a table of contents
+// when it has more than one heading.
+func (r *Renderer) Render(source string) (string, error) {
+	if len(source) > MaxSize {
+		return "", ErrTooLarge
+	}
+	src := []byte(source)
+	doc := r.markdown.Parser().Parse(text.NewReader(src))
+	headings := anchorHeadings(doc, src)
+
+	var body bytes.Buffer
+	if err := r.markdown.Renderer().Render(&body, src, doc); err != nil {
+		return "", fmt.Errorf("render markdown: %w", err)
+	}
+	var out strings.Builder
+	if len(headings) >= minTOCHeadings {
+		writeTOC(&out, headings)
+	}
+	out.Write(r.policy.SanitizeBytes(body.Bytes()))
+	return out.String(), nil
+}
+
+type heading struct {
+	level int
+	id    string
+	text  string
+}
+
+// anchorHeadings gives every heading in doc a unique ID derived from its text
+// and returns them in document order.
+func anchorHeadings(doc ast.Node, src []byte) []heading {
+	var headings []heading
+	used := map[string]bool{}
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		h, ok := n.(*ast.Heading)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		title := plainText(h, src)
+		base := slugify(title)
+		if base == "" {
+			base = "section"
+		}
+		id := base
+		for n := 1; used[id]; n++ {
+			id = base + "-" + strconv.Itoa(n)
+		}
+		used[id] = true
+		id = headingPrefix + id
+		h.SetAttributeString("id", []byte(id))
+		headings = append(headings, heading{level: h.Level, id: id, text: title})
+		return ast.WalkSkipChildren, nil
+	})
+	return headings
+}
+
+// plainText returns the text of n's inline children without markup.
+func plainText(n ast.Node, src []byte) string {
+	var b strings.Builder
+	_ = ast.Walk(n, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Text:
+			b.Write(n.Value(src))
+			if n.SoftLineBreak() || n.HardLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(n.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSpace(b.String())
+}
+
+// slugify lower-cases title, keeps letters, digits, '-' and '_' and turns
+// spaces into '-', as GitHub does for heading anchors.
+func slugify(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
+
+// writeTOC writes headings as nested lists of links, nesting by level
+// relative to the shallowest heading.
+func writeTOC(w *strings.Builder, headings []heading) {
+	top := headings[0].level
+	for _, h := range headings {
+		top = min(top, h.level)
+	}
+	w.WriteString(`<nav class="toc"><ul>`)
+	depth := 0
+	for i, h := range headings {
+		level := h.level - top
+		if i > 0 {
+			switch {
+			case level > depth:
+				// Skipped levels collapse to one step deeper
+				level = depth + 1
+				w.WriteString("<ul>")
+			case level < depth:
+				w.WriteString(strings.Repeat("</li></ul>", depth-level) + "</li>")
+			default:
+				w.WriteString("</li>")
+			}
+		} else {
+			level = 0
+		}
+		depth = level
+		fmt.Fprintf(w, `<li><a href="#%s">%s</a>`, html.EscapeString(h.id), html.EscapeString(h.text))
+	}
+	w.WriteString(strings.Repeat("</li></ul>", depth) + "</li></ul></nav>")
+}
+
+// codeBlockRenderer highlights fenced code blocks with chroma, naming the
+// language on the fence by any of its registry names.
+type codeBlockRenderer struct {
+	formatter *chromahtml.Formatter
+	style     *chroma.Style
+}
+
+func (c *codeBlockRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, c.render)
+}
+
+func (c *codeBlockRenderer) render(w util.BufWriter, src []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	block := node.(*ast.FencedCodeBlock)
+	var code strings.Builder
+	lines := block.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		code.Write(segment.Value(src))
+	}
+	iterator, err := lexerFor(string(block.Language(src))).Tokenise(nil, code.String())
+	if err != nil {
+		return ast.WalkStop, fmt.Errorf("tokenise code block: %w", err)
+	}
+	// The style only matters for inline styles; the themes come from the stylesheet
+	if err := c.formatter.Format(w, c.style, iterator); err != nil {
+		return ast.WalkStop, fmt.Errorf("highlight code block: %w", err)
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+// lexerFor returns the lexer for the language named on a code fence, or
+// plain text.
+func lexerFor(name string) chroma.Lexer {
+	var lexer chroma.Lexer
+	if id, ok := language.Normalize(name); ok {
+		lexer = lexers.Get(id)
+	}
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	return chroma.Coalesce(lexer)
+}
@@ -0,0 +1,134 @@
+package markdown
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRenderSanitizes(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    string
+		forbidden []string
+	}{
+		{"script tag", "hello <script>alert(1)</script>", []string{"<script"}},
+		{"raw html block", "<div onclick=\"steal()\">hi</div>", []string{"<div", "onclick", "steal()"}},
+		{"inline event handler", "<img src=x onerror=alert(1)>", []string{"<img", "onerror"}},
+		{"iframe", "<iframe src=\"https://evil.example\"></iframe>", []string{"<iframe"}},
+		{"javascript link", "[click](javascript:alert(1))", []string{"javascript:"}},
+		{"encoded javascript link", "[click](jav&#x61;script:alert(1))", []string{"javascript:", "alert(1)\""}},
+		{"javascript autolink", "<javascript:alert(1)>", []string{"href=\"javascript:"}},
+		{"javascript image", "![x](javascript:alert(1))", []string{"javascript:"}},
+		{"data link", "[x](data:text/html;base64,PHNjcmlwdD4=)", []string{"data:text/html"}},
+		{"vbscript link", "[x](vbscript:msgbox)", []string{"vbscript:"}},
+		{"style attribute via table alignment", "| a |\n|:-:|\n| b |", []string{"style="}},
+		{"heading id outside the prefix", "# Title {#evil}", []string{`id="evil"`}},
+	}
+	r := New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := r.Render(tt.source)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			for _, s := range tt.forbidden {
+				if strings.Contains(strings.ToLower(out), strings.ToLower(s)) {
+					t.Errorf("Render(%q) = %q, contains %q", tt.source, out, s)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderKeepsMarkdown(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{"link", "[docs](https://example.com/docs)", []string{`<a href="https://example.com/docs"`}},
+		{"mailto link", "[mail](mailto:ops@example.com)", []string{`href="mailto:ops@example.com"`}},
+		{"linkify", "see https://example.com", []string{`<a href="https://example.com"`}},
+		{"emphasis", "*a* **b** ~~c~~", []string{"<em>a</em>", "<strong>b</strong>", "<del>c</del>"}},
+		{"table alignment", "| a |\n|:-:|\n| b |", []string{`<th align="center">a</th>`}},
+		{"task list", "- [x] done\n- [ ] todo", []string{`<input checked="" disabled="" type="checkbox"`, `<input disabled="" type="checkbox"`}},
+		{"highlighted code", "```go\nfunc main() {}\n```", []string{`<pre class="chroma">`, `<span class="kd">func</span>`}},
+		{"code by alias", "```golang\nfunc main() {}\n```", []string{`<span class="kd">func</span>`}},
+		{"unknown code language", "```cobol\nDISPLAY 'HI'.\n```", []string{`<pre class="chroma">`, "DISPLAY"}},
+		{"escaped code", "```\n<script>alert(1)</script>\n```", []string{"&lt;script&gt;"}},
+	}
+	r := New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := r.Render(tt.source)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(out, s) {
+					t.Errorf("Render(%q) = %q, missing %q", tt.source, out, s)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderTOC(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+		absent []string
+	}{
+		{
+			name:   "one heading has no toc",
+			source: "# Only",
+			want:   []string{`<h1 id="md-only">Only</h1>`},
+			absent: []string{`<nav class="toc">`},
+		},
+		{
+			name:   "nested headings",
+			source: "# Setup\n## Install\n## Configure\n# Usage",
+			want: []string{
+				`<nav class="toc"><ul><li><a href="#md-setup">Setup</a><ul><li><a href="#md-install">Install</a></li><li><a href="#md-configure">Configure</a></li></ul></li><li><a href="#md-usage">Usage</a></li></ul></nav>`,
+				`<h2 id="md-install">Install</h2>`,
+			},
+		},
+		{
+			name:   "duplicate and empty titles",
+			source: "## Notes\n## Notes\n## !!!",
+			want:   []string{`id="md-notes"`, `id="md-notes-1"`, `id="md-section"`},
+		},
+		{
+			name:   "markup in titles is escaped",
+			source: "# 1 < 2\n# `code` & more",
+			want:   []string{`<a href="#md-1--2">1 &lt; 2</a>`, `<a href="#md-code--more">code &amp; more</a>`},
+		},
+	}
+	r := New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := r.Render(tt.source)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(out, s) {
+					t.Errorf("Render() = %q, missing %q", out, s)
+				}
+			}
+			for _, s := range tt.absent {
+				if strings.Contains(out, s) {
+					t.Errorf("Render() = %q, contains %q", out, s)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderTooLarge(t *testing.T) {
+	if _, err := New().Render(strings.Repeat("a", MaxSize+1)); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Render() error = %v, want ErrTooLarge", err)
+	}
+}
@@ -0,0 +1,121 @@
+// Package metrics owns the Prometheus registry for the service and the helpers
+// the rest of the code base uses to record domain events.
+package metrics
+
+import (
+	"net/http"
+	"pastebin/internal/language"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "pastebin"
+
+// Registry is the registry every collector in this package is registered with.
+// A dedicated registry keeps third-party packages from leaking metrics into /metrics.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests processed, labelled by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency, labelled by method, route template and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	pastesCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pastes_created_total",
+		Help:      "Pastes created, labelled by language.",
+	}, []string{"language"})
+
+	passwordCheckFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "paste_password_failures_total",
+		Help:      "Failed paste password checks, labelled by lookup kind (id, slug or update).",
+	}, []string{"lookup"})
+
+	authFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_failures_total",
+		Help:      "Authentication failures, labelled by reason.",
+	}, []string{"reason"})
+
+	secretFindings = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "secret_findings_total",
+		Help:      "Secrets found in paste content, labelled by rule and the action taken on the paste.",
+	}, []string{"rule", "action"})
+
+	viewIncrements = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "paste_view_increments_total",
+		Help:      "Paste view-count increments written to analytics.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		pastesCreated,
+		passwordCheckFailures,
+		authFailures,
+		secretFindings,
+		viewIncrements,
+	)
+}
+
+// Handler returns the HTTP handler that serves the registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// PasteCreated records a new paste. Language comes from clients, so only
+// languages in the registry get their own label value: empty values are
+// reported as "unknown" and anything else as "other", bounding the series
+// count whatever clients send.
+func PasteCreated(name string) {
+	label := "unknown"
+	if strings.TrimSpace(name) != "" {
+		label = "other"
+		if id, ok := language.Normalize(name); ok {
+			label = id
+		}
+	}
+	pastesCreated.WithLabelValues(label).Inc()
+}
+
+// PasswordCheckFailed records a wrong or missing password on a protected paste.
+func PasswordCheckFailed(lookup string) {
+	passwordCheckFailures.WithLabelValues(lookup).Inc()
+}
+
+// AuthFailed records a rejected login or bearer token.
+func AuthFailed(reason string) {
+	authFailures.WithLabelValues(reason).Inc()
+}
+
+// SecretFound records a secret found by rule in paste content. action is
+// what was done to the paste: warn, private or block.
+func SecretFound(rule, action string) {
+	secretFindings.WithLabelValues(rule, action).Inc()
+}
+
+// ViewCounted records a successful view-count increment.
+func ViewCounted() {
+	viewIncrements.Inc()
+}
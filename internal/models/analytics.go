@@ -7,9 +7,11 @@ import (
 )
 
 type Analytics struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	PasteID   uuid.UUID `json:"paste_id" db:"paste_id"`
-	URL       string    `json:"url" db:"url"`
+	ID      uuid.UUID `json:"id" db:"id"`
+	PasteID uuid.UUID `json:"paste_id" db:"paste_id"`
+	Slug    string    `json:"slug" db:"slug"`
+	// URL is built per request from Slug.
+	URL       string    `json:"url" db:"-"`
 	Views     int       `json:"views" db:"views"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
@@ -17,5 +19,32 @@ type Analytics struct {
 
 type AnalyticsInput struct {
 	PasteID uuid.UUID `json:"paste_id"`
-	URL     string    `json:"url"`
+}
+
+// AnalyticsSummary is the per-user dashboard rollup returned by GET /analytics/summary.
+type AnalyticsSummary struct {
+	TotalPastes     int                 `json:"total_pastes" db:"total_pastes"`
+	TotalViews      int                 `json:"total_views" db:"total_views"`
+	ViewsLast7Days  int                 `json:"views_last_7_days" db:"views_last_7_days"`
+	ViewsLast30Days int                 `json:"views_last_30_days" db:"views_last_30_days"`
+	ExpiringSoon    int                 `json:"expiring_next_24h" db:"expiring_next_24h"`
+	PrivatePastes   int                 `json:"private_pastes" db:"private_pastes"`
+	PublicPastes    int                 `json:"public_pastes" db:"public_pastes"`
+	TopPastes       []TopPaste          `json:"top_pastes" db:"top_pastes"`
+	Languages       []LanguageBreakdown `json:"languages" db:"languages"`
+}
+
+type TopPaste struct {
+	PasteID  uuid.UUID `json:"paste_id"`
+	Title    string    `json:"title"`
+	Slug     string    `json:"slug"`
+	URL      string    `json:"url"`
+	Language string    `json:"language"`
+	Views    int       `json:"views"`
+}
+
+type LanguageBreakdown struct {
+	Language string `json:"language"`
+	Pastes   int    `json:"pastes"`
+	Views    int    `json:"views"`
 }
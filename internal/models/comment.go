@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Comment is a comment on a paste, optionally anchored to a range of lines,
+// with its replies.
+type Comment struct {
+	ID       uuid.UUID  `json:"id" db:"id"`
+	PasteID  uuid.UUID  `json:"paste_id" db:"paste_id"`
+	UserID   uuid.UUID  `json:"user_id" db:"user_id"`
+	Author   string     `json:"author" db:"author"`
+	ParentID *uuid.UUID `json:"parent_id,omitempty" db:"parent_id"`
+	Body     string     `json:"body" db:"body"`
+	// LineStart and LineEnd are the 1-based, inclusive lines the comment is
+	// about.
+	LineStart *int `json:"line_start,omitempty" db:"line_start" example:"12"`
+	LineEnd   *int `json:"line_end,omitempty" db:"line_end" example:"14"`
+	// Deleted comments keep their place in the thread without a body.
+	Deleted   bool      `json:"deleted,omitempty" db:"deleted"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	Replies   []Comment `json:"replies,omitempty" db:"-"`
+}
+
+// CommentInput adds a comment to a paste, or a reply to one of its comments.
+type CommentInput struct {
+	Body string `json:"body" example:"This timeout is too short for the migration job."`
+	// ParentID makes the comment a reply.
+	ParentID *uuid.UUID `json:"parent_id,omitempty"`
+	// LineStart anchors the comment to a line, or with LineEnd to a range.
+	LineStart *int `json:"line_start,omitempty" example:"12"`
+	LineEnd   *int `json:"line_end,omitempty" example:"14"`
+}
+
+// CommentUpdate changes the body of a comment.
+type CommentUpdate struct {
+	Body string `json:"body"`
+}
@@ -1,33 +1,95 @@
 package models
 
 import (
+	"pastebin/internal/secretscan"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type PasteInput struct {
-	Title     string     `json:"title"`
-	Content   string     `json:"content"`
-	Language  string     `json:"language"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	// Language is an ID, name, alias or extension from GET /languages. It is
+	// detected from Content, with Title as a file name hint, when omitted.
+	Language  string     `json:"language" example:"go"`
 	Password  string     `json:"password"`
 	ExpiresIn string     `json:"expires_in,omitempty"`
 	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Slug requests a custom slug, as in /p/deploy-runbook, instead of a
+	// generated one.
+	Slug string `json:"slug,omitempty" example:"deploy-runbook"`
+	// Encryption marks Content as base64 ciphertext produced by the client.
+	Encryption *ClientEncryption `json:"encryption,omitempty"`
+	// IsPrivate keeps the paste to its owner. Pastes with a password are
+	// always private.
+	IsPrivate bool `json:"is_private,omitempty"`
+	// Unlisted keeps a public paste out of GET /explore and GET /trending.
+	Unlisted bool `json:"unlisted,omitempty"`
+	// SecretAllowlist holds rule IDs and finding fingerprints the secret
+	// scanner should accept in this paste as false positives.
+	SecretAllowlist []string `json:"secret_allowlist,omitempty"`
+	// Redact masks emails, IP addresses, bearer tokens, JWTs, card numbers
+	// and matches of RedactPatterns in Content with placeholders such as
+	// <IP_1> before it is stored.
+	Redact bool `json:"redact,omitempty"`
+	// RedactPatterns are extra regular expressions to redact. When one has a
+	// group named "value", only that group is redacted.
+	RedactPatterns []string `json:"redact_patterns,omitempty" example:"password=(?P<value>\\S+)"`
+	// ForkedFrom is set by POST /p/{slug}/fork, never by clients.
+	ForkedFrom *uuid.UUID `json:"-"`
+}
+
+// ClientEncryption describes how a client encrypted paste content before
+// submitting it. The key is never sent to the server.
+type ClientEncryption struct {
+	Algorithm string `json:"algorithm" example:"aes-256-gcm"`
+	// Nonce is base64 encoded.
+	Nonce string `json:"nonce"`
 }
 
 type PasteOutput struct {
-	ID           uuid.UUID  `json:"id" db:"id"`
-	UserID       uuid.UUID  `json:"user_id" db:"user_id"`
-	Title        string     `json:"title" db:"title"`
-	IsPrivate    bool       `json:"is_private" db:"is_private"`
-	Content      string     `json:"content" db:"content"`
-	PasswordHash string     `json:"-" db:"password"`
-	Language     string     `json:"language" db:"language"`
-	URL          string     `json:"url" db:"url"`
-	Views        int        `json:"views" db:"views"`
-	ExpiresAt    *time.Time `json:"expires_at,omitempty" db:"expires_at"`
-	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+	ID        uuid.UUID `json:"id" db:"id"`
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	Title     string    `json:"title" db:"title"`
+	IsPrivate bool      `json:"is_private" db:"is_private"`
+	Content   string    `json:"content" db:"content"`
+	// ContentLocked is set when the content is encrypted and was requested
+	// without the paste password, leaving Content empty.
+	ContentLocked bool   `json:"content_locked,omitempty" db:"-"`
+	PasswordHash  string `json:"-" db:"password"`
+	// Encryption is set when Content is ciphertext encrypted by the client.
+	Encryption *ClientEncryption `json:"encryption,omitempty" db:"client_encryption"`
+	Language   string            `json:"language" db:"language"`
+	// RenderedHTML is the sanitized HTML of a Markdown paste, set only when
+	// asked for with include=rendered_html.
+	RenderedHTML string `json:"rendered_html,omitempty" db:"-"`
+	Slug         string `json:"slug" db:"slug"`
+	// URL is built per request from Slug and the origin the request came in on.
+	URL string `json:"url" db:"-"`
+	// ForkedFrom is the ID of the paste this one was forked from.
+	ForkedFrom *uuid.UUID `json:"forked_from,omitempty" db:"forked_from"`
+	// Forks counts the live forks of this paste, private ones included.
+	Forks int `json:"forks" db:"forks"`
+	// Lineage lists the pastes this one descends from, its source first,
+	// leaving out those the caller cannot see.
+	Lineage []ForkRef `json:"lineage,omitempty" db:"-"`
+	// Unlisted keeps a public paste out of the explore and trending feeds.
+	Unlisted bool `json:"unlisted" db:"unlisted"`
+	// Stars counts the users who starred this paste.
+	Stars int `json:"stars" db:"stars"`
+	// CommentsEnabled is false when the owner turned comments off.
+	CommentsEnabled bool `json:"comments_enabled" db:"comments_enabled"`
+	// SecretFindings lists credentials found in the content when it was
+	// created.
+	SecretFindings []secretscan.Finding `json:"secret_findings,omitempty" db:"-"`
+	// Redactions counts the values redacted from the content when it was
+	// created, by category.
+	Redactions map[string]int `json:"redactions,omitempty" db:"-"`
+	Views      int            `json:"views" db:"views"`
+	ExpiresAt  *time.Time     `json:"expires_at,omitempty" db:"expires_at"`
+	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at" db:"updated_at"`
 }
 
 type PatchPaste struct {
@@ -38,7 +100,53 @@ type PatchPaste struct {
 	Language  *string    `json:"language" db:"language"`
 	IsPrivate *bool      `json:"is_private" db:"is_private"`
 	Password  *string    `json:"password" db:"password"`
-	ExpiresAt *time.Time `json:"expires_at" db:"expires_at"`
+	// CurrentPassword unlocks encrypted content when the password changes
+	// without new content being supplied.
+	CurrentPassword *string    `json:"current_password,omitempty" db:"-"`
+	ExpiresAt       *time.Time `json:"expires_at" db:"expires_at"`
+	// Encryption must accompany new content of a client-encrypted paste.
+	Encryption *ClientEncryption `json:"encryption,omitempty" db:"client_encryption"`
+	// SecretAllowlist replaces the paste's accepted secret scanner findings.
+	SecretAllowlist *[]string `json:"secret_allowlist,omitempty" db:"secret_allowlist"`
+	// CommentsEnabled turns new comments on the paste on or off.
+	CommentsEnabled *bool `json:"comments_enabled,omitempty" db:"comments_enabled"`
+	// Unlisted keeps a public paste out of the explore and trending feeds.
+	Unlisted *bool `json:"unlisted,omitempty" db:"unlisted"`
+}
+
+// PasteUpdateOutput reports what updating a paste found.
+type PasteUpdateOutput struct {
+	// SecretFindings lists credentials found in the updated paste.
+	SecretFindings []secretscan.Finding `json:"secret_findings,omitempty"`
+}
+
+// ForkInput creates a fork of a paste owned by the caller.
+type ForkInput struct {
+	// Password opens a password-protected source paste and protects the fork.
+	Password string `json:"password,omitempty"`
+	// Title defaults to the source paste's title.
+	Title string `json:"title,omitempty"`
+	// Slug requests a custom slug for the fork.
+	Slug string `json:"slug,omitempty" example:"deploy-runbook-v2"`
+}
+
+// ForkRef identifies a paste in a fork lineage.
+type ForkRef struct {
+	ID    uuid.UUID `json:"id" db:"id"`
+	Title string    `json:"title" db:"title"`
+	Slug  string    `json:"slug" db:"slug"`
+	URL   string    `json:"url" db:"-"`
+}
+
+// SlugInput renames a paste's slug. The old slug keeps redirecting to it.
+type SlugInput struct {
+	Slug string `json:"slug" example:"deploy-runbook"`
+}
+
+// SlugOutput is a paste's slug and the URL it is shared under.
+type SlugOutput struct {
+	Slug string `json:"slug"`
+	URL  string `json:"url"`
 }
 
 type PaginatedPastesResponse struct {
@@ -48,3 +156,30 @@ type PaginatedPastesResponse struct {
 	Offset  int           `json:"offset"`
 	HasMore bool          `json:"has_more"`
 }
+
+// TrendingPaste is a paste in the trending feed with the views it got in the
+// feed's window.
+type TrendingPaste struct {
+	PasteOutput
+	WindowViews int64 `json:"window_views" db:"window_views"`
+}
+
+// TrendingPastesResponse is a page of the trending feed.
+type TrendingPastesResponse struct {
+	// Window is the period views were counted over.
+	Window string          `json:"window" example:"24h"`
+	Pastes []TrendingPaste `json:"pastes"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+}
+
+// StoredContent is paste content as kept in the database. Codec is "identity"
+// when Data is the plain text, otherwise the compression applied to it.
+type StoredContent struct {
+	Codec string
+	Data  []byte
+	// Encryption is set when the content is ciphertext encrypted by the client.
+	Encryption *ClientEncryption
+	// Language names the download file's extension.
+	Language string
+}
@@ -0,0 +1,14 @@
+package models
+
+// SecretRuleInput creates or changes a secret scanning rule. For a built-in
+// rule an empty pattern or description keeps the built-in one.
+type SecretRuleInput struct {
+	Description string `json:"description" example:"Internal deploy token"`
+	Pattern     string `json:"pattern" example:"\\bdpl_[A-Za-z0-9]{32}\\b"`
+	// MinEntropy is the Shannon entropy in bits per character a match needs.
+	MinEntropy float64 `json:"min_entropy" example:"0"`
+	// Action overrides the configured policy: warn, private or block.
+	Action string `json:"action,omitempty" example:"block"`
+	// Enabled defaults to true.
+	Enabled *bool `json:"enabled,omitempty"`
+}
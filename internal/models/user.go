@@ -10,6 +10,23 @@ type User struct {
 	Email        string    `json:"email" db:"email"`
 	Avatar       string    `json:"avatar" db:"avatar"`
 	PasswordHash string    `json:"-" db:"password_hash"`
+	// Storage is only filled in for the user's own profile.
+	Storage *StorageUsage `json:"storage,omitempty" db:"-"`
+}
+
+// StorageUsage is how much paste content a user stores against their quota.
+type StorageUsage struct {
+	UsedBytes int64 `json:"used_bytes"`
+	// QuotaBytes is 0 when the user has no storage limit.
+	QuotaBytes int64 `json:"quota_bytes"`
+	// CustomQuota is true when an admin has overridden the default quota.
+	CustomQuota bool `json:"custom_quota"`
+}
+
+// StorageQuotaInput sets a user's storage quota. A null quota_bytes restores
+// the configured default; 0 removes the limit.
+type StorageQuotaInput struct {
+	QuotaBytes *int64 `json:"quota_bytes"`
 }
 
 // PatchProfile represents optional fields for partial profile updates
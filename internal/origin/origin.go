@@ -0,0 +1,102 @@
+// Package origin works out the public origin, such as
+// https://paste.example.com, a request reached the service under, so links in
+// responses point back at it. Pastes store only their slug; their URL is built
+// per request, and moving the service to a new hostname leaves them intact.
+package origin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type contextKey string
+
+const originCtxKey contextKey = "origin"
+
+// Resolver maps the host a request was made to onto one of the configured
+// origins. Hosts that match none get the default origin, so a forged Host
+// header cannot make the service hand out links to another site.
+type Resolver struct {
+	fallback   string
+	byHost     map[string]string
+	trustProxy bool
+}
+
+// NewResolver returns a resolver defaulting to baseURL that also accepts the
+// origins in allowed. With trustProxy set the host is taken from
+// X-Forwarded-Host when a proxy sent one.
+func NewResolver(baseURL string, allowed []string, trustProxy bool) (*Resolver, error) {
+	r := &Resolver{byHost: make(map[string]string), trustProxy: trustProxy}
+	for i, raw := range append([]string{baseURL}, allowed...) {
+		o, host, err := parse(raw)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			r.fallback = o
+		}
+		if _, ok := r.byHost[host]; !ok {
+			r.byHost[host] = o
+		}
+	}
+	return r, nil
+}
+
+func parse(raw string) (string, string, error) {
+	u, err := url.Parse(strings.TrimRight(strings.TrimSpace(raw), "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", "", fmt.Errorf("origin %q must be an absolute URL", raw)
+	}
+	if u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+		return "", "", fmt.Errorf("origin %q must not have a path, query or fragment", raw)
+	}
+	return u.Scheme + "://" + u.Host, strings.ToLower(u.Host), nil
+}
+
+// Resolve returns the configured origin matching the request's host.
+func (r *Resolver) Resolve(req *http.Request) string {
+	host := req.Host
+	if r.trustProxy {
+		// Proxies append, so the first entry is the host the client asked for
+		if forwarded, _, _ := strings.Cut(req.Header.Get("X-Forwarded-Host"), ","); strings.TrimSpace(forwarded) != "" {
+			host = strings.TrimSpace(forwarded)
+		}
+	}
+	if o, ok := r.byHost[strings.ToLower(host)]; ok {
+		return o
+	}
+	return r.fallback
+}
+
+// Middleware stores the request's public origin in its context.Context.
+func Middleware(r *Resolver) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			c.SetRequest(req.WithContext(WithOrigin(req.Context(), r.Resolve(req))))
+			return next(c)
+		}
+	}
+}
+
+// WithOrigin returns a copy of ctx carrying origin.
+func WithOrigin(ctx context.Context, origin string) context.Context {
+	return context.WithValue(ctx, originCtxKey, origin)
+}
+
+// FromContext returns the origin stored in ctx, or "" outside of a request,
+// which leaves links relative.
+func FromContext(ctx context.Context) string {
+	o, _ := ctx.Value(originCtxKey).(string)
+	return o
+}
+
+// PasteURL returns the shareable URL of the paste with slug.
+func PasteURL(ctx context.Context, slug string) string {
+	return FromContext(ctx) + "/p/" + url.PathEscape(slug)
+}
@@ -0,0 +1,99 @@
+package origin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestNewResolver(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		allowed []string
+		wantErr bool
+	}{
+		{"base url", "https://paste.example.com", nil, false},
+		{"trailing slash", "https://paste.example.com/", []string{"http://localhost:8080/"}, false},
+		{"relative", "paste.example.com", nil, true},
+		{"with path", "https://example.com/paste", nil, true},
+		{"with query", "https://paste.example.com?x=1", nil, true},
+		{"bad allowed origin", "https://paste.example.com", []string{"/mirror"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewResolver(tt.baseURL, tt.allowed, false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewResolver() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	allowed := []string{"https://mirror.example.org", "http://localhost:8080"}
+	tests := []struct {
+		name          string
+		trustProxy    bool
+		host          string
+		forwardedHost string
+		want          string
+	}{
+		{"base host", false, "paste.example.com", "", "https://paste.example.com"},
+		{"allowed host", false, "mirror.example.org", "", "https://mirror.example.org"},
+		{"host with port", false, "localhost:8080", "", "http://localhost:8080"},
+		{"host case", false, "MIRROR.example.org", "", "https://mirror.example.org"},
+		{"forged host", false, "evil.example", "", "https://paste.example.com"},
+		{"forwarded host ignored without trust", false, "paste.example.com", "mirror.example.org", "https://paste.example.com"},
+		{"forged forwarded host ignored without trust", false, "paste.example.com", "evil.example", "https://paste.example.com"},
+		{"forwarded host behind proxy", true, "internal:8080", "mirror.example.org", "https://mirror.example.org"},
+		{"forged forwarded host behind proxy", true, "paste.example.com", "evil.example", "https://paste.example.com"},
+		{"first forwarded host wins", true, "internal:8080", "mirror.example.org, evil.example", "https://mirror.example.org"},
+		{"forged first forwarded host", true, "internal:8080", "evil.example, mirror.example.org", "https://paste.example.com"},
+		{"blank forwarded host", true, "mirror.example.org", " ", "https://mirror.example.org"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewResolver("https://paste.example.com", allowed, tt.trustProxy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/p/abc", nil)
+			req.Host = tt.host
+			if tt.forwardedHost != "" {
+				req.Header.Set("X-Forwarded-Host", tt.forwardedHost)
+			}
+			if got := r.Resolve(req); got != tt.want {
+				t.Fatalf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddlewareAndPasteURL(t *testing.T) {
+	r, err := NewResolver("https://paste.example.com", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/p/abc", nil)
+	req.Host = "paste.example.com"
+	c := e.NewContext(req, httptest.NewRecorder())
+	var got string
+	handler := Middleware(r)(func(c echo.Context) error {
+		got = PasteURL(c.Request().Context(), "my notes")
+		return nil
+	})
+	if err := handler(c); err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://paste.example.com/p/my%20notes"; got != want {
+		t.Fatalf("PasteURL() = %q, want %q", got, want)
+	}
+	if got := PasteURL(context.Background(), "abc"); got != "/p/abc" {
+		t.Fatalf("PasteURL() outside a request = %q, want a relative link", got)
+	}
+}
@@ -0,0 +1,134 @@
+// Package pastecrypt encrypts paste content under a key derived from the paste
+// password, so the server can only read a protected paste while someone who
+// knows the password is asking for it.
+//
+// Keys are derived with Argon2id and content is sealed with AES-256-GCM. The
+// KDF parameters are stored in every envelope so they can be raised later
+// without breaking existing pastes.
+package pastecrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// ErrDecrypt is returned when an envelope cannot be opened, almost always
+// because the password is wrong.
+var ErrDecrypt = errors.New("unable to decrypt paste content")
+
+const (
+	version  = 1
+	saltSize = 16
+	keySize  = 32
+	// headerSize is version, time, memory and threads followed by the salt.
+	headerSize = 1 + 4 + 4 + 1 + saltSize
+
+	// Envelopes asking for more than this are rejected rather than letting a
+	// tampered row make the server burn memory.
+	maxTime   = 16
+	maxMemory = 1024 * 1024 // KiB
+
+	// maxConcurrentKDF bounds how many key derivations run at once, so a
+	// burst of password guesses queues instead of each allocating its own
+	// Argon2 memory: with DefaultParams at most 256 MiB is in use.
+	maxConcurrentKDF = 4
+)
+
+// kdfSlots holds a token for every key derivation in progress.
+var kdfSlots = make(chan struct{}, maxConcurrentKDF)
+
+// Params are the Argon2id cost parameters used for new envelopes.
+type Params struct {
+	Time    uint32
+	Memory  uint32 // KiB
+	Threads uint8
+}
+
+// DefaultParams follow the second recommended option of RFC 9106 scaled to a
+// web request: 3 passes over 64 MiB.
+var DefaultParams = Params{Time: 3, Memory: 64 * 1024, Threads: 4}
+
+// Seal encrypts plaintext under password with DefaultParams.
+func Seal(password string, plaintext []byte) ([]byte, error) {
+	return SealWithParams(DefaultParams, password, plaintext)
+}
+
+// SealWithParams encrypts plaintext under password. The envelope is
+//
+//	version | time | memory | threads | salt | nonce | ciphertext+tag
+func SealWithParams(params Params, password string, plaintext []byte) ([]byte, error) {
+	header := make([]byte, headerSize)
+	header[0] = version
+	binary.BigEndian.PutUint32(header[1:5], params.Time)
+	binary.BigEndian.PutUint32(header[5:9], params.Memory)
+	header[9] = params.Threads
+	salt := header[10:]
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("generate salt: %w", err)
+	}
+
+	aead, err := newAEAD(params, password, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+
+	out := make([]byte, 0, len(header)+len(nonce)+len(plaintext)+aead.Overhead())
+	out = append(out, header...)
+	out = append(out, nonce...)
+	// The header is authenticated so its parameters cannot be tampered with.
+	return aead.Seal(out, nonce, plaintext, header), nil
+}
+
+// Open decrypts an envelope produced by Seal.
+func Open(password string, envelope []byte) ([]byte, error) {
+	if len(envelope) < headerSize || envelope[0] != version {
+		return nil, fmt.Errorf("%w: malformed envelope", ErrDecrypt)
+	}
+	header := envelope[:headerSize]
+	params := Params{
+		Time:    binary.BigEndian.Uint32(header[1:5]),
+		Memory:  binary.BigEndian.Uint32(header[5:9]),
+		Threads: header[9],
+	}
+	aead, err := newAEAD(params, password, header[10:])
+	if err != nil {
+		return nil, err
+	}
+	rest := envelope[headerSize:]
+	if len(rest) < aead.NonceSize() {
+		return nil, fmt.Errorf("%w: malformed envelope", ErrDecrypt)
+	}
+	plaintext, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], header)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+func newAEAD(params Params, password string, salt []byte) (cipher.AEAD, error) {
+	if params.Time == 0 || params.Memory == 0 || params.Threads == 0 || params.Time > maxTime || params.Memory > maxMemory {
+		return nil, fmt.Errorf("%w: invalid key derivation parameters", ErrDecrypt)
+	}
+	kdfSlots <- struct{}{}
+	key := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, keySize)
+	<-kdfSlots
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create gcm: %w", err)
+	}
+	return aead, nil
+}
@@ -0,0 +1,93 @@
+package pastecrypt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// testParams keep key derivation cheap; the envelope format is the same.
+var testParams = Params{Time: 1, Memory: 64, Threads: 1}
+
+func TestSealOpen(t *testing.T) {
+	tests := []struct {
+		name      string
+		params    Params
+		password  string
+		plaintext []byte
+	}{
+		{"default params", DefaultParams, "correct horse battery staple", []byte("kubectl apply -f deploy.yaml")},
+		{"cheap params", testParams, "hunter2", []byte("line one\nline two\n")},
+		{"empty content", testParams, "hunter2", []byte{}},
+		{"unicode password", testParams, "pässwörd 🔑", []byte("secret")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			envelope, err := SealWithParams(tt.params, tt.password, tt.plaintext)
+			if err != nil {
+				t.Fatalf("SealWithParams() error = %v", err)
+			}
+			if len(tt.plaintext) > 0 && bytes.Contains(envelope, tt.plaintext) {
+				t.Fatal("envelope contains the plaintext")
+			}
+			got, err := Open(tt.password, envelope)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			if !bytes.Equal(got, tt.plaintext) {
+				t.Fatalf("Open() = %q, want %q", got, tt.plaintext)
+			}
+		})
+	}
+}
+
+func TestSealIsRandomized(t *testing.T) {
+	a, err := SealWithParams(testParams, "hunter2", []byte("same"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := SealWithParams(testParams, "hunter2", []byte("same"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(a, b) {
+		t.Fatal("sealing twice produced the same envelope")
+	}
+}
+
+func TestOpenRejects(t *testing.T) {
+	envelope, err := SealWithParams(testParams, "hunter2", []byte("deploy key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tamper := func(f func(e []byte) []byte) []byte {
+		return f(bytes.Clone(envelope))
+	}
+	tests := []struct {
+		name     string
+		password string
+		envelope []byte
+	}{
+		{"wrong password", "hunter3", envelope},
+		{"empty password", "", envelope},
+		{"flipped ciphertext bit", "hunter2", tamper(func(e []byte) []byte { e[len(e)-1] ^= 1; return e })},
+		{"flipped salt bit", "hunter2", tamper(func(e []byte) []byte { e[10] ^= 1; return e })},
+		{"raised time cost", "hunter2", tamper(func(e []byte) []byte { binary.BigEndian.PutUint32(e[1:5], 2); return e })},
+		{"excessive memory cost", "hunter2", tamper(func(e []byte) []byte { binary.BigEndian.PutUint32(e[5:9], maxMemory+1); return e })},
+		{"zero threads", "hunter2", tamper(func(e []byte) []byte { e[9] = 0; return e })},
+		{"unknown version", "hunter2", tamper(func(e []byte) []byte { e[0] = version + 1; return e })},
+		{"truncated header", "hunter2", envelope[:headerSize-1]},
+		{"truncated nonce", "hunter2", envelope[:headerSize+4]},
+		{"truncated tag", "hunter2", envelope[:len(envelope)-1]},
+		{"empty", "hunter2", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Open(tt.password, tt.envelope)
+			if !errors.Is(err, ErrDecrypt) {
+				t.Fatalf("Open() = %q, %v; want ErrDecrypt", got, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+
+	"pastebin/internal/auth"
+	"pastebin/internal/logging"
+	"pastebin/pkg/utils"
+)
+
+// Group names a route group with its own budget.
+type Group string
+
+const (
+	// GroupPublicRead covers unauthenticated paste reads, keyed by client IP.
+	GroupPublicRead Group = "public_read"
+	// GroupAuth covers login and registration, keyed by client IP.
+	GroupAuth Group = "auth"
+	// GroupRead covers authenticated reads, keyed by user ID.
+	GroupRead Group = "read"
+	// GroupWrite covers authenticated writes, keyed by user ID.
+	GroupWrite Group = "write"
+	// GroupPasswordCheck covers requests that give a paste password, on top
+	// of their route group, since each wrong guess costs a key derivation.
+	GroupPasswordCheck Group = "password_check"
+)
+
+// Limiter applies per-group rules against a Store.
+type Limiter struct {
+	store  Store
+	rules  map[Group]Rule
+	logger zerolog.Logger
+}
+
+func NewLimiter(store Store, rules map[Group]Rule, logger zerolog.Logger) *Limiter {
+	return &Limiter{
+		store:  store,
+		rules:  rules,
+		logger: logger,
+	}
+}
+
+// Middleware enforces the budget of group. Requests that went through
+// AuthMiddleware are keyed by user ID, everything else by client IP. A nil
+// Limiter, or a group without a rule, lets every request through.
+//
+// If the store fails the request is allowed: a database hiccup should not take
+// the API down with it.
+func (l *Limiter) Middleware(group Group) echo.MiddlewareFunc {
+	return l.MiddlewareIf(group, nil)
+}
+
+// MiddlewareIf is Middleware for the requests applies reports true for; the
+// others pass through without spending the budget. A nil applies matches every
+// request.
+func (l *Limiter) MiddlewareIf(group Group, applies func(echo.Context) bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if l == nil {
+			return next
+		}
+		rule, ok := l.rules[group]
+		if !ok || rule.Burst <= 0 {
+			return next
+		}
+		return func(c echo.Context) error {
+			if applies != nil && !applies(c) {
+				return next(c)
+			}
+			ctx := c.Request().Context()
+			res, err := l.store.Take(ctx, key(c, group), rule)
+			if err != nil {
+				logging.FromContext(ctx, l.logger).Warn().Err(err).Str("group", string(group)).Msg("rate limiter unavailable, allowing request")
+				return next(c)
+			}
+
+			h := c.Response().Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+			h.Set("RateLimit-Policy", strconv.Itoa(rule.PerMinute)+";w=60")
+			if !res.Allowed {
+				h.Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+				return utils.SendError(c, http.StatusTooManyRequests, "rate limit exceeded, retry later")
+			}
+			return next(c)
+		}
+	}
+}
+
+// Run periodically drops idle buckets until ctx is cancelled. It is registered
+// as a background worker.
+func (l *Limiter) Run(ctx context.Context) error {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := l.store.Cleanup(ctx, l.maxIdle()); err != nil {
+				logging.FromContext(ctx, l.logger).Warn().Err(err).Msg("failed to clean up rate limit buckets")
+			}
+		}
+	}
+}
+
+// maxIdle is the longest time any bucket needs to refill completely; an idle
+// bucket older than that is indistinguishable from a new one.
+func (l *Limiter) maxIdle() time.Duration {
+	longest := time.Minute
+	for _, rule := range l.rules {
+		if rule.PerMinute <= 0 {
+			continue
+		}
+		refill := time.Duration(float64(rule.Burst) / rule.ratePerSecond() * float64(time.Second))
+		longest = max(longest, refill)
+	}
+	return longest
+}
+
+func key(c echo.Context, group Group) string {
+	if userID, err := auth.GetUserIDFromEchoContext(c); err == nil {
+		return string(group) + ":user:" + userID.String()
+	}
+	return string(group) + ":ip:" + c.RealIP()
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,49 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresStore keeps buckets in the rate_limit_buckets table so every replica
+// shares the same budget. Each Take is a single upsert; the refill and the
+// allow decision are computed from the old row inside the statement, so
+// concurrent requests for the same key serialize on the row lock.
+type PostgresStore struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresStore(db *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (p *PostgresStore) Take(ctx context.Context, key string, rule Rule) (Result, error) {
+	query := `INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+		VALUES ($1, $2::double precision - 1, true, clock_timestamp())
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = CASE
+				WHEN LEAST($2, b.tokens + EXTRACT(EPOCH FROM clock_timestamp() - b.updated_at) * $3) >= 1
+				THEN LEAST($2, b.tokens + EXTRACT(EPOCH FROM clock_timestamp() - b.updated_at) * $3) - 1
+				ELSE LEAST($2, b.tokens + EXTRACT(EPOCH FROM clock_timestamp() - b.updated_at) * $3)
+			END,
+			allowed = LEAST($2, b.tokens + EXTRACT(EPOCH FROM clock_timestamp() - b.updated_at) * $3) >= 1,
+			updated_at = clock_timestamp()
+		RETURNING tokens, allowed`
+	var tokens float64
+	var allowed bool
+	if err := p.db.QueryRow(ctx, query, key, float64(rule.Burst), rule.ratePerSecond()).Scan(&tokens, &allowed); err != nil {
+		return Result{}, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+	return result(allowed, tokens, rule), nil
+}
+
+func (p *PostgresStore) Cleanup(ctx context.Context, maxIdle time.Duration) error {
+	query := `DELETE FROM rate_limit_buckets WHERE updated_at < NOW() - make_interval(secs => $1)`
+	if _, err := p.db.Exec(ctx, query, maxIdle.Seconds()); err != nil {
+		return fmt.Errorf("failed to clean up rate limit buckets: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+// Package ratelimit implements token-bucket rate limiting for HTTP routes with
+// pluggable bucket storage: in-process memory for a single replica, or
+// Postgres when several replicas must share budgets.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Rule is a token bucket: Burst requests may be made at once, refilled at
+// PerMinute tokens per minute.
+type Rule struct {
+	PerMinute int
+	Burst     int
+}
+
+func (r Rule) ratePerSecond() float64 {
+	return float64(r.PerMinute) / 60
+}
+
+// Result describes the state of a bucket after a Take.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until the next request would be allowed. Zero when Allowed.
+	RetryAfter time.Duration
+}
+
+// Store takes one token from the bucket identified by key.
+type Store interface {
+	Take(ctx context.Context, key string, rule Rule) (Result, error)
+	// Cleanup drops buckets that have been idle for longer than maxIdle.
+	Cleanup(ctx context.Context, maxIdle time.Duration) error
+}
+
+// result derives the reported numbers from the tokens left in a bucket.
+func result(allowed bool, tokens float64, rule Rule) Result {
+	rate := rule.ratePerSecond()
+	res := Result{
+		Allowed:   allowed,
+		Limit:     rule.Burst,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+	}
+	if rate <= 0 {
+		return res
+	}
+	res.Reset = secondsToDuration((float64(rule.Burst) - tokens) / rate)
+	if !allowed {
+		res.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+	return res
+}
+
+func secondsToDuration(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
@@ -0,0 +1,175 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+// fakeClock is a settable time source for MemoryStore.
+type fakeClock struct{ t time.Time }
+
+func (f *fakeClock) now() time.Time { return f.t }
+
+func newTestStore() (*MemoryStore, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	store := NewMemoryStore()
+	store.now = clock.now
+	return store, clock
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	rule := Rule{PerMinute: 60, Burst: 3}
+	// Each step advances the clock by wait, then takes one token
+	type step struct {
+		wait          time.Duration
+		wantAllowed   bool
+		wantRemaining int
+		wantRetry     time.Duration
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "burst then refused",
+			steps: []step{
+				{0, true, 2, 0},
+				{0, true, 1, 0},
+				{0, true, 0, 0},
+				{0, false, 0, time.Second},
+			},
+		},
+		{
+			name: "refills at the rate",
+			steps: []step{
+				{0, true, 2, 0},
+				{0, true, 1, 0},
+				{0, true, 0, 0},
+				{500 * time.Millisecond, false, 0, 500 * time.Millisecond},
+				{500 * time.Millisecond, true, 0, 0},
+			},
+		},
+		{
+			name: "never refills above the burst",
+			steps: []step{
+				{0, true, 2, 0},
+				{time.Hour, true, 2, 0},
+				{0, true, 1, 0},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, clock := newTestStore()
+			for i, s := range tt.steps {
+				clock.t = clock.t.Add(s.wait)
+				res, err := store.Take(context.Background(), "k", rule)
+				if err != nil {
+					t.Fatalf("step %d: Take() error = %v", i, err)
+				}
+				if res.Allowed != s.wantAllowed || res.Remaining != s.wantRemaining || res.RetryAfter != s.wantRetry {
+					t.Fatalf("step %d: Take() = allowed %v, remaining %d, retry after %v; want %v, %d, %v",
+						i, res.Allowed, res.Remaining, res.RetryAfter, s.wantAllowed, s.wantRemaining, s.wantRetry)
+				}
+				if res.Limit != rule.Burst {
+					t.Fatalf("step %d: Limit = %d, want %d", i, res.Limit, rule.Burst)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryStoreKeysAreIndependent(t *testing.T) {
+	store, _ := newTestStore()
+	rule := Rule{PerMinute: 1, Burst: 1}
+	ctx := context.Background()
+	if res, _ := store.Take(ctx, "a", rule); !res.Allowed {
+		t.Fatal("first take on a refused")
+	}
+	if res, _ := store.Take(ctx, "a", rule); res.Allowed {
+		t.Fatal("second take on a allowed")
+	}
+	if res, _ := store.Take(ctx, "b", rule); !res.Allowed {
+		t.Fatal("first take on b refused")
+	}
+}
+
+func TestMemoryStoreCleanup(t *testing.T) {
+	store, clock := newTestStore()
+	ctx := context.Background()
+	rule := Rule{PerMinute: 60, Burst: 1}
+	store.Take(ctx, "old", rule)
+	clock.t = clock.t.Add(2 * time.Minute)
+	store.Take(ctx, "new", rule)
+	if err := store.Cleanup(ctx, time.Minute); err != nil {
+		t.Fatalf("Cleanup() error = %v", err)
+	}
+	if _, ok := store.buckets["old"]; ok {
+		t.Error("idle bucket was kept")
+	}
+	if _, ok := store.buckets["new"]; !ok {
+		t.Error("recent bucket was dropped")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	rules := map[Group]Rule{GroupPublicRead: {PerMinute: 60, Burst: 2}}
+	withPassword := func(c echo.Context) bool { return c.QueryParam("password") != "" }
+	tests := []struct {
+		name     string
+		limiter  func() *Limiter
+		group    Group
+		applies  func(echo.Context) bool
+		target   string
+		requests int
+		wantLast int
+	}{
+		{"within burst", newTestLimiter(rules), GroupPublicRead, nil, "/", 2, http.StatusOK},
+		{"over burst", newTestLimiter(rules), GroupPublicRead, nil, "/", 3, http.StatusTooManyRequests},
+		{"group without rule", newTestLimiter(rules), GroupWrite, nil, "/", 10, http.StatusOK},
+		{"nil limiter", func() *Limiter { return nil }, GroupPublicRead, nil, "/", 10, http.StatusOK},
+		{"condition not met", newTestLimiter(rules), GroupPublicRead, withPassword, "/", 10, http.StatusOK},
+		{"condition met", newTestLimiter(rules), GroupPublicRead, withPassword, "/?password=guess", 3, http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			handler := tt.limiter().MiddlewareIf(tt.group, tt.applies)(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+			var rec *httptest.ResponseRecorder
+			for range tt.requests {
+				req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+				req.RemoteAddr = "192.0.2.1:1234"
+				rec = httptest.NewRecorder()
+				if err := handler(e.NewContext(req, rec)); err != nil {
+					t.Fatalf("handler error = %v", err)
+				}
+			}
+			if rec.Code != tt.wantLast {
+				t.Fatalf("last status = %d, want %d", rec.Code, tt.wantLast)
+			}
+			if rec.Code == http.StatusTooManyRequests {
+				if got := rec.Header().Get("Retry-After"); got != "1" {
+					t.Errorf("Retry-After = %q, want 1", got)
+				}
+				if got := rec.Header().Get("RateLimit-Remaining"); got != "0" {
+					t.Errorf("RateLimit-Remaining = %q, want 0", got)
+				}
+			}
+		})
+	}
+}
+
+func newTestLimiter(rules map[Group]Rule) func() *Limiter {
+	return func() *Limiter {
+		store, _ := newTestStore()
+		return NewLimiter(store, rules, zerolog.Nop())
+	}
+}
@@ -0,0 +1,244 @@
+// Package redact masks personal and sensitive values in text, such as the
+// emails, addresses and tokens in a pasted log, with numbered placeholders.
+// The same value always gets the same placeholder, so the text stays
+// readable: every occurrence of one address becomes <IP_1>, the next one
+// <IP_2>.
+package redact
+
+import (
+	"fmt"
+	"net/netip"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Categories of redacted values, as reported in counts.
+const (
+	CategoryEmail  = "email"
+	CategoryIP     = "ip"
+	CategoryBearer = "bearer_token"
+	CategoryJWT    = "jwt"
+	CategoryCard   = "card"
+	// CategoryCustom covers matches of user-defined patterns.
+	CategoryCustom = "custom"
+)
+
+const (
+	// MaxPatterns bounds the user-defined patterns of one redaction.
+	MaxPatterns = 20
+	// maxPatternLength bounds the length of one user-defined pattern.
+	maxPatternLength = 512
+	// valueGroup names the group of a pattern that holds the value to
+	// redact; without it the whole match is redacted.
+	valueGroup = "value"
+)
+
+var (
+	emailPattern  = regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}\b`)
+	jwtPattern    = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	bearerPattern = regexp.MustCompile(`(?i)\bbearer\s+(?P<value>[A-Za-z0-9._~+/-]+=*)`)
+	cardPattern   = regexp.MustCompile(`\b[2-6](?:[ -]?\d){11,17}[ -]?\d\b`)
+	ipv4Pattern   = regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\.){3}(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\b`)
+	// ipv6Candidate finds runs that may be IPv6 addresses; ipv6Spans keeps
+	// those that parse
+	ipv6Candidate = regexp.MustCompile(`[0-9A-Fa-f]*:[0-9A-Fa-f:.]*[0-9A-Fa-f:]`)
+)
+
+// detector finds the spans of one category of values in text.
+type detector struct {
+	category    string
+	placeholder string
+	find        func(text string) [][2]int
+}
+
+// builtin lists the detectors in priority order: a value one detector
+// redacts is not redacted again by a later one.
+var builtin = []detector{
+	{CategoryJWT, "JWT", regexpSpans(jwtPattern)},
+	{CategoryBearer, "TOKEN", regexpSpans(bearerPattern)},
+	{CategoryEmail, "EMAIL", regexpSpans(emailPattern)},
+	{CategoryIP, "IP", ipv6Spans},
+	{CategoryIP, "IP", regexpSpans(ipv4Pattern)},
+	{CategoryCard, "CARD", cardSpans},
+}
+
+// Redactor redacts the built-in categories and any user-defined patterns.
+type Redactor struct {
+	detectors []detector
+}
+
+// New returns a Redactor that also redacts matches of patterns, which are
+// RE2 regular expressions. When a pattern has a group named "value", only
+// that group is redacted, so "password=(?P<value>\S+)" keeps the key.
+func New(patterns []string) (*Redactor, error) {
+	if len(patterns) > MaxPatterns {
+		return nil, fmt.Errorf("at most %d patterns are allowed", MaxPatterns)
+	}
+	var custom []detector
+	for _, pattern := range patterns {
+		if len(pattern) > maxPatternLength {
+			return nil, fmt.Errorf("patterns must be at most %d characters", maxPatternLength)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if re.MatchString("") {
+			return nil, fmt.Errorf("%q matches empty text", pattern)
+		}
+		custom = append(custom, detector{CategoryCustom, "REDACTED", regexpSpans(re)})
+	}
+	// What the user asked for goes first
+	return &Redactor{detectors: append(custom, builtin...)}, nil
+}
+
+// Redact returns text with every detected value replaced by its placeholder,
+// and how many values of each category were replaced.
+func (r *Redactor) Redact(text string) (string, map[string]int) {
+	type match struct {
+		start, end int
+		detector   *detector
+	}
+	// matches stays sorted and free of overlaps. Each detector's spans are
+	// merged into it in one sweep, dropping those that overlap a match of an
+	// earlier detector, so a paste full of values costs linear time per
+	// detector rather than comparing every pair.
+	var matches []match
+	for i := range r.detectors {
+		d := &r.detectors[i]
+		spans := d.find(text)
+		if len(spans) == 0 {
+			continue
+		}
+		slices.SortFunc(spans, func(a, b [2]int) int { return a[0] - b[0] })
+		merged := make([]match, 0, len(matches)+len(spans))
+		next := 0
+		for _, span := range spans {
+			for next < len(matches) && matches[next].start < span[0] {
+				merged = append(merged, matches[next])
+				next++
+			}
+			if len(merged) > 0 && merged[len(merged)-1].end > span[0] {
+				continue
+			}
+			if next < len(matches) && matches[next].start < span[1] {
+				continue
+			}
+			merged = append(merged, match{span[0], span[1], d})
+		}
+		matches = append(merged, matches[next:]...)
+	}
+	if len(matches) == 0 {
+		return text, nil
+	}
+
+	// Placeholders are numbered per label in order of first appearance
+	numbers := map[string]map[string]int{}
+	counts := map[string]int{}
+	var b strings.Builder
+	b.Grow(len(text))
+	last := 0
+	for _, m := range matches {
+		label := m.detector.placeholder
+		value := text[m.start:m.end]
+		if numbers[label] == nil {
+			numbers[label] = map[string]int{}
+		}
+		n, ok := numbers[label][value]
+		if !ok {
+			n = len(numbers[label]) + 1
+			numbers[label][value] = n
+		}
+		counts[m.detector.category]++
+		b.WriteString(text[last:m.start])
+		b.WriteString("<" + label + "_" + strconv.Itoa(n) + ">")
+		last = m.end
+	}
+	b.WriteString(text[last:])
+	return b.String(), counts
+}
+
+// regexpSpans returns a finder for re's matches, or its "value" group when
+// it has one.
+func regexpSpans(re *regexp.Regexp) func(string) [][2]int {
+	group := re.SubexpIndex(valueGroup)
+	return func(text string) [][2]int {
+		var spans [][2]int
+		for _, m := range re.FindAllStringSubmatchIndex(text, -1) {
+			start, end := m[0], m[1]
+			if group > 0 {
+				if m[2*group] < 0 {
+					continue
+				}
+				start, end = m[2*group], m[2*group+1]
+			}
+			if start < end {
+				spans = append(spans, [2]int{start, end})
+			}
+		}
+		return spans
+	}
+}
+
+// ipv6Spans finds IPv6 addresses. Candidates next to letters, digits or
+// underscores, as in "std::vector", and times such as 12:30:00 are skipped
+// because they do not stand alone or do not parse.
+func ipv6Spans(text string) [][2]int {
+	var spans [][2]int
+	for _, m := range ipv6Candidate.FindAllStringIndex(text, -1) {
+		start, end := m[0], m[1]
+		if start > 0 && isWordByte(text[start-1]) || end < len(text) && isWordByte(text[end]) {
+			continue
+		}
+		candidate := text[start:end]
+		if strings.Count(candidate, ":") < 2 {
+			continue
+		}
+		if addr, err := netip.ParseAddr(candidate); err != nil || !addr.Is6() {
+			continue
+		}
+		spans = append(spans, [2]int{start, end})
+	}
+	return spans
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// cardSpans finds 13 to 19 digit numbers, optionally grouped with spaces or
+// dashes, that start like the numbers of the major card networks and pass
+// the Luhn check card numbers carry. The first digit keeps millisecond
+// timestamps out.
+func cardSpans(text string) [][2]int {
+	var spans [][2]int
+	for _, m := range cardPattern.FindAllStringIndex(text, -1) {
+		if luhn(text[m[0]:m[1]]) {
+			spans = append(spans, [2]int{m[0], m[1]})
+		}
+	}
+	return spans
+}
+
+// luhn reports whether the digits in number have a valid Luhn checksum.
+func luhn(number string) bool {
+	sum, digits := 0, 0
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if digits%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+	}
+	return digits >= 13 && digits <= 19 && sum%10 == 0
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"pastebin/internal/metrics"
 	"pastebin/internal/models"
+	"pastebin/internal/tracing"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
@@ -12,6 +15,14 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// incrementHourlyViewsQuery bumps the current hour's bucket, which backs the windowed view counts.
+const incrementHourlyViewsQuery = `INSERT INTO pastes_analytics_hourly (paste_id, bucket, views) VALUES ($1, date_trunc('hour', NOW()), 1)
+ ON CONFLICT (paste_id, bucket)
+ DO UPDATE SET views = pastes_analytics_hourly.views + 1`
+
+// analyticsQuery selects models.Analytics rows, taking the slug from the paste.
+const analyticsQuery = `SELECT a.id, a.paste_id, p.slug, a.views, a.created_at, a.updated_at FROM pastes_analytics a JOIN pastes p ON p.id = a.paste_id`
+
 type AnalyticsRepository struct {
 	db *pgxpool.Pool
 }
@@ -22,14 +33,16 @@ func NewAnalyticsRepository(db *pgxpool.Pool) *AnalyticsRepository {
 	}
 }
 
-func (a *AnalyticsRepository) CreateAnalytics(ctx context.Context, pasteID uuid.UUID, url string) error {
-	query := `INSERT INTO pastes_analytics (paste_id, url) VALUES ($1, $2)`
+func (a *AnalyticsRepository) CreateAnalytics(ctx context.Context, pasteID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "AnalyticsRepository.CreateAnalytics")
+	defer span.End()
+	query := `INSERT INTO pastes_analytics (paste_id) VALUES ($1)`
 	tx, err := a.db.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback(ctx)
-	_, err = tx.Exec(ctx, query, pasteID, url)
+	_, err = tx.Exec(ctx, query, pasteID)
 	if err != nil {
 		return fmt.Errorf("failed to create analytics: %w", err)
 	}
@@ -40,7 +53,9 @@ func (a *AnalyticsRepository) CreateAnalytics(ctx context.Context, pasteID uuid.
 }
 
 func (a *AnalyticsRepository) GetAnalyticsByPasteID(ctx context.Context, pasteID uuid.UUID) (*models.Analytics, error) {
-	query := `SELECT * FROM pastes_analytics WHERE paste_id = $1`
+	ctx, span := tracing.Start(ctx, "AnalyticsRepository.GetAnalyticsByPasteID")
+	defer span.End()
+	query := analyticsQuery + ` WHERE a.paste_id = $1`
 	row, err := a.db.Query(ctx, query, pasteID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get analytics by paste_id: %w", err)
@@ -57,6 +72,8 @@ func (a *AnalyticsRepository) GetAnalyticsByPasteID(ctx context.Context, pasteID
 }
 
 func (a *AnalyticsRepository) IncrementViews(ctx context.Context, pasteID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "AnalyticsRepository.IncrementViews")
+	defer span.End()
 	// Build the update query using squirrel
 	query := sq.Update("pastes_analytics").
 		Set("views", sq.Expr("views+1")).
@@ -78,17 +95,23 @@ func (a *AnalyticsRepository) IncrementViews(ctx context.Context, pasteID uuid.U
 	if err != nil {
 		return fmt.Errorf("failed to increment views: %w", err)
 	}
+	if _, err := tx.Exec(ctx, incrementHourlyViewsQuery, pasteID); err != nil {
+		return fmt.Errorf("failed to increment hourly views: %w", err)
+	}
 	if err := tx.Commit(ctx); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	metrics.ViewCounted()
 	return nil
 }
 
-func (a *AnalyticsRepository) GetAnalyticsByURL(ctx context.Context, url string) (*models.Analytics, error) {
-	query := `SELECT * FROM pastes_analytics WHERE url = $1`
-	row, err := a.db.Query(ctx, query, url)
+func (a *AnalyticsRepository) GetAnalyticsBySlug(ctx context.Context, slug string) (*models.Analytics, error) {
+	ctx, span := tracing.Start(ctx, "AnalyticsRepository.GetAnalyticsBySlug")
+	defer span.End()
+	query := analyticsQuery + ` WHERE p.slug = $1`
+	row, err := a.db.Query(ctx, query, slug)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get analytics by url: %w", err)
+		return nil, fmt.Errorf("failed to get analytics by slug: %w", err)
 	}
 	defer row.Close()
 	analytics, err := pgx.CollectOneRow(row, pgx.RowToStructByName[models.Analytics])
@@ -102,18 +125,20 @@ func (a *AnalyticsRepository) GetAnalyticsByURL(ctx context.Context, url string)
 }
 
 func (a *AnalyticsRepository) GetAllAnalytics(ctx context.Context, order string, limit int, offset int) ([]models.Analytics, error) {
+	ctx, span := tracing.Start(ctx, "AnalyticsRepository.GetAllAnalytics")
+	defer span.End()
 	// ORDER BY cannot use parameters, so we need to validate and use string interpolation carefully
 	// Only allow safe column names
 	allowedOrders := map[string]string{
-		"created_at": "created_at",
-		"updated_at": "updated_at",
-		"views":      "views",
+		"created_at": "a.created_at",
+		"updated_at": "a.updated_at",
+		"views":      "a.views",
 	}
-	orderBy := "created_at DESC" // default
+	orderBy := "a.created_at DESC" // default
 	if orderCol, ok := allowedOrders[order]; ok {
 		orderBy = orderCol + " DESC"
 	}
-	query := fmt.Sprintf(`SELECT * FROM pastes_analytics ORDER BY %s LIMIT $1 OFFSET $2`, orderBy)
+	query := fmt.Sprintf(analyticsQuery+` ORDER BY %s LIMIT $1 OFFSET $2`, orderBy)
 	rows, err := a.db.Query(ctx, query, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all analytics: %w", err)
@@ -127,6 +152,8 @@ func (a *AnalyticsRepository) GetAllAnalytics(ctx context.Context, order string,
 }
 
 func (a *AnalyticsRepository) GetAllAnalyticsByUser(ctx context.Context, userID uuid.UUID, order string, limit, offset int) ([]models.Analytics, error) {
+	ctx, span := tracing.Start(ctx, "AnalyticsRepository.GetAllAnalyticsByUser")
+	defer span.End()
 	// ORDER BY cannot use parameters, so we need to validate and use string interpolation carefully
 	// Only allow safe column names
 	allowedOrders := map[string]string{
@@ -138,7 +165,7 @@ func (a *AnalyticsRepository) GetAllAnalyticsByUser(ctx context.Context, userID
 	if orderCol, ok := allowedOrders[order]; ok {
 		orderBy = orderCol + " DESC"
 	}
-	query := fmt.Sprintf(`SELECT a.* FROM pastes_analytics a JOIN pastes p ON a.paste_id = p.id WHERE p.user_id = $1 ORDER BY %s LIMIT $2 OFFSET $3`, orderBy)
+	query := fmt.Sprintf(analyticsQuery+` WHERE p.user_id = $1 ORDER BY %s LIMIT $2 OFFSET $3`, orderBy)
 	rows, err := a.db.Query(ctx, query, userID, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all analytics by user: %w", err)
@@ -152,7 +179,9 @@ func (a *AnalyticsRepository) GetAllAnalyticsByUser(ctx context.Context, userID
 }
 
 func (a *AnalyticsRepository) GetAnalyticsByID(ctx context.Context, id uuid.UUID) (*models.Analytics, error) {
-	query := `SELECT * FROM pastes_analytics WHERE id = $1`
+	ctx, span := tracing.Start(ctx, "AnalyticsRepository.GetAnalyticsByID")
+	defer span.End()
+	query := analyticsQuery + ` WHERE a.id = $1`
 	rows, err := a.db.Query(ctx, query, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get analytics for id: %w", err)
@@ -164,3 +193,77 @@ func (a *AnalyticsRepository) GetAnalyticsByID(ctx context.Context, id uuid.UUID
 	}
 	return &analytic, nil
 }
+
+// GetSummaryByUser builds the dashboard rollup for a user's non-expired pastes in a single round trip.
+// Windowed view counts come from the hourly buckets; totals come from pastes_analytics.
+func (a *AnalyticsRepository) GetSummaryByUser(ctx context.Context, userID uuid.UUID) (*models.AnalyticsSummary, error) {
+	ctx, span := tracing.Start(ctx, "AnalyticsRepository.GetSummaryByUser")
+	defer span.End()
+	query := `WITH user_pastes AS (
+		SELECT p.id, p.title, p.slug, p.language, p.is_private, p.expires_at, COALESCE(a.views, 0) AS views
+		FROM pastes p
+		LEFT JOIN pastes_analytics a ON p.id = a.paste_id
+		WHERE p.user_id = $1 AND (p.expires_at IS NULL OR p.expires_at > NOW())
+	), recent AS (
+		SELECT
+			COALESCE(SUM(h.views) FILTER (WHERE h.bucket >= NOW() - INTERVAL '7 days'), 0)::bigint AS views_7d,
+			COALESCE(SUM(h.views), 0)::bigint AS views_30d
+		FROM pastes_analytics_hourly h
+		JOIN user_pastes up ON up.id = h.paste_id
+		WHERE h.bucket >= NOW() - INTERVAL '30 days'
+	)
+	SELECT
+		(SELECT COUNT(*) FROM user_pastes) AS total_pastes,
+		(SELECT COALESCE(SUM(views), 0)::bigint FROM user_pastes) AS total_views,
+		recent.views_7d AS views_last_7_days,
+		recent.views_30d AS views_last_30_days,
+		(SELECT COUNT(*) FROM user_pastes WHERE expires_at <= NOW() + INTERVAL '24 hours') AS expiring_next_24h,
+		(SELECT COUNT(*) FROM user_pastes WHERE is_private) AS private_pastes,
+		(SELECT COUNT(*) FROM user_pastes WHERE NOT is_private) AS public_pastes,
+		COALESCE((
+			SELECT json_agg(t) FROM (
+				SELECT id AS paste_id, title, slug, language, views
+				FROM user_pastes
+				ORDER BY views DESC, id
+				LIMIT 10
+			) t
+		), '[]'::json) AS top_pastes,
+		COALESCE((
+			SELECT json_agg(l) FROM (
+				SELECT language, COUNT(*) AS pastes, COALESCE(SUM(views), 0)::bigint AS views
+				FROM user_pastes
+				GROUP BY language
+				ORDER BY COUNT(*) DESC, language
+			) l
+		), '[]'::json) AS languages
+	FROM recent`
+	rows, err := a.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get analytics summary: %w", err)
+	}
+	defer rows.Close()
+	summary, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[models.AnalyticsSummary])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect analytics summary: %w", err)
+	}
+	return &summary, nil
+}
+
+// PruneHourlyViews deletes up to limit hourly view buckets older than before
+// and returns how many it removed.
+func (a *AnalyticsRepository) PruneHourlyViews(ctx context.Context, before time.Time, limit int) (int, error) {
+	ctx, span := tracing.Start(ctx, "AnalyticsRepository.PruneHourlyViews")
+	defer span.End()
+	query := `DELETE FROM pastes_analytics_hourly
+		WHERE (paste_id, bucket) IN (
+			SELECT paste_id, bucket FROM pastes_analytics_hourly
+			WHERE bucket < $1
+			ORDER BY bucket
+			LIMIT $2
+		)`
+	tag, err := a.db.Exec(ctx, query, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune hourly views: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"pastebin/internal/models"
+	"pastebin/internal/tracing"
 	"pastebin/pkg/utils"
 
 	"github.com/google/uuid"
@@ -21,6 +22,8 @@ func NewAuthRepository(db *pgxpool.Pool) *AuthRepository {
 }
 
 func (a *AuthRepository) Register(ctx context.Context, registerInput *models.RegisterInput) error {
+	ctx, span := tracing.Start(ctx, "AuthRepository.Register")
+	defer span.End()
 	hashedPassword, err := utils.HashPassword(registerInput.Password)
 	if err != nil {
 		return fmt.Errorf("failed to hash password: %w", err)
@@ -0,0 +1,258 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"pastebin/internal/blobstore"
+	"pastebin/internal/codec"
+	"pastebin/internal/envelope"
+	"pastebin/internal/tracing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// BlobRepository keeps reference counts for content-addressed paste content
+// in the blobs table and the bytes themselves in a blobstore.Store.
+//
+// Writing content is split in two so that the slow part stays outside the
+// paste transaction: Prepare records the blob with no references and uploads
+// it, then acquire takes a reference inside the transaction. If the paste is
+// never written the blob stays unreferenced and the collector removes it.
+//
+// With a keyring, blobs are encrypted under their own data key, kept wrapped
+// in the blobs row, and named by a keyed hash instead of the plain SHA-256.
+type BlobRepository struct {
+	db         *pgxpool.Pool
+	store      blobstore.Store
+	compressor *codec.Compressor
+	keyring    *envelope.Keyring
+}
+
+func NewBlobRepository(db *pgxpool.Pool, store blobstore.Store, compressor *codec.Compressor, keyring *envelope.Keyring) *BlobRepository {
+	return &BlobRepository{
+		db:         db,
+		store:      store,
+		compressor: compressor,
+		keyring:    keyring,
+	}
+}
+
+// name returns the hash content is stored under.
+func (b *BlobRepository) name(content []byte) string {
+	if b.keyring != nil {
+		return b.keyring.BlobName(content)
+	}
+	return blobstore.Hash(content)
+}
+
+// Prepare makes sure content is stored and returns its hash. Content that is
+// already referenced is not uploaded again.
+//
+// Writers of the same hash take turns under an advisory lock held until the
+// bytes are stored, so the codec and data key recorded for a blob always
+// belong to the bytes in the store.
+func (b *BlobRepository) Prepare(ctx context.Context, content []byte) (string, error) {
+	ctx, span := tracing.Start(ctx, "BlobRepository.Prepare")
+	defer span.End()
+	hash := b.name(content)
+
+	tx, err := b.db.Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('blob:' || $1))`, hash); err != nil {
+		return "", fmt.Errorf("failed to lock blob: %w", err)
+	}
+
+	// Touching an unreferenced blob restarts its grace period, and the row
+	// lock keeps the collector away from it until the bytes are stored.
+	var refs int
+	err = tx.QueryRow(ctx, `UPDATE blobs SET unreferenced_at = CASE WHEN refs = 0 THEN NOW() END WHERE hash = $1 RETURNING refs`, hash).Scan(&refs)
+	if err == nil && refs > 0 {
+		if err := tx.Commit(ctx); err != nil {
+			return "", fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return hash, nil
+	}
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("failed to look up blob: %w", err)
+	}
+
+	contentCodec, data, err := b.compressor.Encode(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to compress blob: %w", err)
+	}
+	var key []byte
+	var keyID *string
+	if b.keyring != nil {
+		sealed, err := b.keyring.Seal(data)
+		if err != nil {
+			return "", fmt.Errorf("failed to encrypt blob: %w", err)
+		}
+		data, key, keyID = sealed.Data, sealed.Key, &sealed.KeyID
+	}
+	// An unreferenced blob is rewritten, since its bytes may be missing or
+	// half written; the lock makes sure no one else writes them meanwhile.
+	query := `INSERT INTO blobs (hash, size, codec, key, key_id, refs, unreferenced_at) VALUES ($1, $2, $3, $4, $5, 0, NOW())
+		ON CONFLICT (hash) DO UPDATE SET codec = EXCLUDED.codec, key = EXCLUDED.key, key_id = EXCLUDED.key_id, unreferenced_at = NOW()`
+	if _, err := tx.Exec(ctx, query, hash, len(content), contentCodec, key, keyID); err != nil {
+		return "", fmt.Errorf("failed to record blob: %w", err)
+	}
+	if err := b.store.Put(ctx, hash, data); err != nil {
+		return "", err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return hash, nil
+}
+
+// acquire takes a reference to a prepared blob within tx.
+func (b *BlobRepository) acquire(ctx context.Context, tx pgx.Tx, hash string) error {
+	cmdTag, err := tx.Exec(ctx, `UPDATE blobs SET refs = refs + 1, unreferenced_at = NULL WHERE hash = $1`, hash)
+	if err != nil {
+		return fmt.Errorf("failed to reference blob: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return fmt.Errorf("blob %s not found", hash)
+	}
+	return nil
+}
+
+// release drops a reference within tx. The last reference starts the grace
+// period after which the collector deletes the blob.
+func (b *BlobRepository) release(ctx context.Context, tx pgx.Tx, hash string) error {
+	query := `UPDATE blobs SET refs = GREATEST(refs - 1, 0), unreferenced_at = CASE WHEN refs <= 1 THEN NOW() ELSE unreferenced_at END WHERE hash = $1`
+	if _, err := tx.Exec(ctx, query, hash); err != nil {
+		return fmt.Errorf("failed to release blob: %w", err)
+	}
+	return nil
+}
+
+// Load returns the stored codec and bytes of a blob.
+func (b *BlobRepository) Load(ctx context.Context, hash string) (codec.Codec, []byte, error) {
+	ctx, span := tracing.Start(ctx, "BlobRepository.Load")
+	defer span.End()
+	var contentCodec codec.Codec
+	var key []byte
+	var keyID *string
+	if err := b.db.QueryRow(ctx, `SELECT codec, key, key_id FROM blobs WHERE hash = $1`, hash).Scan(&contentCodec, &key, &keyID); err != nil {
+		return "", nil, fmt.Errorf("failed to look up blob %s: %w", hash, err)
+	}
+	data, err := b.store.Get(ctx, hash)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to load blob %s: %w", hash, err)
+	}
+	if key != nil {
+		if data, err = unwrapContent(ctx, b.keyring, data, key, keyID); err != nil {
+			return "", nil, fmt.Errorf("failed to decrypt blob %s: %w", hash, err)
+		}
+	}
+	return contentCodec, data, nil
+}
+
+// RewrapBatch re-wraps the data keys of up to limit blobs with a hash
+// greater than after under the current master key, leaving the stored bytes
+// alone. It returns the last hash it looked at, or "" once there is nothing
+// left, and how many keys it re-wrapped.
+func (b *BlobRepository) RewrapBatch(ctx context.Context, after string, limit int) (string, int, error) {
+	ctx, span := tracing.Start(ctx, "BlobRepository.RewrapBatch")
+	defer span.End()
+	if b.keyring == nil {
+		return "", 0, errNoKeyring
+	}
+	tx, err := b.db.Begin(ctx)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `SELECT hash, key, key_id FROM blobs
+		WHERE hash > $1 AND key_id <> $3
+		ORDER BY hash
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED`
+	rows, err := tx.Query(ctx, query, after, limit, b.keyring.CurrentID())
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to query blobs: %w", err)
+	}
+	type keyRow struct {
+		Hash  string `db:"hash"`
+		Key   []byte `db:"key"`
+		KeyID string `db:"key_id"`
+	}
+	batch, err := pgx.CollectRows(rows, pgx.RowToStructByName[keyRow])
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to collect blobs: %w", err)
+	}
+	if len(batch) == 0 {
+		return "", 0, nil
+	}
+	for _, row := range batch {
+		key, keyID, err := b.keyring.Rewrap(row.Key, row.KeyID)
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to rewrap blob %s: %w", row.Hash, err)
+		}
+		if _, err := tx.Exec(ctx, `UPDATE blobs SET key = $2, key_id = $3 WHERE hash = $1`, row.Hash, key, keyID); err != nil {
+			return "", 0, fmt.Errorf("failed to update blob %s: %w", row.Hash, err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return "", 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return batch[len(batch)-1].Hash, len(batch), nil
+}
+
+// CollectGarbage deletes up to limit blobs that have had no references for
+// longer than grace and returns how many it removed. Rows locked by a
+// concurrent writer are skipped until the next run.
+func (b *BlobRepository) CollectGarbage(ctx context.Context, grace time.Duration, limit int) (int, error) {
+	ctx, span := tracing.Start(ctx, "BlobRepository.CollectGarbage")
+	defer span.End()
+	tx, err := b.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `SELECT hash FROM blobs b
+		WHERE refs = 0
+			AND unreferenced_at < NOW() - make_interval(secs => $1)
+			AND NOT EXISTS (SELECT 1 FROM pastes p WHERE p.content_hash = b.hash)
+		ORDER BY unreferenced_at
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED`
+	rows, err := tx.Query(ctx, query, grace.Seconds(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find unreferenced blobs: %w", err)
+	}
+	hashes, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return 0, fmt.Errorf("failed to collect unreferenced blobs: %w", err)
+	}
+
+	// Bytes go first: a blob row without bytes is repaired by the next
+	// Prepare, bytes without a row would never be found again.
+	deleted := make([]string, 0, len(hashes))
+	var errs []error
+	for _, hash := range hashes {
+		if err := b.store.Delete(ctx, hash); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		deleted = append(deleted, hash)
+	}
+	if len(deleted) > 0 {
+		if _, err := tx.Exec(ctx, `DELETE FROM blobs WHERE hash = ANY($1)`, deleted); err != nil {
+			return 0, fmt.Errorf("failed to delete blobs: %w", err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return len(deleted), errors.Join(errs...)
+}
@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"pastebin/db"
+	"pastebin/internal/blobstore"
+	"pastebin/internal/codec"
+	"pastebin/internal/envelope"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testPool returns a pool on a fresh schema with every migration applied, in
+// the database named by TEST_DATABASE_URL. Tests using it are skipped when
+// the variable is not set.
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	conn, err := pgx.Connect(ctx, url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer conn.Close(ctx)
+	if _, err := conn.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		conn, err := pgx.Connect(ctx, url)
+		if err != nil {
+			t.Errorf("connect: %v", err)
+			return
+		}
+		defer conn.Close(ctx)
+		if _, err := conn.Exec(ctx, "DROP SCHEMA "+schema+" CASCADE"); err != nil {
+			t.Errorf("drop schema: %v", err)
+		}
+	})
+
+	cfg, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("parse TEST_DATABASE_URL: %v", err)
+	}
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema + ",public"
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatalf("open pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	// Migration files sort by version; only the goose Up part is applied
+	entries, err := fs.ReadDir(db.Migrations, "migrations")
+	if err != nil {
+		t.Fatalf("read migrations: %v", err)
+	}
+	for _, entry := range entries {
+		migration, err := fs.ReadFile(db.Migrations, "migrations/"+entry.Name())
+		if err != nil {
+			t.Fatalf("read %s: %v", entry.Name(), err)
+		}
+		_, up, _ := strings.Cut(string(migration), "-- +goose Up")
+		up, _, _ = strings.Cut(up, "-- +goose Down")
+		if _, err := pool.Exec(ctx, up); err != nil {
+			t.Fatalf("apply %s: %v", entry.Name(), err)
+		}
+	}
+	return pool
+}
+
+// slowStore delays Put so concurrent writers overlap while uploading.
+type slowStore struct {
+	blobstore.Store
+	delay time.Duration
+}
+
+func (s slowStore) Put(ctx context.Context, hash string, data []byte) error {
+	time.Sleep(s.delay)
+	return s.Store.Put(ctx, hash, data)
+}
+
+func TestBlobRepositoryPrepareConcurrent(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	store := slowStore{Store: blobstore.NewPostgresStore(pool), delay: 20 * time.Millisecond}
+	keyring, err := envelope.NewKeyring(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	// Writers seal under their own data keys and, like replicas mid-way
+	// through a config change, disagree on the codec
+	content := bytes.Repeat([]byte("the same paste, uploaded at once\n"), 512)
+	writers := make([]*BlobRepository, 8)
+	for i := range writers {
+		contentCodec := codec.Gzip
+		if i%2 == 1 {
+			contentCodec = codec.Zstd
+		}
+		writers[i] = NewBlobRepository(pool, store, codec.NewCompressor(contentCodec, 0), keyring)
+	}
+
+	hashes := make([]string, len(writers))
+	errs := make([]error, len(writers))
+	var wg sync.WaitGroup
+	for i, writer := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hashes[i], errs[i] = writer.Prepare(ctx, content)
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("writer %d: Prepare() error = %v", i, err)
+		}
+		if hashes[i] != hashes[0] {
+			t.Fatalf("writer %d: Prepare() = %s, want %s", i, hashes[i], hashes[0])
+		}
+	}
+
+	contentCodec, data, err := writers[0].Load(ctx, hashes[0])
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	got, err := codec.Decode(contentCodec, data)
+	if err != nil {
+		t.Fatalf("Decode(%s) error = %v", contentCodec, err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatal("Load() returned different content than was prepared")
+	}
+}
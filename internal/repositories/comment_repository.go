@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"pastebin/internal/models"
+	"pastebin/internal/tracing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrCommentNotFound is returned when no live comment has the requested ID.
+var ErrCommentNotFound = errors.New("comment not found")
+
+// commentColumns selects a comment of c with its author from u. Deleted
+// comments come without a body.
+const commentColumns = `c.id, c.paste_id, c.user_id, u.name AS author, c.parent_id,
+	CASE WHEN c.deleted_at IS NULL THEN c.body ELSE '' END AS body,
+	c.line_start, c.line_end, c.deleted_at IS NOT NULL AS deleted, c.created_at, c.updated_at`
+
+type CommentRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCommentRepository(db *pgxpool.Pool) *CommentRepository {
+	return &CommentRepository{
+		db: db,
+	}
+}
+
+// CreateComment adds a comment by userID and returns it.
+func (c *CommentRepository) CreateComment(ctx context.Context, pasteID, userID uuid.UUID, input *models.CommentInput) (*models.Comment, error) {
+	ctx, span := tracing.Start(ctx, "CommentRepository.CreateComment")
+	defer span.End()
+	var commentID uuid.UUID
+	query := `INSERT INTO paste_comments (paste_id, user_id, parent_id, body, line_start, line_end) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	if err := c.db.QueryRow(ctx, query, pasteID, userID, input.ParentID, input.Body, input.LineStart, input.LineEnd).Scan(&commentID); err != nil {
+		return nil, fmt.Errorf("failed to insert comment: %w", err)
+	}
+	return c.GetComment(ctx, commentID)
+}
+
+// GetComment returns a live comment, or ErrCommentNotFound.
+func (c *CommentRepository) GetComment(ctx context.Context, commentID uuid.UUID) (*models.Comment, error) {
+	ctx, span := tracing.Start(ctx, "CommentRepository.GetComment")
+	defer span.End()
+	query := `SELECT ` + commentColumns + ` FROM paste_comments c JOIN users u ON u.id = c.user_id WHERE c.id = $1 AND c.deleted_at IS NULL`
+	rows, err := c.db.Query(ctx, query, commentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query comment: %w", err)
+	}
+	defer rows.Close()
+	comment, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[models.Comment])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCommentNotFound
+		}
+		return nil, fmt.Errorf("failed to collect comment: %w", err)
+	}
+	return &comment, nil
+}
+
+// ListComments returns every comment on a paste, deleted ones included,
+// oldest first.
+func (c *CommentRepository) ListComments(ctx context.Context, pasteID uuid.UUID) ([]models.Comment, error) {
+	ctx, span := tracing.Start(ctx, "CommentRepository.ListComments")
+	defer span.End()
+	query := `SELECT ` + commentColumns + ` FROM paste_comments c JOIN users u ON u.id = c.user_id WHERE c.paste_id = $1 ORDER BY c.created_at, c.id`
+	rows, err := c.db.Query(ctx, query, pasteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query comments: %w", err)
+	}
+	defer rows.Close()
+	comments, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.Comment])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect comments: %w", err)
+	}
+	return comments, nil
+}
+
+// UpdateComment replaces the body of a live comment.
+func (c *CommentRepository) UpdateComment(ctx context.Context, commentID uuid.UUID, body string) (*models.Comment, error) {
+	ctx, span := tracing.Start(ctx, "CommentRepository.UpdateComment")
+	defer span.End()
+	cmdTag, err := c.db.Exec(ctx, `UPDATE paste_comments SET body = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`, commentID, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update comment: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return nil, ErrCommentNotFound
+	}
+	return c.GetComment(ctx, commentID)
+}
+
+// DeleteComment drops the body of a live comment and marks it deleted.
+func (c *CommentRepository) DeleteComment(ctx context.Context, commentID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "CommentRepository.DeleteComment")
+	defer span.End()
+	cmdTag, err := c.db.Exec(ctx, `UPDATE paste_comments SET body = '', deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`, commentID)
+	if err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrCommentNotFound
+	}
+	return nil
+}
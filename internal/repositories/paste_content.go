@@ -0,0 +1,400 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"pastebin/internal/codec"
+	"pastebin/internal/metrics"
+	"pastebin/internal/models"
+	"pastebin/internal/pastecrypt"
+	"pastebin/internal/tracing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// pasteRow is a pastes row as stored. Content is empty when the paste is
+// compressed (its bytes are in Data), encrypted (Data holds the possibly
+// compressed content sealed under the paste password) or kept as a blob (Hash
+// is set and load fetches Codec and Data from the blob store). With Key set,
+// Data is additionally encrypted under a data key wrapped by the master key
+// KeyID.
+type pasteRow struct {
+	models.PasteOutput
+	Codec  string  `db:"content_codec"`
+	Data   []byte  `db:"content_data"`
+	Hash   *string `db:"content_hash"`
+	Sealed bool    `db:"content_encrypted"`
+	Key    []byte  `db:"content_key"`
+	KeyID  *string `db:"content_key_id"`
+}
+
+// load resolves the content of blob-backed and encrypted rows into Codec and
+// Data, or Content when it is not compressed. It reports false, leaving the
+// content unresolved, when the content is encrypted under the paste password
+// and no password was given.
+func (p *PasteRepository) load(ctx context.Context, r *pasteRow, password string) (bool, error) {
+	if r.Hash == nil && r.Key == nil && !r.Sealed {
+		return true, nil
+	}
+	if r.Sealed && password == "" {
+		return false, nil
+	}
+	switch {
+	case r.Hash != nil:
+		contentCodec, data, err := p.blobs.Load(ctx, *r.Hash)
+		if err != nil {
+			return false, err
+		}
+		r.Codec, r.Data = string(contentCodec), data
+	case r.Key != nil:
+		data, err := unwrapContent(ctx, p.keyring, r.Data, r.Key, r.KeyID)
+		if err != nil {
+			return false, fmt.Errorf("failed to decrypt paste content: %w", err)
+		}
+		r.Data = data
+	}
+	if r.Sealed {
+		data, err := openContent(ctx, password, r.Data)
+		if err != nil {
+			return false, err
+		}
+		r.Data = data
+	}
+	if codec.Codec(r.Codec) == codec.Identity {
+		r.Content, r.Data = string(r.Data), nil
+	}
+	return true, nil
+}
+
+// decode returns the paste with its plain-text content, or with
+// ContentLocked set when the content is encrypted and password is empty.
+func (p *PasteRepository) decode(ctx context.Context, r *pasteRow, password string) (*models.PasteOutput, error) {
+	ok, err := p.load(ctx, r, password)
+	if err != nil {
+		return nil, err
+	}
+	paste := r.PasteOutput
+	if !ok {
+		paste.Content, paste.ContentLocked = "", true
+		return &paste, nil
+	}
+	if c := codec.Codec(r.Codec); c != codec.Identity {
+		content, err := codec.Decode(c, r.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode paste content: %w", err)
+		}
+		paste.Content = string(content)
+	}
+	return &paste, nil
+}
+
+// stored returns the paste content as stored, without decompressing it.
+// Encrypted content is decrypted first, so it needs the paste password.
+func (p *PasteRepository) stored(ctx context.Context, r *pasteRow, password string) (*models.StoredContent, error) {
+	ok, err := p.load(ctx, r, password)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("password required")
+	}
+	if codec.Codec(r.Codec) == codec.Identity {
+		return &models.StoredContent{Codec: string(codec.Identity), Data: []byte(r.Content), Encryption: r.Encryption, Language: r.Language}, nil
+	}
+	return &models.StoredContent{Codec: r.Codec, Data: r.Data, Encryption: r.Encryption, Language: r.Language}, nil
+}
+
+// openContent decrypts sealed content. The key derivation is deliberately
+// slow, so it gets its own span like the bcrypt check.
+func openContent(ctx context.Context, password string, sealed []byte) ([]byte, error) {
+	_, span := tracing.Start(ctx, "pastecrypt.Open")
+	defer span.End()
+	data, err := pastecrypt.Open(password, sealed)
+	if err != nil {
+		if errors.Is(err, pastecrypt.ErrDecrypt) {
+			return nil, fmt.Errorf("invalid password")
+		}
+		return nil, fmt.Errorf("failed to decrypt paste content: %w", err)
+	}
+	return data, nil
+}
+
+// storedContent holds the values of the content columns for a paste.
+type storedContent struct {
+	content   string
+	codec     codec.Codec
+	data      []byte
+	hash      *string
+	encrypted bool
+	key       []byte
+	keyID     *string
+	size      int
+}
+
+func (s *storedContent) columns() map[string]any {
+	return map[string]any{
+		"content":           s.content,
+		"content_codec":     s.codec,
+		"content_data":      s.data,
+		"content_hash":      s.hash,
+		"content_encrypted": s.encrypted,
+		"content_key":       s.key,
+		"content_key_id":    s.keyID,
+		"content_size":      s.size,
+	}
+}
+
+// encodeContent prepares content for storage. Without a password it is
+// stored as a blob when a blob store is configured; a blob is uploaded here
+// but only referenced once the caller acquires it in its transaction.
+// Otherwise it is kept inline, see encodeInline. Content encrypted under a
+// password is never a blob, as it cannot be shared between pastes.
+func (p *PasteRepository) encodeContent(ctx context.Context, content, password string) (*storedContent, error) {
+	if password == "" && p.blobs != nil {
+		hash, err := p.blobs.Prepare(ctx, []byte(content))
+		if err != nil {
+			return nil, fmt.Errorf("failed to store paste content: %w", err)
+		}
+		return &storedContent{codec: codec.Identity, hash: &hash, size: len(content)}, nil
+	}
+	return p.encodeInline(ctx, content, password, p.compressor)
+}
+
+// encodeInline prepares content for the content columns of the pastes row:
+// compressed by compressor when large enough, sealed under a key derived from
+// password when one is given, and encrypted under a new data key when a
+// keyring is configured.
+func (p *PasteRepository) encodeInline(ctx context.Context, content, password string, compressor *codec.Compressor) (*storedContent, error) {
+	c, data, err := compressor.Encode([]byte(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress paste content: %w", err)
+	}
+	stored := &storedContent{codec: c, size: len(content)}
+	if password != "" {
+		if data, err = sealContent(ctx, password, data); err != nil {
+			return nil, fmt.Errorf("failed to encrypt paste content: %w", err)
+		}
+		stored.encrypted = true
+	}
+	switch {
+	case p.keyring != nil:
+		sealed, err := p.keyring.Seal(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt paste content: %w", err)
+		}
+		stored.data, stored.key, stored.keyID = sealed.Data, sealed.Key, &sealed.KeyID
+	case c == codec.Identity && !stored.encrypted:
+		stored.content = content
+	default:
+		stored.data = data
+	}
+	return stored, nil
+}
+
+func sealContent(ctx context.Context, password string, data []byte) ([]byte, error) {
+	_, span := tracing.Start(ctx, "pastecrypt.Seal")
+	defer span.End()
+	return pastecrypt.Seal(password, data)
+}
+
+// reencodeContent locks the paste within tx and encodes its new content, or
+// its current content under a new password, charging the size difference to
+// the owner and swapping blob references. Without a new password the content
+// stays encrypted under the current one, which must then be given in
+// CurrentPassword if the content is already encrypted. Blobs are uploaded
+// while the row is locked, which is fine for the sizes pastes come in.
+func (p *PasteRepository) reencodeContent(ctx context.Context, tx pgx.Tx, pasteID uuid.UUID, patch *models.PatchPaste) (*storedContent, error) {
+	var r pasteRow
+	var oldSize int64
+	query := `SELECT user_id, password, content, content_codec, content_data, content_hash, content_encrypted, content_key, content_key_id, content_size
+		FROM pastes WHERE id = $1 FOR UPDATE`
+	err := tx.QueryRow(ctx, query, pasteID).Scan(&r.UserID, &r.PasswordHash, &r.Content, &r.Codec, &r.Data, &r.Hash, &r.Sealed, &r.Key, &r.KeyID, &oldSize)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("paste not found with id: %s", pasteID.String())
+		}
+		return nil, fmt.Errorf("failed to lock paste: %w", err)
+	}
+	oldHash := r.Hash
+
+	var current string
+	if patch.CurrentPassword != nil && *patch.CurrentPassword != "" {
+		current = *patch.CurrentPassword
+		if !verifyPastePassword(ctx, r.PasswordHash, current) {
+			metrics.PasswordCheckFailed("update")
+			return nil, ErrInvalidCurrentPassword
+		}
+	}
+	// Encrypted content can only be kept or re-keyed by someone who knows
+	// the password it is encrypted under
+	if r.Sealed && current == "" && (patch.Content == nil || patch.Password == nil) {
+		return nil, ErrCurrentPasswordRequired
+	}
+	password := current
+	if patch.Password != nil {
+		password = *patch.Password
+	}
+	var content string
+	if patch.Content != nil {
+		content = *patch.Content
+	} else {
+		paste, err := p.decode(ctx, &r, current)
+		if err != nil {
+			return nil, err
+		}
+		content = paste.Content
+	}
+
+	stored, err := p.encodeContent(ctx, content, password)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.chargeStorage(ctx, tx, r.UserID, int64(stored.size)-oldSize); err != nil {
+		return nil, err
+	}
+	if stored.hash != nil {
+		if err := p.blobs.acquire(ctx, tx, *stored.hash); err != nil {
+			return nil, err
+		}
+	}
+	if oldHash != nil {
+		if err := p.blobs.release(ctx, tx, *oldHash); err != nil {
+			return nil, err
+		}
+	}
+	return stored, nil
+}
+
+// RecompressBatch rewrites up to limit inline pastes with an id greater than
+// after using the repository's compressor, skipping rows locked by concurrent
+// writes. Blob-backed pastes and those encrypted under a password are left
+// alone. Passing decompress stores them as plain text instead. It returns the
+// last id it looked at, or uuid.Nil once there is nothing left, and how many
+// rows changed.
+func (p *PasteRepository) RecompressBatch(ctx context.Context, after uuid.UUID, limit int, decompress bool) (uuid.UUID, int, error) {
+	ctx, span := tracing.Start(ctx, "PasteRepository.RecompressBatch")
+	defer span.End()
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	batch, err := p.lockInlineBatch(ctx, tx, after, limit)
+	if err != nil {
+		return uuid.Nil, 0, err
+	}
+	if len(batch) == 0 {
+		return uuid.Nil, 0, nil
+	}
+
+	compressor := p.compressor
+	if decompress {
+		compressor = nil
+	}
+	changed := 0
+	for _, row := range batch {
+		oldCodec := codec.Codec(row.Codec)
+		paste, err := p.decode(ctx, &row, "")
+		if err != nil {
+			return uuid.Nil, 0, fmt.Errorf("failed to decode paste %s: %w", row.ID, err)
+		}
+		stored, err := p.encodeInline(ctx, paste.Content, "", compressor)
+		if err != nil {
+			return uuid.Nil, 0, fmt.Errorf("failed to encode paste %s: %w", row.ID, err)
+		}
+		if stored.codec == oldCodec {
+			continue
+		}
+		query, args, err := sq.Update("pastes").
+			SetMap(stored.columns()).
+			Where(sq.Eq{"id": row.ID}).
+			PlaceholderFormat(sq.Dollar).
+			ToSql()
+		if err != nil {
+			return uuid.Nil, 0, fmt.Errorf("failed to build update query: %w", err)
+		}
+		if _, err := tx.Exec(ctx, query, args...); err != nil {
+			return uuid.Nil, 0, fmt.Errorf("failed to update paste %s: %w", row.ID, err)
+		}
+		changed++
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return uuid.Nil, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return batch[len(batch)-1].ID, changed, nil
+}
+
+// MoveBatchToBlobs moves up to limit inline pastes with an id greater than
+// after into the blob store. Pastes encrypted under a password stay inline.
+// It returns the last id it looked at, or uuid.Nil once there is nothing
+// left, and how many pastes moved.
+func (p *PasteRepository) MoveBatchToBlobs(ctx context.Context, after uuid.UUID, limit int) (uuid.UUID, int, error) {
+	ctx, span := tracing.Start(ctx, "PasteRepository.MoveBatchToBlobs")
+	defer span.End()
+	if p.blobs == nil {
+		return uuid.Nil, 0, fmt.Errorf("no blob store configured")
+	}
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	batch, err := p.lockInlineBatch(ctx, tx, after, limit)
+	if err != nil {
+		return uuid.Nil, 0, err
+	}
+	if len(batch) == 0 {
+		return uuid.Nil, 0, nil
+	}
+
+	for _, row := range batch {
+		paste, err := p.decode(ctx, &row, "")
+		if err != nil {
+			return uuid.Nil, 0, fmt.Errorf("failed to decode paste %s: %w", row.ID, err)
+		}
+		hash, err := p.blobs.Prepare(ctx, []byte(paste.Content))
+		if err != nil {
+			return uuid.Nil, 0, fmt.Errorf("failed to store paste %s: %w", row.ID, err)
+		}
+		if err := p.blobs.acquire(ctx, tx, hash); err != nil {
+			return uuid.Nil, 0, err
+		}
+		update := `UPDATE pastes SET content = '', content_codec = $2, content_data = NULL, content_hash = $3, content_key = NULL, content_key_id = NULL WHERE id = $1`
+		if _, err := tx.Exec(ctx, update, row.ID, codec.Identity, hash); err != nil {
+			return uuid.Nil, 0, fmt.Errorf("failed to update paste %s: %w", row.ID, err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return uuid.Nil, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return batch[len(batch)-1].ID, len(batch), nil
+}
+
+// lockInlineBatch locks up to limit pastes with an id greater than after
+// whose content is kept inline and not encrypted under a password, skipping
+// rows locked by concurrent writes. Only the id and content columns are set.
+func (p *PasteRepository) lockInlineBatch(ctx context.Context, tx pgx.Tx, after uuid.UUID, limit int) ([]pasteRow, error) {
+	query := `SELECT id, content, content_codec, content_data, content_key, content_key_id FROM pastes
+		WHERE id > $1 AND content_hash IS NULL AND NOT content_encrypted
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED`
+	rows, err := tx.Query(ctx, query, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pastes: %w", err)
+	}
+	batch, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (pasteRow, error) {
+		var r pasteRow
+		err := row.Scan(&r.ID, &r.Content, &r.Codec, &r.Data, &r.Key, &r.KeyID)
+		return r, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect pastes: %w", err)
+	}
+	return batch, nil
+}
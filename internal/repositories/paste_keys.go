@@ -0,0 +1,180 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"pastebin/internal/codec"
+	"pastebin/internal/envelope"
+	"pastebin/internal/tracing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var errNoKeyring = errors.New("no master key configured")
+
+// unwrapContent decrypts envelope-encrypted content with its wrapped data key.
+func unwrapContent(ctx context.Context, keyring *envelope.Keyring, data, key []byte, keyID *string) ([]byte, error) {
+	_, span := tracing.Start(ctx, "envelope.Open")
+	defer span.End()
+	if keyring == nil {
+		return nil, errNoKeyring
+	}
+	var id string
+	if keyID != nil {
+		id = *keyID
+	}
+	return keyring.Open(envelope.Sealed{Data: data, Key: key, KeyID: id})
+}
+
+// RewrapBatch re-wraps the data keys of up to limit pastes with an id greater
+// than after under the current master key, leaving their content alone. It
+// returns the last id it looked at, or uuid.Nil once there is nothing left,
+// and how many keys it re-wrapped.
+func (p *PasteRepository) RewrapBatch(ctx context.Context, after uuid.UUID, limit int) (uuid.UUID, int, error) {
+	ctx, span := tracing.Start(ctx, "PasteRepository.RewrapBatch")
+	defer span.End()
+	if p.keyring == nil {
+		return uuid.Nil, 0, errNoKeyring
+	}
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `SELECT id, content_key, content_key_id FROM pastes
+		WHERE id > $1 AND content_key_id <> $3
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED`
+	rows, err := tx.Query(ctx, query, after, limit, p.keyring.CurrentID())
+	if err != nil {
+		return uuid.Nil, 0, fmt.Errorf("failed to query pastes: %w", err)
+	}
+	type keyRow struct {
+		ID    uuid.UUID `db:"id"`
+		Key   []byte    `db:"content_key"`
+		KeyID string    `db:"content_key_id"`
+	}
+	batch, err := pgx.CollectRows(rows, pgx.RowToStructByName[keyRow])
+	if err != nil {
+		return uuid.Nil, 0, fmt.Errorf("failed to collect pastes: %w", err)
+	}
+	if len(batch) == 0 {
+		return uuid.Nil, 0, nil
+	}
+	for _, row := range batch {
+		key, keyID, err := p.keyring.Rewrap(row.Key, row.KeyID)
+		if err != nil {
+			return uuid.Nil, 0, fmt.Errorf("failed to rewrap paste %s: %w", row.ID, err)
+		}
+		update := `UPDATE pastes SET content_key = $2, content_key_id = $3 WHERE id = $1`
+		if _, err := tx.Exec(ctx, update, row.ID, key, keyID); err != nil {
+			return uuid.Nil, 0, fmt.Errorf("failed to update paste %s: %w", row.ID, err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return uuid.Nil, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return batch[len(batch)-1].ID, len(batch), nil
+}
+
+// EncryptBatch encrypts the content of up to limit pastes with an id greater
+// than after that were stored before a master key was configured. Inline
+// content is encrypted as stored, still compressed or sealed under its
+// password. Content in an unencrypted blob is stored again as an encrypted
+// blob, and the old blob is left to the collector. It returns the last id it
+// looked at, or uuid.Nil once there is nothing left, and how many pastes it
+// encrypted.
+func (p *PasteRepository) EncryptBatch(ctx context.Context, after uuid.UUID, limit int) (uuid.UUID, int, error) {
+	ctx, span := tracing.Start(ctx, "PasteRepository.EncryptBatch")
+	defer span.End()
+	if p.keyring == nil {
+		return uuid.Nil, 0, errNoKeyring
+	}
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `SELECT p.id, p.content, p.content_data, p.content_hash FROM pastes p
+		LEFT JOIN blobs b ON b.hash = p.content_hash
+		WHERE p.id > $1 AND p.content_key IS NULL AND (p.content_hash IS NULL OR b.key IS NULL)
+		ORDER BY p.id
+		LIMIT $2
+		FOR UPDATE OF p SKIP LOCKED`
+	rows, err := tx.Query(ctx, query, after, limit)
+	if err != nil {
+		return uuid.Nil, 0, fmt.Errorf("failed to query pastes: %w", err)
+	}
+	type plainRow struct {
+		ID      uuid.UUID `db:"id"`
+		Content string    `db:"content"`
+		Data    []byte    `db:"content_data"`
+		Hash    *string   `db:"content_hash"`
+	}
+	batch, err := pgx.CollectRows(rows, pgx.RowToStructByName[plainRow])
+	if err != nil {
+		return uuid.Nil, 0, fmt.Errorf("failed to collect pastes: %w", err)
+	}
+	if len(batch) == 0 {
+		return uuid.Nil, 0, nil
+	}
+
+	for _, row := range batch {
+		if row.Hash != nil {
+			if err := p.encryptBlob(ctx, tx, row.ID, *row.Hash); err != nil {
+				return uuid.Nil, 0, err
+			}
+			continue
+		}
+		data := row.Data
+		if data == nil {
+			data = []byte(row.Content)
+		}
+		sealed, err := p.keyring.Seal(data)
+		if err != nil {
+			return uuid.Nil, 0, fmt.Errorf("failed to encrypt paste %s: %w", row.ID, err)
+		}
+		update := `UPDATE pastes SET content = '', content_data = $2, content_key = $3, content_key_id = $4 WHERE id = $1`
+		if _, err := tx.Exec(ctx, update, row.ID, sealed.Data, sealed.Key, sealed.KeyID); err != nil {
+			return uuid.Nil, 0, fmt.Errorf("failed to update paste %s: %w", row.ID, err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return uuid.Nil, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return batch[len(batch)-1].ID, len(batch), nil
+}
+
+// encryptBlob points a paste at an encrypted copy of its unencrypted blob.
+func (p *PasteRepository) encryptBlob(ctx context.Context, tx pgx.Tx, pasteID uuid.UUID, hash string) error {
+	if p.blobs == nil {
+		return fmt.Errorf("paste %s is stored as a blob but no blob store is configured", pasteID)
+	}
+	contentCodec, data, err := p.blobs.Load(ctx, hash)
+	if err != nil {
+		return err
+	}
+	content, err := codec.Decode(contentCodec, data)
+	if err != nil {
+		return fmt.Errorf("failed to decode paste %s: %w", pasteID, err)
+	}
+	newHash, err := p.blobs.Prepare(ctx, content)
+	if err != nil {
+		return fmt.Errorf("failed to store paste %s: %w", pasteID, err)
+	}
+	if err := p.blobs.acquire(ctx, tx, newHash); err != nil {
+		return err
+	}
+	if err := p.blobs.release(ctx, tx, hash); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE pastes SET content_hash = $2 WHERE id = $1`, pasteID, newHash); err != nil {
+		return fmt.Errorf("failed to update paste %s: %w", pasteID, err)
+	}
+	return nil
+}
@@ -2,10 +2,19 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
+	"maps"
+	"pastebin/internal/codec"
+	"pastebin/internal/envelope"
+	"pastebin/internal/logging"
+	"pastebin/internal/metrics"
 	"pastebin/internal/models"
+	"pastebin/internal/slug"
+	"pastebin/internal/tracing"
 	"pastebin/pkg/utils"
+	"slices"
+	"strings"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
@@ -14,34 +23,63 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var (
+	// ErrStorageQuotaExceeded is returned when a write would take a user past their storage quota.
+	ErrStorageQuotaExceeded = errors.New("storage quota exceeded")
+	// ErrCurrentPasswordRequired is returned when re-encrypting a paste needs its current password.
+	ErrCurrentPasswordRequired = errors.New("current password required")
+	// ErrInvalidCurrentPassword is returned when the current password given for a paste is wrong.
+	ErrInvalidCurrentPassword = errors.New("invalid current password")
+)
+
+// forksColumn selects how many live forks the paste aliased p has.
+const forksColumn = `(SELECT COUNT(*) FROM pastes f WHERE f.forked_from = p.id AND (f.expires_at IS NULL OR f.expires_at > NOW())) AS forks`
+
+// starsColumn selects how many users starred the paste aliased p.
+const starsColumn = `(SELECT COUNT(*) FROM paste_stars st WHERE st.paste_id = p.id) AS stars`
+
+// listedCondition matches the pastes aliased p that the explore and trending
+// feeds may show: live, public, without a password and not unlisted.
+const listedCondition = `p.is_private = FALSE AND p.password = '' AND p.unlisted = FALSE AND (p.expires_at IS NULL OR p.expires_at > NOW())`
+
+// maxLineageDepth bounds how far back a fork lineage is followed.
+const maxLineageDepth = 20
+
 type PasteRepository struct {
 	db *pgxpool.Pool
+	// storageQuota applies to users without a per-user override; 0 is unlimited.
+	storageQuota int64
+	compressor   *codec.Compressor
+	// blobs stores content by hash; without it content is kept inline.
+	blobs *BlobRepository
+	// keyring encrypts inline content at rest; without it content is stored
+	// unencrypted.
+	keyring *envelope.Keyring
+	slugs   *slug.Generator
 }
 
-func NewPasteRepository(db *pgxpool.Pool) *PasteRepository {
+func NewPasteRepository(db *pgxpool.Pool, storageQuota int64, compressor *codec.Compressor, blobs *BlobRepository, keyring *envelope.Keyring, slugs *slug.Generator) *PasteRepository {
 	return &PasteRepository{
-		db: db,
+		db:           db,
+		storageQuota: storageQuota,
+		compressor:   compressor,
+		blobs:        blobs,
+		keyring:      keyring,
+		slugs:        slugs,
 	}
 }
 
 func (p *PasteRepository) CreatePaste(ctx context.Context, userID uuid.UUID, pasteInput *models.PasteInput) (*models.PasteOutput, error) {
-	query := `INSERT INTO pastes (user_id, title, is_private, content, language, url, password, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	ctx, span := tracing.Start(ctx, "PasteRepository.CreatePaste")
+	defer span.End()
+	query := `INSERT INTO pastes (user_id, title, is_private, content, content_codec, content_data, content_hash, content_encrypted, content_key, content_key_id, content_size, language, slug, password, client_encryption, expires_at, secret_allowlist, forked_from, unlisted) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19) RETURNING id`
 	title := pasteInput.Title
 	if title == "" {
 		title = "Untitled"
 	}
-	urlSlug := uuid.New().String()[:8]
-	var isPrivate bool
+	isPrivate := pasteInput.IsPrivate
 	var passwordHash string
-	baseURL := os.Getenv("BASE_URL")
-	if baseURL == "" {
-		baseURL = "http://localhost:8080"
-	}
-	url := baseURL + "/p/" + urlSlug
-	if pasteInput.Password == "" {
-		isPrivate = false
-		passwordHash = ""
-	} else {
+	if pasteInput.Password != "" {
 		isPrivate = true
 		hashedPassword, err := utils.HashPassword(pasteInput.Password)
 		if err != nil {
@@ -51,15 +89,37 @@ func (p *PasteRepository) CreatePaste(ctx context.Context, userID uuid.UUID, pas
 	}
 	language := pasteInput.Language
 	content := pasteInput.Content
+	stored, err := p.encodeContent(ctx, content, pasteInput.Password)
+	if err != nil {
+		return nil, err
+	}
 	expiresAt := pasteInput.ExpiresAt
+	allowlist := pasteInput.SecretAllowlist
+	if allowlist == nil {
+		allowlist = []string{}
+	}
 	tx, err := p.db.Begin(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
-	_, err = tx.Exec(ctx, query, userID, title, isPrivate, content, language, url, passwordHash, expiresAt)
+	if err := p.chargeStorage(ctx, tx, userID, int64(len(content))); err != nil {
+		return nil, err
+	}
+	if stored.hash != nil {
+		if err := p.blobs.acquire(ctx, tx, *stored.hash); err != nil {
+			return nil, err
+		}
+	}
+	var pasteID uuid.UUID
+	_, err = p.insertWithSlug(ctx, tx, pasteInput.Slug, func(tx pgx.Tx, slug string) error {
+		return tx.QueryRow(ctx, query, userID, title, isPrivate, stored.content, stored.codec, stored.data, stored.hash, stored.encrypted, stored.key, stored.keyID, stored.size, language, slug, passwordHash, pasteInput.Encryption, expiresAt, allowlist, pasteInput.ForkedFrom, pasteInput.Unlisted).Scan(&pasteID)
+	})
 	if err != nil {
+		if errors.Is(err, ErrSlugTaken) {
+			return nil, err
+		}
 		return nil, fmt.Errorf("failed to insert paste: %w", err)
 	}
 	if err := tx.Commit(ctx); err != nil {
@@ -67,8 +127,8 @@ func (p *PasteRepository) CreatePaste(ctx context.Context, userID uuid.UUID, pas
 	}
 
 	// Retrieve the created paste to return it
-	getQuery := `SELECT id, user_id, title, is_private, content, password, language, url, expires_at, created_at, updated_at, 0 as views FROM pastes WHERE url = $1`
-	row, err := p.db.Query(ctx, getQuery, url)
+	getQuery := `SELECT id, user_id, title, is_private, content, password, client_encryption, language, slug, forked_from, 0 as forks, 0 as stars, comments_enabled, unlisted, expires_at, created_at, updated_at, 0 as views FROM pastes WHERE id = $1`
+	row, err := p.db.Query(ctx, getQuery, pasteID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve created paste: %w", err)
 	}
@@ -77,11 +137,15 @@ func (p *PasteRepository) CreatePaste(ctx context.Context, userID uuid.UUID, pas
 	if err != nil {
 		return nil, fmt.Errorf("failed to collect created paste: %w", err)
 	}
+	// The stored content may be compressed, encrypted or a blob reference
+	paste.Content = content
 
 	return &paste, nil
 }
 
 func (p *PasteRepository) UpdatePaste(ctx context.Context, pasteID uuid.UUID, patchInput *models.PatchPaste) error {
+	ctx, span := tracing.Start(ctx, "PasteRepository.UpdatePaste")
+	defer span.End()
 	// Convert patch input to a map of updates, skipping nil fields
 	updates := utils.StructToMap(patchInput, "db")
 
@@ -112,6 +176,25 @@ func (p *PasteRepository) UpdatePaste(ctx context.Context, pasteID uuid.UUID, pa
 	// Always update the updated_at timestamp
 	updates["updated_at"] = time.Now()
 
+	// Begin transaction
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// New content, or a new password for encrypted content, means the
+	// content columns have to be written again
+	if patchInput.Content != nil || patchInput.Password != nil {
+		stored, err := p.reencodeContent(ctx, tx, pasteID, patchInput)
+		if err != nil {
+			return err
+		}
+		for column, value := range stored.columns() {
+			updates[column] = value
+		}
+	}
+
 	// Build the update query using squirrel
 	updateBuilder := sq.Update("pastes").
 		SetMap(updates).
@@ -123,13 +206,6 @@ func (p *PasteRepository) UpdatePaste(ctx context.Context, pasteID uuid.UUID, pa
 		return fmt.Errorf("failed to build update query: %w", err)
 	}
 
-	// Begin transaction
-	tx, err := p.db.Begin(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback(ctx)
-
 	// Execute the update query
 	cmdTag, err := tx.Exec(ctx, query, args...)
 	if err != nil {
@@ -148,14 +224,117 @@ func (p *PasteRepository) UpdatePaste(ctx context.Context, pasteID uuid.UUID, pa
 	return nil
 }
 
+// GetSecretAllowlist returns the rule IDs and fingerprints the secret scanner
+// accepts in a paste.
+func (p *PasteRepository) GetSecretAllowlist(ctx context.Context, pasteID uuid.UUID) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "PasteRepository.GetSecretAllowlist")
+	defer span.End()
+	var allowlist []string
+	if err := p.db.QueryRow(ctx, `SELECT secret_allowlist FROM pastes WHERE id = $1`, pasteID).Scan(&allowlist); err != nil {
+		return nil, fmt.Errorf("failed to get secret allowlist: %w", err)
+	}
+	return allowlist, nil
+}
+
+// chargeStorage adds delta bytes to the user's stored total within tx. Growth
+// that would take the user past their quota fails with ErrStorageQuotaExceeded;
+// shrinking always succeeds.
+func (p *PasteRepository) chargeStorage(ctx context.Context, tx pgx.Tx, userID uuid.UUID, delta int64) error {
+	if delta == 0 {
+		return nil
+	}
+	query := `UPDATE users SET storage_used_bytes = GREATEST(storage_used_bytes + $2, 0)
+		WHERE id = $1 AND (
+			$2 < 0
+			OR COALESCE(storage_quota_bytes, $3) = 0
+			OR storage_used_bytes + $2 <= COALESCE(storage_quota_bytes, $3)
+		)`
+	cmdTag, err := tx.Exec(ctx, query, userID, delta, p.storageQuota)
+	if err != nil {
+		return fmt.Errorf("failed to update storage usage: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrStorageQuotaExceeded
+	}
+	return nil
+}
+
+// PurgeExpiredPastes deletes up to limit expired pastes and returns how many
+// it removed. Like DeletePasteByID, it gives their bytes back to the owners'
+// storage usage and drops their blob references. Rows locked by a concurrent
+// writer are skipped until the next run.
+func (p *PasteRepository) PurgeExpiredPastes(ctx context.Context, limit int) (int, error) {
+	ctx, span := tracing.Start(ctx, "PasteRepository.PurgeExpiredPastes")
+	defer span.End()
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `DELETE FROM pastes WHERE id IN (
+			SELECT id FROM pastes
+			WHERE expires_at <= NOW()
+			ORDER BY expires_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING user_id, content_size, content_hash`
+	rows, err := tx.Query(ctx, query, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired pastes: %w", err)
+	}
+	freed := map[uuid.UUID]int64{}
+	var hashes []string
+	purged := 0
+	for rows.Next() {
+		var ownerID uuid.UUID
+		var size int64
+		var hash *string
+		if err := rows.Scan(&ownerID, &size, &hash); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan expired paste: %w", err)
+		}
+		freed[ownerID] += size
+		if hash != nil {
+			hashes = append(hashes, *hash)
+		}
+		purged++
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to delete expired pastes: %w", err)
+	}
+
+	// A fixed lock order keeps concurrent purges from deadlocking
+	owners := slices.SortedFunc(maps.Keys(freed), func(a, b uuid.UUID) int { return strings.Compare(a.String(), b.String()) })
+	for _, ownerID := range owners {
+		if err := p.chargeStorage(ctx, tx, ownerID, -freed[ownerID]); err != nil {
+			return 0, err
+		}
+	}
+	slices.Sort(hashes)
+	for _, hash := range hashes {
+		if err := p.blobs.release(ctx, tx, hash); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return purged, nil
+}
+
 func (p *PasteRepository) GetPasteByID(ctx context.Context, pasteID uuid.UUID, isAuthenticated bool, userID uuid.UUID, password string) (*models.PasteOutput, error) {
-	query := `SELECT p.id, p.user_id, p.title, p.is_private, p.content, p.password, p.language, p.url, p.expires_at, p.created_at, p.updated_at, COALESCE(a.views, 0) as views FROM pastes p LEFT JOIN pastes_analytics a ON p.id = a.paste_id WHERE p.id = $1`
+	ctx, span := tracing.Start(ctx, "PasteRepository.GetPasteByID")
+	defer span.End()
+	query := `SELECT p.id, p.user_id, p.title, p.is_private, p.content, p.content_codec, p.content_data, p.content_hash, p.content_encrypted, p.content_key, p.content_key_id, p.password, p.client_encryption, p.language, p.slug, p.forked_from, ` + forksColumn + `, ` + starsColumn + `, p.comments_enabled, p.unlisted, p.expires_at, p.created_at, p.updated_at, COALESCE(a.views, 0) as views FROM pastes p LEFT JOIN pastes_analytics a ON p.id = a.paste_id WHERE p.id = $1`
 	row, err := p.db.Query(ctx, query, pasteID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query paste: %w", err)
 	}
 	defer row.Close()
-	paste, err := pgx.CollectExactlyOneRow(row, pgx.RowToStructByName[models.PasteOutput])
+	paste, err := pgx.CollectExactlyOneRow(row, pgx.RowToStructByName[pasteRow])
 	if err != nil {
 		return nil, fmt.Errorf("failed to collect paste: %w", err)
 	}
@@ -171,27 +350,48 @@ func (p *PasteRepository) GetPasteByID(ctx context.Context, pasteID uuid.UUID, i
 		if password == "" {
 			return nil, fmt.Errorf("password required")
 		}
-		if !utils.VerifyPassword(paste.PasswordHash, password) {
+		if !verifyPastePassword(ctx, paste.PasswordHash, password) {
+			metrics.PasswordCheckFailed("id")
 			return nil, fmt.Errorf("invalid password")
 
 		}
 	}
 	if !isOwner {
 		// Increment view count for non-owner views
-		_ = p.incrementViewCount(ctx, pasteID)
+		if err := p.incrementViewCount(ctx, pasteID); err != nil {
+			logging.Ctx(ctx).Warn().Err(err).Str("paste_id", pasteID.String()).Msg("failed to increment view count")
+		}
 	}
-	return &paste, nil
+	return p.decode(ctx, &paste, password)
+}
+
+// verifyPastePassword wraps the bcrypt comparison in its own span; it dominates
+// latency on protected pastes and is otherwise invisible in traces.
+func verifyPastePassword(ctx context.Context, hash, password string) bool {
+	_, span := tracing.Start(ctx, "bcrypt.VerifyPassword")
+	defer span.End()
+	return utils.VerifyPassword(hash, password)
 }
 
 func (p *PasteRepository) incrementViewCount(ctx context.Context, pasteID uuid.UUID) error {
-	query := `INSERT INTO pastes_analytics (paste_id, views, updated_at) VALUES ($1, 1, NOW())
+	ctx, span := tracing.Start(ctx, "PasteRepository.incrementViewCount")
+	defer span.End()
+	query := `WITH total AS (
+ INSERT INTO pastes_analytics (paste_id, views, updated_at) VALUES ($1, 1, NOW())
  ON CONFLICT (paste_id)
- DO UPDATE SET views = pastes_analytics.views + 1, updated_at = NOW()`
-	_, err := p.db.Exec(ctx, query, pasteID)
-	return err
+ DO UPDATE SET views = pastes_analytics.views + 1, updated_at = NOW()
+)
+` + incrementHourlyViewsQuery
+	if _, err := p.db.Exec(ctx, query, pasteID); err != nil {
+		return err
+	}
+	metrics.ViewCounted()
+	return nil
 }
 
 func (p *PasteRepository) GetAllPastes(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.PasteOutput, int, error) {
+	ctx, span := tracing.Start(ctx, "PasteRepository.GetAllPastes")
+	defer span.End()
 	// First, get the total count of non-expired pastes for the user
 	countQuery := `SELECT COUNT(*) FROM pastes WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > NOW())`
 	var total int
@@ -201,7 +401,7 @@ func (p *PasteRepository) GetAllPastes(ctx context.Context, userID uuid.UUID, li
 	}
 
 	// Then get the paginated results
-	query := `SELECT p.id, p.user_id, p.title, p.is_private, p.language, p.url, p.expires_at, p.created_at, COALESCE(a.views, 0) as views
+	query := `SELECT p.id, p.user_id, p.title, p.is_private, '' AS content, '' AS password, p.client_encryption, p.language, p.slug, p.forked_from, ` + forksColumn + `, ` + starsColumn + `, p.comments_enabled, p.unlisted, p.expires_at, p.created_at, p.updated_at, COALESCE(a.views, 0) as views
 		FROM pastes p
 		LEFT JOIN pastes_analytics a ON p.id = a.paste_id
 		WHERE p.user_id = $1 AND (p.expires_at IS NULL OR p.expires_at > NOW())
@@ -221,9 +421,12 @@ func (p *PasteRepository) GetAllPastes(ctx context.Context, userID uuid.UUID, li
 }
 
 func (p *PasteRepository) DeletePasteByID(ctx context.Context, pasteID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "PasteRepository.DeletePasteByID")
+	defer span.End()
 	// Build the delete query using squirrel
 	query := sq.Delete("pastes").
 		Where(sq.Eq{"id": pasteID}).
+		Suffix("RETURNING user_id, content_size, content_hash").
 		PlaceholderFormat(sq.Dollar)
 
 	queryStr, args, err := query.ToSql()
@@ -239,13 +442,25 @@ func (p *PasteRepository) DeletePasteByID(ctx context.Context, pasteID uuid.UUID
 	defer tx.Rollback(ctx)
 
 	// Execute the delete query
-	cmdTag, err := tx.Exec(ctx, queryStr, args...)
+	var ownerID uuid.UUID
+	var size int64
+	var hash *string
+	err = tx.QueryRow(ctx, queryStr, args...).Scan(&ownerID, &size, &hash)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("paste not found with id: %s", pasteID)
+		}
 		return fmt.Errorf("failed to delete paste by id: %w", err)
 	}
 
-	if cmdTag.RowsAffected() == 0 {
-		return fmt.Errorf("paste not found with id: %s", pasteID)
+	// Give the freed bytes back to the owner and drop the blob reference
+	if err := p.chargeStorage(ctx, tx, ownerID, -size); err != nil {
+		return err
+	}
+	if hash != nil {
+		if err := p.blobs.release(ctx, tx, *hash); err != nil {
+			return err
+		}
 	}
 
 	// Commit transaction
@@ -257,56 +472,161 @@ func (p *PasteRepository) DeletePasteByID(ctx context.Context, pasteID uuid.UUID
 }
 
 func (p *PasteRepository) GetPasteBySlug(ctx context.Context, slug string, password string) (*models.PasteOutput, error) {
-	// Query for paste where URL ends with /p/slug
-	query := `SELECT p.id, p.user_id, p.title, p.is_private, p.content, p.password, p.language, p.url, p.expires_at, p.created_at, p.updated_at, COALESCE(a.views, 0) as views FROM pastes p LEFT JOIN pastes_analytics a ON p.id = a.paste_id WHERE p.url LIKE $1`
-	row, err := p.db.Query(ctx, query, "%/p/"+slug)
+	ctx, span := tracing.Start(ctx, "PasteRepository.GetPasteBySlug")
+	defer span.End()
+	paste, err := p.getPasteRowBySlug(ctx, slug, password)
+	if err != nil {
+		return nil, err
+	}
+	return p.decode(ctx, paste, password)
+}
+
+// GetStoredPasteBySlug is GetPasteBySlug without decompression: the paste's
+// Content is empty when it is compressed, and the stored bytes are returned
+// alongside so they can be served as-is.
+func (p *PasteRepository) GetStoredPasteBySlug(ctx context.Context, slug string, password string) (*models.PasteOutput, *models.StoredContent, error) {
+	ctx, span := tracing.Start(ctx, "PasteRepository.GetStoredPasteBySlug")
+	defer span.End()
+	paste, err := p.getPasteRowBySlug(ctx, slug, password)
+	if err != nil {
+		return nil, nil, err
+	}
+	stored, err := p.stored(ctx, paste, password)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &paste.PasteOutput, stored, nil
+}
+
+func (p *PasteRepository) getPasteRowBySlug(ctx context.Context, slug string, password string) (*pasteRow, error) {
+	query := `SELECT p.id, p.user_id, p.title, p.is_private, p.content, p.content_codec, p.content_data, p.content_hash, p.content_encrypted, p.content_key, p.content_key_id, p.password, p.client_encryption, p.language, p.slug, p.forked_from, ` + forksColumn + `, ` + starsColumn + `, p.comments_enabled, p.unlisted, p.expires_at, p.created_at, p.updated_at, COALESCE(a.views, 0) as views FROM pastes p LEFT JOIN pastes_analytics a ON p.id = a.paste_id WHERE p.slug = $1`
+	row, err := p.db.Query(ctx, query, slug)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query paste by slug: %w", err)
 	}
 	defer row.Close()
-	paste, err := pgx.CollectExactlyOneRow(row, pgx.RowToStructByName[models.PasteOutput])
+	paste, err := pgx.CollectExactlyOneRow(row, pgx.RowToStructByName[pasteRow])
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPasteNotFound
+		}
 		return nil, fmt.Errorf("failed to collect paste: %w", err)
 	}
-
-	// Check if paste has expired
-	if paste.ExpiresAt != nil && paste.ExpiresAt.Before(time.Now()) {
-		return nil, fmt.Errorf("paste has expired")
+	if err := checkSlugAccess(ctx, &paste.PasteOutput, password); err != nil {
+		return nil, err
 	}
-
-	// Check if paste is private (should not be accessible publicly)
+	// Views of private pastes are not counted
 	if paste.IsPrivate {
-
-		if password == "" {
-			return nil, fmt.Errorf("password required")
-		}
-		if !utils.VerifyPassword(paste.PasswordHash, password) {
-			return nil, fmt.Errorf("invalid password")
-
-		}
-
 		return &paste, nil
 	}
 
 	// Increment view count
 	if err := p.incrementViewCount(ctx, paste.ID); err != nil {
 		// Log the error but don't fail the paste retrieval
+		logging.Ctx(ctx).Warn().Err(err).Str("paste_id", paste.ID.String()).Msg("failed to increment view count")
 	}
 
 	return &paste, nil
 }
 
+// checkSlugAccess applies the rules for reading a paste by slug: it must not
+// have expired, and a private paste needs its password.
+func checkSlugAccess(ctx context.Context, paste *models.PasteOutput, password string) error {
+	if paste.ExpiresAt != nil && paste.ExpiresAt.Before(time.Now()) {
+		return fmt.Errorf("paste has expired")
+	}
+	if paste.IsPrivate {
+		return checkPastePassword(ctx, paste, password)
+	}
+	return nil
+}
+
+// checkPastePassword reports whether password opens paste.
+func checkPastePassword(ctx context.Context, paste *models.PasteOutput, password string) error {
+	if password == "" {
+		return fmt.Errorf("password required")
+	}
+	if !verifyPastePassword(ctx, paste.PasswordHash, password) {
+		metrics.PasswordCheckFailed("slug")
+		return fmt.Errorf("invalid password")
+	}
+	return nil
+}
+
+// CheckPasteAccess applies the rules for acting on a paste without reading
+// its content: it must not have expired, and a paste with a password needs
+// it whether or not it is private. Public password-protected pastes have no
+// locked form to fall back on here, unlike GetPasteBySlug.
+func (p *PasteRepository) CheckPasteAccess(ctx context.Context, paste *models.PasteOutput, password string) error {
+	if err := checkSlugAccess(ctx, paste, password); err != nil {
+		return err
+	}
+	if !paste.IsPrivate && paste.PasswordHash != "" {
+		return checkPastePassword(ctx, paste, password)
+	}
+	return nil
+}
+
+// GetPasteAccessBySlug returns the ID, owner, privacy and comment setting of
+// the paste shared under slug, applying the checks of CheckPasteAccess. It
+// loads no content and counts no view.
+func (p *PasteRepository) GetPasteAccessBySlug(ctx context.Context, slug, password string) (*models.PasteOutput, error) {
+	ctx, span := tracing.Start(ctx, "PasteRepository.GetPasteAccessBySlug")
+	defer span.End()
+	paste, err := p.getPasteSettings(ctx, `slug = $1`, slug)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.CheckPasteAccess(ctx, paste, password); err != nil {
+		return nil, err
+	}
+	return paste, nil
+}
+
+// GetPasteSettings returns the ID, owner, privacy and comment setting of a
+// paste, or ErrPasteNotFound.
+func (p *PasteRepository) GetPasteSettings(ctx context.Context, pasteID uuid.UUID) (*models.PasteOutput, error) {
+	ctx, span := tracing.Start(ctx, "PasteRepository.GetPasteSettings")
+	defer span.End()
+	return p.getPasteSettings(ctx, `id = $1`, pasteID)
+}
+
+func (p *PasteRepository) getPasteSettings(ctx context.Context, where string, arg any) (*models.PasteOutput, error) {
+	var paste models.PasteOutput
+	query := `SELECT id, user_id, slug, is_private, password, comments_enabled, expires_at FROM pastes WHERE ` + where
+	err := p.db.QueryRow(ctx, query, arg).Scan(&paste.ID, &paste.UserID, &paste.Slug, &paste.IsPrivate, &paste.PasswordHash, &paste.CommentsEnabled, &paste.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPasteNotFound
+		}
+		return nil, fmt.Errorf("failed to get paste: %w", err)
+	}
+	return &paste, nil
+}
+
 func (p *PasteRepository) FilterPastes(ctx context.Context, userID uuid.UUID, pasteFilter *models.PasteFilters) (*[]models.PasteOutput, error) {
+	ctx, span := tracing.Start(ctx, "PasteRepository.FilterPastes")
+	defer span.End()
 	// Build base select query - only select columns that exist in PasteOutput
 	builder := sq.Select(
 		"p.id",
 		"p.user_id",
 		"p.title",
 		"p.is_private",
-		"p.content",
+		// Content is stored compressed, encrypted or as a blob, so listings leave it out
+		"'' AS content",
+		"'' AS password",
+		"p.client_encryption",
 		"p.language",
-		"p.url",
+		"p.slug",
+		"p.forked_from",
+		forksColumn,
+		starsColumn,
+		"p.comments_enabled",
+		"p.unlisted",
 		"p.expires_at",
+		"p.created_at",
+		"p.updated_at",
 		"COALESCE(a.views, 0) as views",
 	).From("pastes p").
 		LeftJoin("pastes_analytics a ON p.id = a.paste_id").
@@ -319,41 +639,7 @@ func (p *PasteRepository) FilterPastes(ctx context.Context, userID uuid.UUID, pa
 	})
 	// Only selected users
 	builder = builder.Where(sq.Eq{"p.user_id": userID})
-	// Apply filters if provided
-	if pasteFilter != nil {
-		if len(pasteFilter.Languages) > 0 {
-			builder = builder.Where(sq.Eq{"p.language": pasteFilter.Languages})
-		}
-		if pasteFilter.DateFrom != nil {
-			builder = builder.Where(sq.GtOrEq{"p.created_at": *pasteFilter.DateFrom})
-		}
-		if pasteFilter.DateTo != nil {
-			builder = builder.Where(sq.LtOrEq{"p.created_at": *pasteFilter.DateTo})
-		}
-
-		// Handle sorting with allow-list for security
-		sortBy := "p.created_at"
-		switch pasteFilter.SortBy {
-		case "created_at":
-			sortBy = "p.created_at"
-		case "updated_at":
-			sortBy = "p.updated_at"
-		case "views":
-			sortBy = "views"
-		case "title":
-			sortBy = "p.title"
-		}
-
-		// Handle sort order
-		sortOrder := "DESC"
-		if pasteFilter.SortOrder == "asc" || pasteFilter.SortOrder == "ASC" {
-			sortOrder = "ASC"
-		}
-		builder = builder.OrderBy(fmt.Sprintf("%s %s", sortBy, sortOrder))
-	} else {
-		// Default sorting when no filter is provided
-		builder = builder.OrderBy("p.created_at DESC")
-	}
+	builder = sortPastes(applyPasteFilters(builder, pasteFilter), pasteFilter, "p.created_at")
 
 	// Build the SQL query
 	queryStr, args, err := builder.ToSql()
@@ -376,3 +662,287 @@ func (p *PasteRepository) FilterPastes(ctx context.Context, userID uuid.UUID, pa
 
 	return &pastes, nil
 }
+
+// applyPasteFilters narrows a query on the pastes aliased p to the languages
+// and creation dates in filter.
+func applyPasteFilters(builder sq.SelectBuilder, pasteFilter *models.PasteFilters) sq.SelectBuilder {
+	if pasteFilter == nil {
+		return builder
+	}
+	if len(pasteFilter.Languages) > 0 {
+		builder = builder.Where(sq.Eq{"p.language": pasteFilter.Languages})
+	}
+	if pasteFilter.DateFrom != nil {
+		builder = builder.Where(sq.GtOrEq{"p.created_at": *pasteFilter.DateFrom})
+	}
+	if pasteFilter.DateTo != nil {
+		builder = builder.Where(sq.LtOrEq{"p.created_at": *pasteFilter.DateTo})
+	}
+	return builder
+}
+
+// sortPastes orders a query on the pastes aliased p as filter asks, by
+// defaultSort descending when it names no known column.
+func sortPastes(builder sq.SelectBuilder, pasteFilter *models.PasteFilters, defaultSort string) sq.SelectBuilder {
+	if pasteFilter == nil {
+		// Default sorting when no filter is provided
+		return builder.OrderBy(defaultSort + " DESC")
+	}
+
+	// Handle sorting with allow-list for security
+	sortBy := defaultSort
+	switch pasteFilter.SortBy {
+	case "created_at":
+		sortBy = "p.created_at"
+	case "updated_at":
+		sortBy = "p.updated_at"
+	case "views":
+		sortBy = "views"
+	case "stars":
+		sortBy = "stars"
+	case "title":
+		sortBy = "p.title"
+	}
+
+	// Handle sort order
+	sortOrder := "DESC"
+	if pasteFilter.SortOrder == "asc" || pasteFilter.SortOrder == "ASC" {
+		sortOrder = "ASC"
+	}
+	return builder.OrderBy(fmt.Sprintf("%s %s", sortBy, sortOrder))
+}
+
+// GetPasteOwner returns the ID of the user owning a paste, or
+// ErrPasteNotFound.
+func (p *PasteRepository) GetPasteOwner(ctx context.Context, pasteID uuid.UUID) (uuid.UUID, error) {
+	ctx, span := tracing.Start(ctx, "PasteRepository.GetPasteOwner")
+	defer span.End()
+	var ownerID uuid.UUID
+	if err := p.db.QueryRow(ctx, `SELECT user_id FROM pastes WHERE id = $1`, pasteID).Scan(&ownerID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, ErrPasteNotFound
+		}
+		return uuid.Nil, fmt.Errorf("failed to get paste owner: %w", err)
+	}
+	return ownerID, nil
+}
+
+// ListForks returns a page of the live forks of a paste, newest first,
+// leaving out private forks of users other than viewerID, and how many there
+// are in all. Content is not loaded.
+func (p *PasteRepository) ListForks(ctx context.Context, pasteID, viewerID uuid.UUID, limit, offset int) ([]models.PasteOutput, int, error) {
+	ctx, span := tracing.Start(ctx, "PasteRepository.ListForks")
+	defer span.End()
+	visible := `p.forked_from = $1 AND (p.is_private = FALSE OR p.user_id = $2) AND (p.expires_at IS NULL OR p.expires_at > NOW())`
+	var total int
+	if err := p.db.QueryRow(ctx, `SELECT COUNT(*) FROM pastes p WHERE `+visible, pasteID, viewerID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count forks: %w", err)
+	}
+	query := `SELECT p.id, p.user_id, p.title, p.is_private, '' AS content, '' AS password, p.client_encryption, p.language, p.slug, p.forked_from, ` + forksColumn + `, ` + starsColumn + `, p.comments_enabled, p.unlisted, p.expires_at, p.created_at, p.updated_at, COALESCE(a.views, 0) as views
+		FROM pastes p
+		LEFT JOIN pastes_analytics a ON p.id = a.paste_id
+		WHERE ` + visible + `
+		ORDER BY p.created_at DESC
+		LIMIT $3 OFFSET $4`
+	rows, err := p.db.Query(ctx, query, pasteID, viewerID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list forks: %w", err)
+	}
+	defer rows.Close()
+	forks, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.PasteOutput])
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to collect forks: %w", err)
+	}
+	return forks, total, nil
+}
+
+// GetForkLineage returns the pastes a paste was forked from, its source
+// first. Private and password-protected pastes of users other than viewerID
+// and expired pastes are left out.
+func (p *PasteRepository) GetForkLineage(ctx context.Context, pasteID, viewerID uuid.UUID) ([]models.ForkRef, error) {
+	ctx, span := tracing.Start(ctx, "PasteRepository.GetForkLineage")
+	defer span.End()
+	query := `WITH RECURSIVE lineage AS (
+			SELECT forked_from AS id, 1 AS depth FROM pastes WHERE id = $1
+			UNION ALL
+			SELECT s.forked_from, l.depth + 1 FROM lineage l JOIN pastes s ON s.id = l.id WHERE l.depth < $3
+		)
+		SELECT p.id, p.title, p.slug
+		FROM lineage l
+		JOIN pastes p ON p.id = l.id
+		WHERE (p.user_id = $2 OR (p.is_private = FALSE AND p.password = '')) AND (p.expires_at IS NULL OR p.expires_at > NOW())
+		ORDER BY l.depth`
+	rows, err := p.db.Query(ctx, query, pasteID, viewerID, maxLineageDepth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fork lineage: %w", err)
+	}
+	defer rows.Close()
+	lineage, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.ForkRef])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect fork lineage: %w", err)
+	}
+	return lineage, nil
+}
+
+// StarPaste stars a paste for a user. Starring it again changes nothing.
+func (p *PasteRepository) StarPaste(ctx context.Context, userID, pasteID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "PasteRepository.StarPaste")
+	defer span.End()
+	query := `INSERT INTO paste_stars (user_id, paste_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	if _, err := p.db.Exec(ctx, query, userID, pasteID); err != nil {
+		return fmt.Errorf("failed to star paste: %w", err)
+	}
+	return nil
+}
+
+// UnstarPaste removes a user's star from the paste shared under slug, if
+// there is one.
+func (p *PasteRepository) UnstarPaste(ctx context.Context, userID uuid.UUID, slug string) error {
+	ctx, span := tracing.Start(ctx, "PasteRepository.UnstarPaste")
+	defer span.End()
+	query := `DELETE FROM paste_stars WHERE user_id = $1 AND paste_id IN (SELECT id FROM pastes WHERE slug = $2)`
+	if _, err := p.db.Exec(ctx, query, userID, slug); err != nil {
+		return fmt.Errorf("failed to unstar paste: %w", err)
+	}
+	return nil
+}
+
+// ListStars returns a page of the pastes a user starred, most recently
+// starred first unless the filter sorts otherwise, and how many there are in
+// all. Expired pastes and pastes made private by another owner since are left
+// out. Content is not loaded.
+func (p *PasteRepository) ListStars(ctx context.Context, userID uuid.UUID, pasteFilter *models.PasteFilters, limit, offset int) ([]models.PasteOutput, int, error) {
+	ctx, span := tracing.Start(ctx, "PasteRepository.ListStars")
+	defer span.End()
+	visible := sq.And{
+		sq.Eq{"st.user_id": userID},
+		sq.Or{sq.Eq{"p.is_private": false}, sq.Eq{"p.user_id": userID}},
+		sq.Or{sq.Eq{"p.expires_at": nil}, sq.Gt{"p.expires_at": time.Now()}},
+	}
+	counter := sq.Select("COUNT(*)").From("paste_stars st").
+		Join("pastes p ON p.id = st.paste_id").
+		Where(visible).
+		PlaceholderFormat(sq.Dollar)
+	countStr, countArgs, err := applyPasteFilters(counter, pasteFilter).ToSql()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to build star count query: %w", err)
+	}
+	var total int
+	if err := p.db.QueryRow(ctx, countStr, countArgs...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count stars: %w", err)
+	}
+
+	builder := sq.Select(
+		"p.id",
+		"p.user_id",
+		"p.title",
+		"p.is_private",
+		"'' AS content",
+		"'' AS password",
+		"p.client_encryption",
+		"p.language",
+		"p.slug",
+		"p.forked_from",
+		forksColumn,
+		starsColumn,
+		"p.comments_enabled",
+		"p.unlisted",
+		"p.expires_at",
+		"p.created_at",
+		"p.updated_at",
+		"COALESCE(a.views, 0) as views",
+	).From("paste_stars st").
+		Join("pastes p ON p.id = st.paste_id").
+		LeftJoin("pastes_analytics a ON p.id = a.paste_id").
+		Where(visible).
+		Limit(uint64(limit)).
+		Offset(uint64(offset)).
+		PlaceholderFormat(sq.Dollar)
+	builder = sortPastes(applyPasteFilters(builder, pasteFilter), pasteFilter, "st.created_at")
+	queryStr, args, err := builder.ToSql()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to build star query: %w", err)
+	}
+	rows, err := p.db.Query(ctx, queryStr, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list stars: %w", err)
+	}
+	defer rows.Close()
+	pastes, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.PasteOutput])
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to collect starred pastes: %w", err)
+	}
+	return pastes, total, nil
+}
+
+// listedColumns selects a paste aliased p without its content or password,
+// for the explore and trending feeds.
+const listedColumns = `p.id, p.user_id, p.title, p.is_private, '' AS content, '' AS password, p.client_encryption, p.language, p.slug, p.forked_from, ` + forksColumn + `, ` + starsColumn + `, p.comments_enabled, p.unlisted, p.expires_at, p.created_at, p.updated_at, COALESCE(a.views, 0) as views`
+
+// ListPublicPastes returns a page of the listed public pastes, newest first,
+// in one of languages when any are given, and how many there are in all.
+// Content is not loaded.
+func (p *PasteRepository) ListPublicPastes(ctx context.Context, languages []string, limit, offset int) ([]models.PasteOutput, int, error) {
+	ctx, span := tracing.Start(ctx, "PasteRepository.ListPublicPastes")
+	defer span.End()
+	where := listedCondition + ` AND (cardinality($1::text[]) = 0 OR p.language = ANY($1))`
+	if languages == nil {
+		languages = []string{}
+	}
+	var total int
+	if err := p.db.QueryRow(ctx, `SELECT COUNT(*) FROM pastes p WHERE `+where, languages).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count public pastes: %w", err)
+	}
+	query := `SELECT ` + listedColumns + `
+		FROM pastes p
+		LEFT JOIN pastes_analytics a ON p.id = a.paste_id
+		WHERE ` + where + `
+		ORDER BY p.created_at DESC, p.id
+		LIMIT $2 OFFSET $3`
+	rows, err := p.db.Query(ctx, query, languages, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list public pastes: %w", err)
+	}
+	defer rows.Close()
+	pastes, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.PasteOutput])
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to collect public pastes: %w", err)
+	}
+	return pastes, total, nil
+}
+
+// ListTrendingPastes returns a page of the listed public pastes viewed in the
+// last window, in one of languages when any are given, ranked by the views
+// counted in the hourly buckets of that window. Content is not loaded.
+func (p *PasteRepository) ListTrendingPastes(ctx context.Context, window time.Duration, languages []string, limit, offset int) ([]models.TrendingPaste, error) {
+	ctx, span := tracing.Start(ctx, "PasteRepository.ListTrendingPastes")
+	defer span.End()
+	if languages == nil {
+		languages = []string{}
+	}
+	query := `WITH recent AS (
+			SELECT h.paste_id, SUM(h.views)::bigint AS window_views
+			FROM pastes_analytics_hourly h
+			WHERE h.bucket >= date_trunc('hour', NOW()) - make_interval(hours => $1)
+			GROUP BY h.paste_id
+		)
+		SELECT ` + listedColumns + `, r.window_views
+		FROM recent r
+		JOIN pastes p ON p.id = r.paste_id
+		LEFT JOIN pastes_analytics a ON p.id = a.paste_id
+		WHERE ` + listedCondition + ` AND (cardinality($2::text[]) = 0 OR p.language = ANY($2))
+		ORDER BY r.window_views DESC, p.created_at DESC, p.id
+		LIMIT $3 OFFSET $4`
+	// The current, partial hour is the last of the window's buckets
+	hours := int(window / time.Hour)
+	rows, err := p.db.Query(ctx, query, hours-1, languages, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trending pastes: %w", err)
+	}
+	defer rows.Close()
+	pastes, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.TrendingPaste])
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect trending pastes: %w", err)
+	}
+	return pastes, nil
+}
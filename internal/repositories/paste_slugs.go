@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"pastebin/internal/tracing"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	// ErrSlugTaken is returned when a requested slug belongs to another paste,
+	// either as its current slug or as a redirect.
+	ErrSlugTaken = errors.New("slug is already taken")
+	// ErrPasteNotFound is returned when no paste has the requested slug or id.
+	ErrPasteNotFound = errors.New("paste not found")
+)
+
+// maxSlugAttempts bounds how often a generated slug is redrawn on conflict.
+const maxSlugAttempts = 5
+
+// insertWithSlug runs insert with a free slug within tx: the requested one,
+// which gets a single try, or a generated one, redrawn on conflict. Each try
+// runs in a savepoint so a conflict does not abort tx. It returns the slug
+// used.
+func (p *PasteRepository) insertWithSlug(ctx context.Context, tx pgx.Tx, requested string, insert func(tx pgx.Tx, slug string) error) (string, error) {
+	for attempt := 1; ; attempt++ {
+		slug := requested
+		if slug == "" {
+			var err error
+			if slug, err = p.slugs.Generate(); err != nil {
+				return "", err
+			}
+		}
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to begin savepoint: %w", err)
+		}
+		err = p.lockSlugs(ctx, savepoint, slug)
+		if err == nil {
+			err = checkSlugFree(ctx, savepoint, slug, uuid.Nil)
+		}
+		if err == nil {
+			if err = insert(savepoint, slug); isSlugConflict(err) {
+				err = ErrSlugTaken
+			}
+		}
+		if err == nil {
+			if err := savepoint.Commit(ctx); err != nil {
+				return "", fmt.Errorf("failed to release savepoint: %w", err)
+			}
+			return slug, nil
+		}
+		_ = savepoint.Rollback(ctx)
+		if !errors.Is(err, ErrSlugTaken) || requested != "" {
+			return "", err
+		}
+		if attempt == maxSlugAttempts {
+			return "", fmt.Errorf("failed to find a free slug after %d attempts: %w", attempt, err)
+		}
+	}
+}
+
+// RenameSlug gives a paste a new slug and keeps the old one as a redirect.
+// Taking back one of the paste's own old slugs drops that redirect.
+func (p *PasteRepository) RenameSlug(ctx context.Context, pasteID uuid.UUID, slug string) error {
+	ctx, span := tracing.Start(ctx, "PasteRepository.RenameSlug")
+	defer span.End()
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var oldSlug string
+	if err := tx.QueryRow(ctx, `SELECT slug FROM pastes WHERE id = $1 FOR UPDATE`, pasteID).Scan(&oldSlug); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrPasteNotFound
+		}
+		return fmt.Errorf("failed to lock paste: %w", err)
+	}
+	if oldSlug == slug {
+		return nil
+	}
+	// The old slug is locked too, so nobody claims it before the redirect
+	// for it exists
+	if err := p.lockSlugs(ctx, tx, oldSlug, slug); err != nil {
+		return err
+	}
+	if err := checkSlugFree(ctx, tx, slug, pasteID); err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `UPDATE pastes SET slug = $2, updated_at = NOW() WHERE id = $1`, pasteID, slug)
+	if err != nil {
+		if isSlugConflict(err) {
+			return ErrSlugTaken
+		}
+		return fmt.Errorf("failed to rename paste slug: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO paste_slug_redirects (slug, paste_id) VALUES ($1, $2)`, oldSlug, pasteID); err != nil {
+		return fmt.Errorf("failed to keep redirect: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ResolveSlugRedirect returns the current slug of the paste an old slug
+// redirects to, or ErrPasteNotFound.
+func (p *PasteRepository) ResolveSlugRedirect(ctx context.Context, slug string) (string, error) {
+	ctx, span := tracing.Start(ctx, "PasteRepository.ResolveSlugRedirect")
+	defer span.End()
+	query := `SELECT p.slug FROM paste_slug_redirects r JOIN pastes p ON p.id = r.paste_id WHERE r.slug = $1`
+	var current string
+	if err := p.db.QueryRow(ctx, query, slug).Scan(&current); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrPasteNotFound
+		}
+		return "", fmt.Errorf("failed to resolve slug redirect: %w", err)
+	}
+	return current, nil
+}
+
+// lockSlugs serialises writers of the given slugs until tx ends. Slugs are
+// locked in order so two renames swapping slugs cannot deadlock.
+func (p *PasteRepository) lockSlugs(ctx context.Context, tx pgx.Tx, slugs ...string) error {
+	slices.Sort(slugs)
+	for _, slug := range slugs {
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('paste_slug:' || $1))`, slug); err != nil {
+			return fmt.Errorf("failed to lock slug: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkSlugFree reports ErrSlugTaken when slug redirects to another paste.
+// A redirect to pasteID itself is dropped, as the paste is taking it back.
+// Slugs in use as current slugs are caught by the unique index instead.
+func checkSlugFree(ctx context.Context, tx pgx.Tx, slug string, pasteID uuid.UUID) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM paste_slug_redirects WHERE slug = $1 AND paste_id = $2`, slug, pasteID); err != nil {
+		return fmt.Errorf("failed to drop redirect: %w", err)
+	}
+	var taken bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM paste_slug_redirects WHERE slug = $1)`, slug).Scan(&taken); err != nil {
+		return fmt.Errorf("failed to check slug: %w", err)
+	}
+	if taken {
+		return ErrSlugTaken
+	}
+	return nil
+}
+
+func isSlugConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_pastes_slug"
+}
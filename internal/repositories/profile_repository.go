@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"pastebin/internal/models"
+	"pastebin/internal/tracing"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
@@ -23,7 +24,9 @@ func NewProfileRepository(db *pgxpool.Pool) *ProfileRepository {
 }
 
 func (p *ProfileRepository) GetProfile(ctx context.Context, userID uuid.UUID) (*models.User, error) {
-	query := `SELECT * FROM users WHERE id = $1`
+	ctx, span := tracing.Start(ctx, "ProfileRepository.GetProfile")
+	defer span.End()
+	query := `SELECT id, name, email, avatar, password_hash FROM users WHERE id = $1`
 	row, err := p.db.Query(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get profile: %w", err)
@@ -40,6 +43,8 @@ func (p *ProfileRepository) GetProfile(ctx context.Context, userID uuid.UUID) (*
 }
 
 func (p *ProfileRepository) UpdateProfile(ctx context.Context, userID uuid.UUID, patch *models.PatchProfile) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "ProfileRepository.UpdateProfile")
+	defer span.End()
 	// Build dynamic update query based on provided fields
 	updateBuilder := sq.Update("users").Where(sq.Eq{"id": userID}).PlaceholderFormat(sq.Dollar)
 
@@ -82,3 +87,17 @@ func (p *ProfileRepository) UpdateProfile(ctx context.Context, userID uuid.UUID,
 
 	return p.GetProfile(ctx, userID)
 }
+
+// GetStorageUsage returns the bytes the user currently stores and their quota
+// override, which is nil when the default applies.
+func (p *ProfileRepository) GetStorageUsage(ctx context.Context, userID uuid.UUID) (int64, *int64, error) {
+	ctx, span := tracing.Start(ctx, "ProfileRepository.GetStorageUsage")
+	defer span.End()
+	query := `SELECT storage_used_bytes, storage_quota_bytes FROM users WHERE id = $1`
+	var used int64
+	var quota *int64
+	if err := p.db.QueryRow(ctx, query, userID).Scan(&used, &quota); err != nil {
+		return 0, nil, fmt.Errorf("failed to get storage usage: %w", err)
+	}
+	return used, quota, nil
+}
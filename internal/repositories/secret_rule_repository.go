@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"pastebin/internal/secretscan"
+	"pastebin/internal/tracing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SecretRuleRepository stores the secret scanning rules admins added or
+// changed. Built-in rules are only stored once an admin overrides them.
+type SecretRuleRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSecretRuleRepository(db *pgxpool.Pool) *SecretRuleRepository {
+	return &SecretRuleRepository{
+		db: db,
+	}
+}
+
+// ListRules returns the stored rules ordered by ID.
+func (s *SecretRuleRepository) ListRules(ctx context.Context) ([]secretscan.Rule, error) {
+	ctx, span := tracing.Start(ctx, "SecretRuleRepository.ListRules")
+	defer span.End()
+	query := `SELECT id, description, pattern, min_entropy, action, enabled FROM secret_rules ORDER BY id`
+	rows, err := s.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query secret rules: %w", err)
+	}
+	defer rows.Close()
+	var rules []secretscan.Rule
+	for rows.Next() {
+		var r secretscan.Rule
+		var action string
+		if err := rows.Scan(&r.ID, &r.Description, &r.Pattern, &r.MinEntropy, &action, &r.Enabled); err != nil {
+			return nil, fmt.Errorf("failed to scan secret rule: %w", err)
+		}
+		r.Action = secretscan.Action(action)
+		rules = append(rules, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read secret rules: %w", err)
+	}
+	return rules, nil
+}
+
+// SaveRule creates or replaces the stored rule with rule.ID.
+func (s *SecretRuleRepository) SaveRule(ctx context.Context, rule *secretscan.Rule) error {
+	ctx, span := tracing.Start(ctx, "SecretRuleRepository.SaveRule")
+	defer span.End()
+	query := `INSERT INTO secret_rules (id, description, pattern, min_entropy, action, enabled, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (id) DO UPDATE SET
+			description = EXCLUDED.description,
+			pattern = EXCLUDED.pattern,
+			min_entropy = EXCLUDED.min_entropy,
+			action = EXCLUDED.action,
+			enabled = EXCLUDED.enabled,
+			updated_at = NOW()`
+	if _, err := s.db.Exec(ctx, query, rule.ID, rule.Description, rule.Pattern, rule.MinEntropy, string(rule.Action), rule.Enabled); err != nil {
+		return fmt.Errorf("failed to save secret rule: %w", err)
+	}
+	return nil
+}
+
+// DeleteRule removes the stored rule with id. It reports whether there was one.
+func (s *SecretRuleRepository) DeleteRule(ctx context.Context, id string) (bool, error) {
+	ctx, span := tracing.Start(ctx, "SecretRuleRepository.DeleteRule")
+	defer span.End()
+	cmdTag, err := s.db.Exec(ctx, `DELETE FROM secret_rules WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete secret rule: %w", err)
+	}
+	return cmdTag.RowsAffected() > 0, nil
+}
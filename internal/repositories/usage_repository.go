@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"pastebin/internal/tracing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type UsageRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewUsageRepository(db *pgxpool.Pool) *UsageRepository {
+	return &UsageRepository{
+		db: db,
+	}
+}
+
+// ConsumeDailyQuota records one paste of size bytes against the user's usage for
+// the current UTC day, but only if that keeps the day within maxPastes and
+// maxBytes (zero means unlimited). It reports whether the usage was recorded.
+func (u *UsageRepository) ConsumeDailyQuota(ctx context.Context, userID uuid.UUID, size int64, maxPastes int, maxBytes int64) (bool, error) {
+	ctx, span := tracing.Start(ctx, "UsageRepository.ConsumeDailyQuota")
+	defer span.End()
+	if maxBytes > 0 && size > maxBytes {
+		return false, nil
+	}
+	query := `INSERT INTO user_daily_usage AS u (user_id, day, pastes, bytes)
+		VALUES ($1, (NOW() AT TIME ZONE 'UTC')::date, 1, $2)
+		ON CONFLICT (user_id, day) DO UPDATE SET
+			pastes = u.pastes + 1,
+			bytes = u.bytes + EXCLUDED.bytes
+		WHERE ($3 = 0 OR u.pastes + 1 <= $3) AND ($4 = 0 OR u.bytes + EXCLUDED.bytes <= $4)
+		RETURNING pastes`
+	var pastes int
+	err := u.db.QueryRow(ctx, query, userID, size, maxPastes, maxBytes).Scan(&pastes)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to consume daily quota: %w", err)
+	}
+	return true, nil
+}
+
+// RefundDailyQuota gives back usage recorded by ConsumeDailyQuota when the paste
+// could not be created after all.
+func (u *UsageRepository) RefundDailyQuota(ctx context.Context, userID uuid.UUID, size int64) error {
+	ctx, span := tracing.Start(ctx, "UsageRepository.RefundDailyQuota")
+	defer span.End()
+	query := `UPDATE user_daily_usage
+		SET pastes = GREATEST(pastes - 1, 0), bytes = GREATEST(bytes - $2, 0)
+		WHERE user_id = $1 AND day = (NOW() AT TIME ZONE 'UTC')::date`
+	if _, err := u.db.Exec(ctx, query, userID, size); err != nil {
+		return fmt.Errorf("failed to refund daily quota: %w", err)
+	}
+	return nil
+}
@@ -2,8 +2,10 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"pastebin/internal/models"
+	"pastebin/internal/tracing"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -20,6 +22,8 @@ func NewUserRepository(db *pgxpool.Pool) *UserRepository {
 	}
 }
 func (u *UserRepository) GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserRepository.GetUserByID")
+	defer span.End()
 	query := `SELECT id, name, email, password_hash FROM users WHERE id=$1`
 	rows, err := u.db.Query(ctx, query, userID)
 	if err != nil {
@@ -33,6 +37,8 @@ func (u *UserRepository) GetUserByID(ctx context.Context, userID uuid.UUID) (*mo
 	return &user, nil
 }
 func (u *UserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserRepository.GetUserByEmail")
+	defer span.End()
 	query := `SELECT id, name, email, password_hash FROM users WHERE email=$1`
 	rows, err := u.db.Query(ctx, query, email)
 	if err != nil {
@@ -50,6 +56,8 @@ func (u *UserRepository) GetUserByEmail(ctx context.Context, email string) (*mod
 }
 
 func (u *UserRepository) ExistsUser(ctx context.Context, email string) (bool, error) {
+	ctx, span := tracing.Start(ctx, "UserRepository.ExistsUser")
+	defer span.End()
 	_, err := u.GetUserByEmail(ctx, email)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -61,6 +69,8 @@ func (u *UserRepository) ExistsUser(ctx context.Context, email string) (bool, er
 }
 
 func (u *UserRepository) CreateUser(ctx context.Context, user *models.User) error {
+	ctx, span := tracing.Start(ctx, "UserRepository.CreateUser")
+	defer span.End()
 	query := `INSERT INTO users (id, name, email, password_hash) VALUES ($1, $2, $3, $4)`
 	_, err := u.db.Exec(ctx, query, user.ID, user.Name, user.Email, user.PasswordHash)
 	if err != nil {
@@ -71,6 +81,8 @@ func (u *UserRepository) CreateUser(ctx context.Context, user *models.User) erro
 
 
 func(u*UserRepository)UpdateUser(ctx context.Context,user *models.User)error{
+	ctx, span := tracing.Start(ctx, "UserRepository.UpdateUser")
+	defer span.End()
 	query:=`UPDATE users SET name=$2,email=$3,password_hash=$4 WHERE id=$1`
 	_,err:=u.db.Exec(ctx,query,user.ID,user.Name,user.Email,user.PasswordHash)
 	if err!=nil{
@@ -79,4 +91,33 @@ func(u*UserRepository)UpdateUser(ctx context.Context,user *models.User)error{
 	return nil
 }
 
+// IsAdmin reports whether the user may use the admin endpoints.
+func (u *UserRepository) IsAdmin(ctx context.Context, userID uuid.UUID) (bool, error) {
+	ctx, span := tracing.Start(ctx, "UserRepository.IsAdmin")
+	defer span.End()
+	var isAdmin bool
+	err := u.db.QueryRow(ctx, `SELECT is_admin FROM users WHERE id = $1`, userID).Scan(&isAdmin)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check admin: %w", err)
+	}
+	return isAdmin, nil
+}
 
+// SetStorageQuota overrides the user's storage quota; nil restores the default.
+// It returns the user's current usage, or pgx.ErrNoRows if the user does not exist.
+func (u *UserRepository) SetStorageQuota(ctx context.Context, userID uuid.UUID, quota *int64) (int64, error) {
+	ctx, span := tracing.Start(ctx, "UserRepository.SetStorageQuota")
+	defer span.End()
+	query := `UPDATE users SET storage_quota_bytes = $2 WHERE id = $1 RETURNING storage_used_bytes`
+	var used int64
+	if err := u.db.QueryRow(ctx, query, userID, quota).Scan(&used); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, pgx.ErrNoRows
+		}
+		return 0, fmt.Errorf("failed to set storage quota: %w", err)
+	}
+	return used, nil
+}
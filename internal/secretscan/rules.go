@@ -0,0 +1,140 @@
+package secretscan
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Action is what happens to a public paste a rule finds a secret in.
+type Action string
+
+const (
+	// ActionWarn lists the findings in the response and stores the paste.
+	ActionWarn Action = "warn"
+	// ActionPrivate stores the paste but makes it private.
+	ActionPrivate Action = "private"
+	// ActionBlock rejects the paste.
+	ActionBlock Action = "block"
+)
+
+// ParseAction parses a rule or policy action.
+func ParseAction(s string) (Action, error) {
+	switch a := Action(strings.ToLower(strings.TrimSpace(s))); a {
+	case ActionWarn, ActionPrivate, ActionBlock:
+		return a, nil
+	}
+	return "", fmt.Errorf("action must be warn, private or block, got %q", s)
+}
+
+// severity orders actions so the strictest finding decides.
+func (a Action) severity() int {
+	switch a {
+	case ActionWarn:
+		return 1
+	case ActionPrivate:
+		return 2
+	case ActionBlock:
+		return 3
+	}
+	return 0
+}
+
+// Rule finds one kind of secret. When Pattern has a group named "secret",
+// only that group is the secret; otherwise the whole match is.
+type Rule struct {
+	ID          string `json:"id" example:"aws-access-key-id"`
+	Description string `json:"description" example:"AWS access key ID"`
+	Pattern     string `json:"pattern"`
+	// MinEntropy is the Shannon entropy in bits per character a secret needs
+	// to count, which keeps placeholders such as "changeme" from matching.
+	MinEntropy float64 `json:"min_entropy,omitempty" example:"3.5"`
+	// Action overrides the configured policy for this rule when set.
+	Action  Action `json:"action,omitempty"`
+	Enabled bool   `json:"enabled"`
+	// Builtin rules ship with the service; admins can change or disable them
+	// but deleting one only resets it.
+	Builtin bool `json:"builtin"`
+
+	re     *regexp.Regexp
+	secret int
+}
+
+// Compile checks the rule and prepares its pattern.
+func (r *Rule) Compile() error {
+	if r.ID == "" {
+		return fmt.Errorf("rule id is required")
+	}
+	if r.Action != "" {
+		if _, err := ParseAction(string(r.Action)); err != nil {
+			return fmt.Errorf("rule %s: %w", r.ID, err)
+		}
+	}
+	if r.MinEntropy < 0 {
+		return fmt.Errorf("rule %s: min_entropy must not be negative", r.ID)
+	}
+	re, err := regexp.Compile(r.Pattern)
+	if err != nil {
+		return fmt.Errorf("rule %s: invalid pattern: %w", r.ID, err)
+	}
+	if re.MatchString("") {
+		return fmt.Errorf("rule %s: pattern must not match empty text", r.ID)
+	}
+	r.re = re
+	r.secret = re.SubexpIndex("secret")
+	return nil
+}
+
+// Builtin returns the rules that ship with the service, covering common
+// cloud, VCS and chat credentials, private keys and secret-looking
+// assignments.
+func Builtin() []Rule {
+	rules := []Rule{
+		{ID: "aws-access-key-id", Description: "AWS access key ID", Pattern: `\b(?:AKIA|ASIA|AGPA|AIDA|AROA)[0-9A-Z]{16}\b`},
+		{ID: "aws-secret-access-key", Description: "AWS secret access key", Pattern: `(?i)aws.{0,20}?(?:secret|private).{0,20}?['"]?\s*[:=]\s*['"]?(?P<secret>[A-Za-z0-9/+=]{40})\b`, MinEntropy: 4},
+		{ID: "github-token", Description: "GitHub token", Pattern: `\bgh[pousr]_[A-Za-z0-9]{36,255}\b`},
+		{ID: "github-fine-grained-token", Description: "GitHub fine-grained personal access token", Pattern: `\bgithub_pat_[A-Za-z0-9_]{82}\b`},
+		{ID: "gitlab-token", Description: "GitLab personal access token", Pattern: `\bglpat-[A-Za-z0-9_-]{20}\b`},
+		{ID: "slack-token", Description: "Slack token", Pattern: `\bxox[abposr]-[0-9A-Za-z-]{10,72}\b`},
+		{ID: "slack-webhook", Description: "Slack incoming webhook URL", Pattern: `https://hooks\.slack\.com/services/T[A-Z0-9]+/B[A-Z0-9]+/[A-Za-z0-9]+`},
+		{ID: "stripe-secret-key", Description: "Stripe secret key", Pattern: `\b[rs]k_live_[0-9A-Za-z]{24,99}\b`},
+		{ID: "google-api-key", Description: "Google API key", Pattern: `\bAIza[0-9A-Za-z_-]{35}\b`},
+		{ID: "private-key", Description: "Private key", Pattern: `-----BEGIN (?:RSA |DSA |EC |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY(?: BLOCK)?-----`},
+		{ID: "generic-secret", Description: "Secret-looking value assigned to a password, secret, token or API key", Pattern: `(?i)\b(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|client[_-]?secret)\b['"]?\s*[:=]\s*['"]?(?P<secret>[^\s'"]{12,})`, MinEntropy: 3.5},
+	}
+	for i := range rules {
+		rules[i].Enabled = true
+		rules[i].Builtin = true
+	}
+	return rules
+}
+
+// Merge applies stored rules over the built-in ones. A stored rule with a
+// built-in ID overrides it, keeping the built-in pattern and description
+// where it leaves them empty; any other stored rule is added.
+func Merge(builtin, stored []Rule) []Rule {
+	merged := make([]Rule, len(builtin))
+	copy(merged, builtin)
+	index := make(map[string]int, len(merged))
+	for i, r := range merged {
+		index[r.ID] = i
+	}
+	for _, r := range stored {
+		i, ok := index[r.ID]
+		if !ok {
+			r.Builtin = false
+			merged = append(merged, r)
+			continue
+		}
+		base := merged[i]
+		if r.Pattern == "" {
+			r.Pattern = base.Pattern
+		}
+		if r.Description == "" {
+			r.Description = base.Description
+		}
+		r.Builtin = true
+		merged[i] = r
+	}
+	return merged
+}
@@ -0,0 +1,199 @@
+// Package secretscan finds credentials such as cloud keys, access tokens and
+// private keys in paste content, using regular expressions and, for
+// free-form values, a minimum Shannon entropy.
+package secretscan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// maxFindings bounds the findings reported for one paste.
+const maxFindings = 100
+
+// Finding is one secret found in content. Lines and columns are 1-based and
+// columns count characters.
+type Finding struct {
+	RuleID      string `json:"rule_id" example:"aws-access-key-id"`
+	Description string `json:"description" example:"AWS access key ID"`
+	Line        int    `json:"line" example:"12"`
+	Column      int    `json:"column" example:"17"`
+	// Match is the secret with all but its first characters masked.
+	Match string `json:"match" example:"AKIA****************"`
+	// Fingerprint identifies this secret under this rule. Listing it in a
+	// paste's secret_allowlist accepts it as a false positive.
+	Fingerprint string `json:"fingerprint" example:"3f1c9a7e52d04b86"`
+	Action      Action `json:"action"`
+}
+
+// Result is what a scan found and the strictest action the findings call for,
+// or "" when nothing was found.
+type Result struct {
+	Findings []Finding
+	Action   Action
+}
+
+// Scanner scans content with a set of rules that can be replaced while it is
+// in use.
+type Scanner struct {
+	policy Action
+	mu     sync.RWMutex
+	rules  []Rule
+}
+
+// New returns a Scanner using the built-in rules. policy is the action of
+// rules that do not set their own.
+func New(policy Action) *Scanner {
+	s := &Scanner{policy: policy}
+	if err := s.SetRules(Builtin()); err != nil {
+		panic("secretscan: " + err.Error())
+	}
+	return s
+}
+
+// SetRules replaces the rules, compiling them first. On error the current
+// rules are kept.
+func (s *Scanner) SetRules(rules []Rule) error {
+	compiled := make([]Rule, 0, len(rules))
+	for _, r := range rules {
+		if !r.Enabled {
+			continue
+		}
+		if err := r.Compile(); err != nil {
+			return err
+		}
+		compiled = append(compiled, r)
+	}
+	s.mu.Lock()
+	s.rules = compiled
+	s.mu.Unlock()
+	return nil
+}
+
+// Scan returns the secrets in content, skipping those whose rule ID or
+// fingerprint is in allowlist.
+func (s *Scanner) Scan(content string, allowlist []string) Result {
+	s.mu.RLock()
+	rules := s.rules
+	s.mu.RUnlock()
+
+	var result Result
+	lines := newLineIndex(content)
+	// Rules run in order, so a secret a specific rule found is not reported
+	// again by a generic one
+	var found [][2]int
+	overlaps := func(start, end int) bool {
+		for _, span := range found {
+			if start < span[1] && span[0] < end {
+				return true
+			}
+		}
+		return false
+	}
+scan:
+	for _, r := range rules {
+		if slices.Contains(allowlist, r.ID) {
+			continue
+		}
+		for _, m := range r.re.FindAllStringSubmatchIndex(content, -1) {
+			start, end := m[0], m[1]
+			if r.secret > 0 && m[2*r.secret] >= 0 {
+				start, end = m[2*r.secret], m[2*r.secret+1]
+			}
+			secret := content[start:end]
+			if overlaps(start, end) || (r.MinEntropy > 0 && entropy(secret) < r.MinEntropy) {
+				continue
+			}
+			fingerprint := fingerprint(r.ID, secret)
+			if slices.Contains(allowlist, fingerprint) {
+				continue
+			}
+			action := r.Action
+			if action == "" {
+				action = s.policy
+			}
+			found = append(found, [2]int{start, end})
+			line, column := lines.position(start)
+			result.Findings = append(result.Findings, Finding{
+				RuleID:      r.ID,
+				Description: r.Description,
+				Line:        line,
+				Column:      column,
+				Match:       mask(secret),
+				Fingerprint: fingerprint,
+				Action:      action,
+			})
+			if action.severity() > result.Action.severity() {
+				result.Action = action
+			}
+			if len(result.Findings) == maxFindings {
+				break scan
+			}
+		}
+	}
+	slices.SortStableFunc(result.Findings, func(a, b Finding) int {
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		return a.Column - b.Column
+	})
+	return result
+}
+
+// entropy returns the Shannon entropy of s in bits per character.
+func entropy(s string) float64 {
+	counts := map[rune]int{}
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+func fingerprint(ruleID, secret string) string {
+	sum := sha256.Sum256([]byte(ruleID + "\x00" + secret))
+	return hex.EncodeToString(sum[:8])
+}
+
+// mask keeps enough of a secret to recognise it and hides the rest.
+func mask(secret string) string {
+	n := utf8.RuneCountInString(secret)
+	keep := min(4, n/4)
+	prefix := string([]rune(secret)[:keep])
+	return prefix + strings.Repeat("*", min(n-keep, 32))
+}
+
+// lineIndex maps byte offsets to line and column.
+type lineIndex struct {
+	content string
+	starts  []int
+}
+
+func newLineIndex(content string) lineIndex {
+	starts := []int{0}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return lineIndex{content: content, starts: starts}
+}
+
+func (l lineIndex) position(offset int) (line, column int) {
+	i, found := slices.BinarySearch(l.starts, offset)
+	if !found {
+		i--
+	}
+	return i + 1, utf8.RuneCountInString(l.content[l.starts[i]:offset]) + 1
+}
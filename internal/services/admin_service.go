@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"pastebin/internal/logging"
+	"pastebin/internal/models"
+	"pastebin/internal/repositories"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+)
+
+// ErrUserNotFound is returned when an admin action targets a user that does not exist.
+var ErrUserNotFound = errors.New("user not found")
+
+type AdminService struct {
+	userRepo     *repositories.UserRepository
+	storageQuota int64
+	logger       zerolog.Logger
+}
+
+func NewAdminService(userRepo *repositories.UserRepository, storageQuota int64, logger zerolog.Logger) *AdminService {
+	return &AdminService{
+		userRepo:     userRepo,
+		storageQuota: storageQuota,
+		logger:       logger,
+	}
+}
+
+// IsAdmin reports whether userID has admin rights. It backs auth.RequireAdmin.
+func (a *AdminService) IsAdmin(ctx context.Context, userID uuid.UUID) (bool, error) {
+	isAdmin, err := a.userRepo.IsAdmin(ctx, userID)
+	if err != nil {
+		logging.FromContext(ctx, a.logger).Error().Err(err).Msg("failed to check admin")
+		return false, fmt.Errorf("unable to check admin: %w", err)
+	}
+	return isAdmin, nil
+}
+
+// SetStorageQuota overrides a user's storage quota, or restores the default
+// when input.QuotaBytes is nil. Lowering a quota below current usage is
+// allowed; the user just cannot grow their storage until they free space.
+func (a *AdminService) SetStorageQuota(ctx context.Context, userID uuid.UUID, input *models.StorageQuotaInput) (*models.StorageUsage, error) {
+	used, err := a.userRepo.SetStorageQuota(ctx, userID, input.QuotaBytes)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		logging.FromContext(ctx, a.logger).Error().Err(err).Msg("failed to set storage quota")
+		return nil, fmt.Errorf("unable to set storage quota: %w", err)
+	}
+	usage := storageUsage(used, input.QuotaBytes, a.storageQuota)
+	return &usage, nil
+}
@@ -3,8 +3,12 @@ package services
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"pastebin/internal/logging"
 	"pastebin/internal/models"
+	"pastebin/internal/origin"
 	"pastebin/internal/repositories"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
@@ -22,22 +26,19 @@ func NewAnalyticsService(analyticsRepo *repositories.AnalyticsRepository, logger
 	}
 }
 
-func (s *AnalyticsService) CreateAnalytics(ctx context.Context, pasteID uuid.UUID, url string) error {
+func (s *AnalyticsService) CreateAnalytics(ctx context.Context, pasteID uuid.UUID) error {
 	if pasteID == uuid.Nil {
 		return fmt.Errorf("unable to create analytics for nil pasteID")
 	}
-	if url == "" {
-		return fmt.Errorf("unable to create analytics for empty url")
-	}
 	analytics, err := s.analyticsRepo.GetAnalyticsByPasteID(ctx, pasteID)
 	if err != nil {
-		s.logger.Error().Err(err).Msg("failed to get analytics by pasteID")
+		logging.FromContext(ctx, s.logger).Error().Err(err).Msg("failed to get analytics by pasteID")
 		return fmt.Errorf("failed to get analytics by pasteID: %w", err)
 	}
 	if analytics == nil {
-		err = s.analyticsRepo.CreateAnalytics(ctx, pasteID, url)
+		err = s.analyticsRepo.CreateAnalytics(ctx, pasteID)
 		if err != nil {
-			s.logger.Error().Err(err).Msg("failed to create analytics")
+			logging.FromContext(ctx, s.logger).Error().Err(err).Msg("failed to create analytics")
 			return fmt.Errorf("failed to create analytics: %w", err)
 		}
 		return nil
@@ -49,14 +50,24 @@ func (s *AnalyticsService) GetAnalyticsByPasteID(ctx context.Context, pasteID uu
 	if pasteID == uuid.Nil {
 		return nil, fmt.Errorf("unable to get analytics for nil pasteID %s", pasteID)
 	}
-	return s.analyticsRepo.GetAnalyticsByPasteID(ctx, pasteID)
+	analytics, err := s.analyticsRepo.GetAnalyticsByPasteID(ctx, pasteID)
+	if err != nil || analytics == nil {
+		return analytics, err
+	}
+	analytics.URL = origin.PasteURL(ctx, analytics.Slug)
+	return analytics, nil
 }
 
 func (s *AnalyticsService) GetAnalyticsByID(ctx context.Context, ID uuid.UUID) (*models.Analytics, error) {
 	if ID == uuid.Nil {
 		return nil, fmt.Errorf("unable to get analytics for nil analytics id ")
 	}
-	return s.analyticsRepo.GetAnalyticsByID(ctx, ID)
+	analytics, err := s.analyticsRepo.GetAnalyticsByID(ctx, ID)
+	if err != nil {
+		return nil, err
+	}
+	analytics.URL = origin.PasteURL(ctx, analytics.Slug)
+	return analytics, nil
 
 }
 
@@ -68,18 +79,29 @@ func (s *AnalyticsService) IncrementViews(ctx context.Context, pasteID uuid.UUID
 	return s.analyticsRepo.IncrementViews(ctx, pasteID)
 }
 
-func (s *AnalyticsService) GetAnalyticsByURL(ctx context.Context, url string) (*models.Analytics, error) {
-	if url == "" {
-		return nil, fmt.Errorf("unable to get analytics for empty url %s ", url)
+// GetAnalyticsByURL looks up analytics by a paste URL. Only the slug is
+// used, so URLs on any origin the paste was shared under match.
+func (s *AnalyticsService) GetAnalyticsByURL(ctx context.Context, pasteURL string) (*models.Analytics, error) {
+	if pasteURL == "" {
+		return nil, fmt.Errorf("unable to get analytics for empty url %s ", pasteURL)
+	}
+	u, err := url.Parse(pasteURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid paste url %s: %w", pasteURL, err)
 	}
-	analytics, err := s.analyticsRepo.GetAnalyticsByURL(ctx, url)
+	_, slug, ok := strings.Cut(u.Path, "/p/")
+	if !ok || slug == "" {
+		return nil, fmt.Errorf("url %s is not a paste url", pasteURL)
+	}
+	analytics, err := s.analyticsRepo.GetAnalyticsBySlug(ctx, slug)
 	if err != nil {
-		s.logger.Error().Err(err).Msg("failed to get analytics by url")
+		logging.FromContext(ctx, s.logger).Error().Err(err).Msg("failed to get analytics by url")
 		return nil, fmt.Errorf("failed to get analytics by url: %w", err)
 	}
 	if analytics == nil {
-		return nil, fmt.Errorf("analytics not found for url %s", url)
+		return nil, fmt.Errorf("analytics not found for url %s", pasteURL)
 	}
+	analytics.URL = origin.PasteURL(ctx, analytics.Slug)
 	return analytics, nil
 }
 
@@ -93,7 +115,11 @@ func (s *AnalyticsService) GetAllAnalytics(ctx context.Context, order string, li
 	if order == "" {
 		order = "created_at DESC"
 	}
-	return s.analyticsRepo.GetAllAnalytics(ctx, order, limit, offset)
+	analytics, err := s.analyticsRepo.GetAllAnalytics(ctx, order, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return withURLs(ctx, analytics), nil
 }
 
 func (s *AnalyticsService) GetAllAnalyticsByUser(ctx context.Context, userID uuid.UUID, order string, limit, offset int) ([]models.Analytics, error) {
@@ -114,8 +140,31 @@ func (s *AnalyticsService) GetAllAnalyticsByUser(ctx context.Context, userID uui
 	}
 	analytics, err := s.analyticsRepo.GetAllAnalyticsByUser(ctx, userID, order, limit, offset)
 	if err != nil {
-		s.logger.Error().Err(err).Msg("failed to get all analytics by user")
+		logging.FromContext(ctx, s.logger).Error().Err(err).Msg("failed to get all analytics by user")
 		return nil, fmt.Errorf("unable to get all analytics by user: %w", err)
 	}
-	return analytics, nil
+	return withURLs(ctx, analytics), nil
+}
+
+func (s *AnalyticsService) GetSummary(ctx context.Context, userID uuid.UUID) (*models.AnalyticsSummary, error) {
+	if userID == uuid.Nil {
+		return nil, fmt.Errorf("unable to get analytics summary for nil userID")
+	}
+	summary, err := s.analyticsRepo.GetSummaryByUser(ctx, userID)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error().Err(err).Msg("failed to get analytics summary")
+		return nil, fmt.Errorf("unable to get analytics summary: %w", err)
+	}
+	for i := range summary.TopPastes {
+		summary.TopPastes[i].URL = origin.PasteURL(ctx, summary.TopPastes[i].Slug)
+	}
+	return summary, nil
+}
+
+// withURLs fills in the paste URLs of analytics rows.
+func withURLs(ctx context.Context, analytics []models.Analytics) []models.Analytics {
+	for i := range analytics {
+		analytics[i].URL = origin.PasteURL(ctx, analytics[i].Slug)
+	}
+	return analytics
 }
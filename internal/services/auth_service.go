@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"pastebin/internal/auth"
+	"pastebin/internal/logging"
+	"pastebin/internal/metrics"
 	"pastebin/internal/models"
 	"pastebin/internal/repositories"
 	"pastebin/pkg/utils"
@@ -18,14 +20,16 @@ type AuthService struct {
 	authRepo   *repositories.AuthRepository
 	jwtManager *auth.JWTManager
 	userRepo   *repositories.UserRepository
+	tokenTTL   time.Duration
 	logger     zerolog.Logger
 }
 
-func NewAuthService(authRepo *repositories.AuthRepository, userRepo *repositories.UserRepository, jwtMgr *auth.JWTManager, logger zerolog.Logger) *AuthService {
+func NewAuthService(authRepo *repositories.AuthRepository, userRepo *repositories.UserRepository, jwtMgr *auth.JWTManager, tokenTTL time.Duration, logger zerolog.Logger) *AuthService {
 	return &AuthService{
 		authRepo:   authRepo,
 		jwtManager: jwtMgr,
 		userRepo:   userRepo,
+		tokenTTL:   tokenTTL,
 		logger:     logger,
 	}
 }
@@ -35,7 +39,7 @@ func (a *AuthService) Register(ctx context.Context, registerInput *models.Regist
 	// only proceed if user does not exists
 	ok, err := a.userRepo.ExistsUser(ctx, registerInput.Email)
 	if err != nil {
-		a.logger.Error().Err(err).Msg("error checking existing user")
+		logging.FromContext(ctx, a.logger).Error().Err(err).Msg("error checking existing user")
 		return fmt.Errorf("error checking existing user: %w", err)
 	}
 
@@ -44,7 +48,7 @@ func (a *AuthService) Register(ctx context.Context, registerInput *models.Regist
 	}
 	regErr := a.authRepo.Register(ctx, registerInput)
 	if regErr != nil {
-		a.logger.Error().Err(regErr).Msg("error registering user")
+		logging.FromContext(ctx, a.logger).Error().Err(regErr).Msg("error registering user")
 		return regErr
 	}
 	return nil
@@ -53,22 +57,24 @@ func (a *AuthService) Register(ctx context.Context, registerInput *models.Regist
 func (a *AuthService) Login(ctx context.Context, loginInput *models.LoginInput) (*models.LoginResponse, error) {
 	user, err := a.userRepo.GetUserByEmail(ctx, loginInput.Email)
 	if errors.Is(err, pgx.ErrNoRows) {
-		a.logger.Error().Msg("user not found")
+		metrics.AuthFailed("unknown_user")
+		logging.FromContext(ctx, a.logger).Error().Msg("user not found")
 		return nil, fmt.Errorf("user not found: %w", err)
 	}
 
 	if err != nil {
-		a.logger.Error().Err(err).Msg("failed to get user by email")
+		logging.FromContext(ctx, a.logger).Error().Err(err).Msg("failed to get user by email")
 		return nil, fmt.Errorf("invalid email or password: %w", err)
 	}
 
 	if !utils.VerifyPassword(user.PasswordHash, loginInput.Password) {
-		a.logger.Error().Msg("invalid email or password")
+		metrics.AuthFailed("bad_password")
+		logging.FromContext(ctx, a.logger).Error().Msg("invalid email or password")
 		return nil, fmt.Errorf("invalid email or password: %w", err)
 	}
-	token, err := a.jwtManager.GenerateToken(user.ID, user.Email, 24*time.Hour)
+	token, err := a.jwtManager.GenerateToken(user.ID, user.Email, a.tokenTTL)
 	if err != nil {
-		a.logger.Error().Err(err).Msg("failed to generate token")
+		logging.FromContext(ctx, a.logger).Error().Err(err).Msg("failed to generate token")
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 	return &models.LoginResponse{
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"pastebin/internal/logging"
+	"pastebin/internal/repositories"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// blobGCBatch bounds how many blobs one collector transaction deletes.
+const blobGCBatch = 500
+
+// BlobGC periodically deletes blobs that no paste references any more.
+type BlobGC struct {
+	blobRepo *repositories.BlobRepository
+	interval time.Duration
+	grace    time.Duration
+	logger   zerolog.Logger
+}
+
+func NewBlobGC(blobRepo *repositories.BlobRepository, interval, grace time.Duration, logger zerolog.Logger) *BlobGC {
+	return &BlobGC{
+		blobRepo: blobRepo,
+		interval: interval,
+		grace:    grace,
+		logger:   logger,
+	}
+}
+
+// Run collects garbage every interval until ctx is cancelled. It is
+// registered as a background worker.
+func (g *BlobGC) Run(ctx context.Context) error {
+	ticker := time.NewTicker(g.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			g.collect(ctx)
+		}
+	}
+}
+
+// collect deletes full batches until a partial one shows nothing is left.
+func (g *BlobGC) collect(ctx context.Context) {
+	total := 0
+	for ctx.Err() == nil {
+		n, err := g.blobRepo.CollectGarbage(ctx, g.grace, blobGCBatch)
+		total += n
+		if err != nil {
+			logging.FromContext(ctx, g.logger).Warn().Err(err).Msg("failed to collect unreferenced blobs")
+			break
+		}
+		if n < blobGCBatch {
+			break
+		}
+	}
+	if total > 0 {
+		logging.FromContext(ctx, g.logger).Info().Int("blobs", total).Msg("deleted unreferenced blobs")
+	}
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"pastebin/internal/auth"
+	"pastebin/internal/logging"
+	"pastebin/internal/models"
+	"pastebin/internal/repositories"
+	"pastebin/internal/tracing"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// maxCommentLength bounds the characters in a comment body.
+const maxCommentLength = 10000
+
+var (
+	// ErrCommentNotFound is returned when no live comment has the requested ID.
+	ErrCommentNotFound = errors.New("comment not found")
+	// ErrInvalidComment is returned for empty or overlong comments, bad line ranges and unknown parents.
+	ErrInvalidComment = errors.New("invalid comment")
+	// ErrCommentsDisabled is returned when commenting on a paste whose owner turned comments off.
+	ErrCommentsDisabled = errors.New("comments are disabled on this paste")
+	// ErrCommentForbidden is returned when someone else than the author edits a comment, or than the author or paste owner deletes one.
+	ErrCommentForbidden = errors.New("not allowed to change this comment")
+)
+
+// CommentService manages threaded comments on pastes. Reading and writing
+// them needs the same access as reading the paste.
+type CommentService struct {
+	commentRepo *repositories.CommentRepository
+	pasteRepo   *repositories.PasteRepository
+	logger      zerolog.Logger
+}
+
+func NewCommentService(commentRepo *repositories.CommentRepository, pasteRepo *repositories.PasteRepository, logger zerolog.Logger) *CommentService {
+	return &CommentService{
+		commentRepo: commentRepo,
+		pasteRepo:   pasteRepo,
+		logger:      logger,
+	}
+}
+
+// pasteBySlug returns the paste shared under slug if the caller may read it,
+// under the rules of PasteService.GetPasteBySlug, except that a paste with a
+// password needs it even when public: comments have no locked form. Pastes
+// the caller may not read are reported missing.
+func (s *CommentService) pasteBySlug(ctx context.Context, slug, password string) (*models.PasteOutput, error) {
+	userID, _ := auth.GetUserIDFromContext(ctx) // Optional auth for public routes
+	paste, err := s.pasteRepo.GetPasteAccessBySlug(ctx, slug, password)
+	if err != nil {
+		if errors.Is(err, repositories.ErrPasteNotFound) {
+			return nil, ErrPasteNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrPasteNotFound, err)
+	}
+	if !visibleTo(paste, userID) {
+		return nil, fmt.Errorf("%w: user does not have permission to view this paste", ErrPasteNotFound)
+	}
+	return paste, nil
+}
+
+// ListComments returns the comment threads on the paste shared under slug,
+// oldest first. Deleted comments only remain where they have replies.
+func (s *CommentService) ListComments(ctx context.Context, slug, password string) ([]models.Comment, error) {
+	ctx, span := tracing.Start(ctx, "CommentService.ListComments")
+	defer span.End()
+	paste, err := s.pasteBySlug(ctx, slug, password)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := s.commentRepo.ListComments(ctx, paste.ID)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error().Err(err).Msg("failed to list comments")
+		return nil, fmt.Errorf("unable to list comments: %w", err)
+	}
+	return threads(comments), nil
+}
+
+// threads nests comments, given oldest first, under their parents.
+func threads(comments []models.Comment) []models.Comment {
+	children := map[uuid.UUID][]models.Comment{}
+	var roots []models.Comment
+	for _, c := range comments {
+		if c.ParentID == nil {
+			roots = append(roots, c)
+		} else {
+			children[*c.ParentID] = append(children[*c.ParentID], c)
+		}
+	}
+	var nest func([]models.Comment) []models.Comment
+	nest = func(level []models.Comment) []models.Comment {
+		kept := make([]models.Comment, 0, len(level))
+		for _, c := range level {
+			c.Replies = nest(children[c.ID])
+			if c.Deleted && len(c.Replies) == 0 {
+				continue
+			}
+			kept = append(kept, c)
+		}
+		if len(kept) == 0 {
+			return nil
+		}
+		return kept
+	}
+	return nest(roots)
+}
+
+// CreateComment adds a comment by the caller to the paste shared under slug.
+func (s *CommentService) CreateComment(ctx context.Context, slug, password string, input *models.CommentInput) (*models.Comment, error) {
+	ctx, span := tracing.Start(ctx, "CommentService.CreateComment")
+	defer span.End()
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error().Err(err).Msg("failed to get userID from context")
+		return nil, fmt.Errorf("unable to get userID from context: %w", err)
+	}
+	if err := checkCommentBody(&input.Body); err != nil {
+		return nil, err
+	}
+	if input.LineStart == nil && input.LineEnd != nil {
+		return nil, fmt.Errorf("%w: line_end needs line_start", ErrInvalidComment)
+	}
+	if input.LineStart != nil {
+		if input.LineEnd == nil {
+			input.LineEnd = input.LineStart
+		}
+		if *input.LineStart < 1 || *input.LineEnd < *input.LineStart {
+			return nil, fmt.Errorf("%w: lines must satisfy 1 <= line_start <= line_end", ErrInvalidComment)
+		}
+	}
+
+	paste, err := s.pasteBySlug(ctx, slug, password)
+	if err != nil {
+		return nil, err
+	}
+	if !paste.CommentsEnabled {
+		return nil, ErrCommentsDisabled
+	}
+	if input.ParentID != nil {
+		parent, err := s.commentRepo.GetComment(ctx, *input.ParentID)
+		if err != nil && !errors.Is(err, repositories.ErrCommentNotFound) {
+			logging.FromContext(ctx, s.logger).Error().Err(err).Msg("failed to get parent comment")
+			return nil, fmt.Errorf("unable to get parent comment: %w", err)
+		}
+		if parent == nil || parent.PasteID != paste.ID {
+			return nil, fmt.Errorf("%w: parent comment not found on this paste", ErrInvalidComment)
+		}
+	}
+
+	comment, err := s.commentRepo.CreateComment(ctx, paste.ID, userID, input)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error().Err(err).Msg("failed to create comment")
+		return nil, fmt.Errorf("unable to create comment: %w", err)
+	}
+	return comment, nil
+}
+
+// UpdateComment changes the body of one of the caller's comments, while the
+// paste still takes comments and the caller may still read it.
+func (s *CommentService) UpdateComment(ctx context.Context, commentID uuid.UUID, password string, input *models.CommentUpdate) (*models.Comment, error) {
+	ctx, span := tracing.Start(ctx, "CommentService.UpdateComment")
+	defer span.End()
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error().Err(err).Msg("failed to get userID from context")
+		return nil, fmt.Errorf("unable to get userID from context: %w", err)
+	}
+	if err := checkCommentBody(&input.Body); err != nil {
+		return nil, err
+	}
+	comment, paste, err := s.commentAndPaste(ctx, commentID, userID, password)
+	if err != nil {
+		return nil, err
+	}
+	if comment.UserID != userID {
+		return nil, ErrCommentForbidden
+	}
+	if !paste.CommentsEnabled {
+		return nil, ErrCommentsDisabled
+	}
+
+	updated, err := s.commentRepo.UpdateComment(ctx, commentID, input.Body)
+	if err != nil {
+		if errors.Is(err, repositories.ErrCommentNotFound) {
+			return nil, ErrCommentNotFound
+		}
+		logging.FromContext(ctx, s.logger).Error().Err(err).Msg("failed to update comment")
+		return nil, fmt.Errorf("unable to update comment: %w", err)
+	}
+	return updated, nil
+}
+
+// DeleteComment deletes a comment of the caller's on a paste the caller may
+// still read, or any comment on one of the caller's pastes. Replies to it are
+// kept.
+func (s *CommentService) DeleteComment(ctx context.Context, commentID uuid.UUID, password string) error {
+	ctx, span := tracing.Start(ctx, "CommentService.DeleteComment")
+	defer span.End()
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error().Err(err).Msg("failed to get userID from context")
+		return fmt.Errorf("unable to get userID from context: %w", err)
+	}
+	comment, paste, err := s.commentAndPaste(ctx, commentID, userID, password)
+	if err != nil {
+		return err
+	}
+	if comment.UserID != userID && paste.UserID != userID {
+		return ErrCommentForbidden
+	}
+
+	if err := s.commentRepo.DeleteComment(ctx, commentID); err != nil {
+		if errors.Is(err, repositories.ErrCommentNotFound) {
+			return ErrCommentNotFound
+		}
+		logging.FromContext(ctx, s.logger).Error().Err(err).Msg("failed to delete comment")
+		return fmt.Errorf("unable to delete comment: %w", err)
+	}
+	return nil
+}
+
+// commentAndPaste returns a live comment and the settings of its paste,
+// checking userID may still read the paste: it may have gone private or
+// gained a password since the comment was written. Owners may always act on
+// comments on their pastes; for anyone else a paste they may not read is
+// reported missing, as on its comment list.
+func (s *CommentService) commentAndPaste(ctx context.Context, commentID, userID uuid.UUID, password string) (*models.Comment, *models.PasteOutput, error) {
+	comment, err := s.commentRepo.GetComment(ctx, commentID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrCommentNotFound) {
+			return nil, nil, ErrCommentNotFound
+		}
+		logging.FromContext(ctx, s.logger).Error().Err(err).Msg("failed to get comment")
+		return nil, nil, fmt.Errorf("unable to get comment: %w", err)
+	}
+	paste, err := s.pasteRepo.GetPasteSettings(ctx, comment.PasteID)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error().Err(err).Msg("failed to get paste of comment")
+		return nil, nil, fmt.Errorf("unable to get paste of comment: %w", err)
+	}
+	if paste.UserID != userID {
+		if err := s.pasteRepo.CheckPasteAccess(ctx, paste, password); err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrPasteNotFound, err)
+		}
+		if !visibleTo(paste, userID) {
+			return nil, nil, fmt.Errorf("%w: user does not have permission to view this paste", ErrPasteNotFound)
+		}
+	}
+	return comment, paste, nil
+}
+
+// checkCommentBody trims body and checks it is neither empty nor too long.
+func checkCommentBody(body *string) error {
+	*body = strings.TrimSpace(*body)
+	if *body == "" {
+		return fmt.Errorf("%w: body is required", ErrInvalidComment)
+	}
+	if utf8.RuneCountInString(*body) > maxCommentLength {
+		return fmt.Errorf("%w: body must be at most %d characters", ErrInvalidComment, maxCommentLength)
+	}
+	return nil
+}
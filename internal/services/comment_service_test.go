@@ -0,0 +1,96 @@
+package services
+
+import (
+	"errors"
+	"pastebin/internal/models"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestThreads(t *testing.T) {
+	// Comments are named by a single letter; parent names "" for a root
+	type comment struct {
+		name, parent string
+		deleted      bool
+	}
+	tests := []struct {
+		name     string
+		comments []comment
+		want     string
+	}{
+		{"no comments", nil, ""},
+		{"flat", []comment{{"a", "", false}, {"b", "", false}}, "a b"},
+		{"nested replies keep their order", []comment{{"a", "", false}, {"b", "a", false}, {"c", "a", false}, {"d", "b", false}}, "a(b(d) c)"},
+		{"deleted leaf is dropped", []comment{{"a", "", false}, {"b", "a", true}, {"c", "", true}}, "a"},
+		{"deleted comment with replies is kept", []comment{{"a", "", true}, {"b", "a", false}}, "a(b)"},
+		{"deleted chain is dropped", []comment{{"a", "", true}, {"b", "a", true}, {"c", "b", true}}, ""},
+		{"deleted chain with a live reply is kept", []comment{{"a", "", true}, {"b", "a", true}, {"c", "b", false}}, "a(b(c))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := map[string]uuid.UUID{}
+			names := map[uuid.UUID]string{}
+			var comments []models.Comment
+			for _, c := range tt.comments {
+				ids[c.name] = uuid.New()
+				names[ids[c.name]] = c.name
+				mc := models.Comment{ID: ids[c.name], Deleted: c.deleted}
+				if c.parent != "" {
+					parent := ids[c.parent]
+					mc.ParentID = &parent
+				}
+				comments = append(comments, mc)
+			}
+			var render func([]models.Comment) string
+			render = func(level []models.Comment) string {
+				parts := make([]string, 0, len(level))
+				for _, c := range level {
+					part := names[c.ID]
+					if len(c.Replies) > 0 {
+						part += "(" + render(c.Replies) + ")"
+					}
+					parts = append(parts, part)
+				}
+				return strings.Join(parts, " ")
+			}
+			if got := render(threads(comments)); got != tt.want {
+				t.Fatalf("threads() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckCommentBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantBody string
+		wantErr  bool
+	}{
+		{"trimmed", "  looks good\n", "looks good", false},
+		{"empty", "", "", true},
+		{"only whitespace", " \n\t ", "", true},
+		{"at the limit", strings.Repeat("é", maxCommentLength), strings.Repeat("é", maxCommentLength), false},
+		{"over the limit", strings.Repeat("é", maxCommentLength+1), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := tt.body
+			err := checkCommentBody(&body)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidComment) {
+					t.Fatalf("checkCommentBody() error = %v, want ErrInvalidComment", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("checkCommentBody() error = %v", err)
+			}
+			if body != tt.wantBody {
+				t.Fatalf("body = %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
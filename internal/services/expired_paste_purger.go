@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"pastebin/internal/logging"
+	"pastebin/internal/repositories"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// expiredPastePurgeInterval is how often expired pastes are deleted.
+	expiredPastePurgeInterval = 5 * time.Minute
+	// expiredPastePurgeBatch bounds how many pastes one purge transaction deletes.
+	expiredPastePurgeBatch = 500
+)
+
+// ExpiredPastePurger periodically deletes expired pastes, giving their bytes
+// back to their owners' storage quota.
+type ExpiredPastePurger struct {
+	pasteRepo *repositories.PasteRepository
+	logger    zerolog.Logger
+}
+
+func NewExpiredPastePurger(pasteRepo *repositories.PasteRepository, logger zerolog.Logger) *ExpiredPastePurger {
+	return &ExpiredPastePurger{
+		pasteRepo: pasteRepo,
+		logger:    logger,
+	}
+}
+
+// Run purges every expiredPastePurgeInterval until ctx is cancelled. It is
+// registered as a background worker.
+func (e *ExpiredPastePurger) Run(ctx context.Context) error {
+	ticker := time.NewTicker(expiredPastePurgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			e.purge(ctx)
+		}
+	}
+}
+
+// purge deletes full batches until a partial one shows nothing is left.
+func (e *ExpiredPastePurger) purge(ctx context.Context) {
+	total := 0
+	for ctx.Err() == nil {
+		n, err := e.pasteRepo.PurgeExpiredPastes(ctx, expiredPastePurgeBatch)
+		total += n
+		if err != nil {
+			logging.FromContext(ctx, e.logger).Warn().Err(err).Msg("failed to purge expired pastes")
+			break
+		}
+		if n < expiredPastePurgeBatch {
+			break
+		}
+	}
+	if total > 0 {
+		logging.FromContext(ctx, e.logger).Info().Int("pastes", total).Msg("purged expired pastes")
+	}
+}
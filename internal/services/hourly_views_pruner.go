@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"pastebin/internal/logging"
+	"pastebin/internal/repositories"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// hourlyViewsRetention is how long hourly view buckets are kept: the
+	// longest window they are summed over, the 30 days of GET /analytics/summary.
+	hourlyViewsRetention = 30 * 24 * time.Hour
+	// hourlyViewsPruneInterval is how often expired buckets are deleted.
+	hourlyViewsPruneInterval = time.Hour
+	// hourlyViewsPruneBatch bounds how many buckets one delete removes.
+	hourlyViewsPruneBatch = 5000
+)
+
+// HourlyViewsPruner periodically deletes hourly view buckets older than any
+// window they are read for, so the table stays proportional to recent traffic.
+type HourlyViewsPruner struct {
+	analyticsRepo *repositories.AnalyticsRepository
+	logger        zerolog.Logger
+}
+
+func NewHourlyViewsPruner(analyticsRepo *repositories.AnalyticsRepository, logger zerolog.Logger) *HourlyViewsPruner {
+	return &HourlyViewsPruner{
+		analyticsRepo: analyticsRepo,
+		logger:        logger,
+	}
+}
+
+// Run prunes every hourlyViewsPruneInterval until ctx is cancelled. It is
+// registered as a background worker.
+func (h *HourlyViewsPruner) Run(ctx context.Context) error {
+	ticker := time.NewTicker(hourlyViewsPruneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			h.prune(ctx)
+		}
+	}
+}
+
+// prune deletes full batches until a partial one shows nothing is left.
+func (h *HourlyViewsPruner) prune(ctx context.Context) {
+	before := time.Now().Add(-hourlyViewsRetention)
+	total := 0
+	for ctx.Err() == nil {
+		n, err := h.analyticsRepo.PruneHourlyViews(ctx, before, hourlyViewsPruneBatch)
+		total += n
+		if err != nil {
+			logging.FromContext(ctx, h.logger).Warn().Err(err).Msg("failed to prune hourly views")
+			break
+		}
+		if n < hourlyViewsPruneBatch {
+			break
+		}
+	}
+	if total > 0 {
+		logging.FromContext(ctx, h.logger).Info().Int("buckets", total).Msg("pruned hourly views")
+	}
+}
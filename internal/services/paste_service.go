@@ -2,75 +2,423 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"pastebin/internal/auth"
+	"pastebin/internal/language"
+	"pastebin/internal/logging"
+	"pastebin/internal/metrics"
 	"pastebin/internal/models"
+	"pastebin/internal/origin"
+	"pastebin/internal/redact"
 	"pastebin/internal/repositories"
+	"pastebin/internal/secretscan"
+	"pastebin/internal/slug"
+	"pastebin/internal/tracing"
+	"pastebin/pkg/clientcrypt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/labstack/gommon/bytes"
 	"github.com/rs/zerolog"
 )
 
+var (
+	// ErrDailyQuotaExceeded is returned when a user has used up their daily paste count or bytes.
+	ErrDailyQuotaExceeded = errors.New("daily paste quota exceeded")
+	// ErrPasteTooLarge is returned when paste content exceeds the configured maximum size.
+	ErrPasteTooLarge = errors.New("paste content is too large")
+	// ErrStorageQuotaExceeded is returned when a paste would take its owner past their storage quota.
+	ErrStorageQuotaExceeded = errors.New("storage quota exceeded, delete some pastes first")
+	// ErrCurrentPasswordRequired is returned when an encrypted paste's password changes without its current password.
+	ErrCurrentPasswordRequired = errors.New("current_password is required to re-encrypt this paste")
+	// ErrInvalidCurrentPassword is returned when the current password given for a paste is wrong.
+	ErrInvalidCurrentPassword = errors.New("invalid current password")
+	// ErrEncryptionRejected is returned for malformed client-encrypted content or metadata.
+	ErrEncryptionRejected = errors.New("paste encryption rejected")
+	// ErrInvalidSlug is returned for custom slugs that are malformed or reserved.
+	ErrInvalidSlug = errors.New("invalid slug")
+	// ErrSlugTaken is returned when a custom slug is used by another paste.
+	ErrSlugTaken = errors.New("slug is already taken")
+	// ErrPasteNotFound is returned when no paste has the requested slug.
+	ErrPasteNotFound = errors.New("paste not found")
+	// ErrUnknownLanguage is returned for languages missing from the registry.
+	ErrUnknownLanguage = errors.New("unknown language")
+	// ErrSecretsDetected is matched by the SecretsError returned when the
+	// secret scanner blocks a paste.
+	ErrSecretsDetected = errors.New("paste contains secrets")
+	// ErrInvalidSecretAllowlist is returned for secret allowlists with too many entries.
+	ErrInvalidSecretAllowlist = errors.New("invalid secret allowlist")
+	// ErrInvalidRedactPattern is returned for redaction patterns that are malformed, too long or too many.
+	ErrInvalidRedactPattern = errors.New("invalid redact pattern")
+	// ErrInvalidTrendingWindow is returned for trending windows other than 24h and 7d.
+	ErrInvalidTrendingWindow = errors.New("window must be 24h or 7d")
+)
+
+// trendingWindows are the periods the trending feed can rank views over.
+var trendingWindows = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+}
+
+// maxSecretAllowlist bounds the entries in a paste's secret allowlist.
+const maxSecretAllowlist = 100
+
+// SecretsError is returned when a paste is rejected for the secrets in it.
+type SecretsError struct {
+	Findings []secretscan.Finding
+}
+
+func (e *SecretsError) Error() string {
+	return fmt.Sprintf("%s: %d found, remove them or add their fingerprints to secret_allowlist", ErrSecretsDetected, len(e.Findings))
+}
+
+func (e *SecretsError) Is(target error) bool {
+	return target == ErrSecretsDetected
+}
+
+// DailyQuota caps what one user may create per UTC day. Zero disables a limit.
+type DailyQuota struct {
+	Pastes int
+	Bytes  int64
+}
+
 type PasteService struct {
-	pasteRepo *repositories.PasteRepository
-	logger    zerolog.Logger
+	pasteRepo  *repositories.PasteRepository
+	usageRepo  *repositories.UsageRepository
+	maxSize    int64
+	dailyQuota DailyQuota
+	slugs      *slug.Generator
+	// secrets scans content for credentials; nil disables scanning.
+	secrets *secretscan.Scanner
+	logger  zerolog.Logger
 }
 
-func NewPasteService(pasteRepo *repositories.PasteRepository, logger zerolog.Logger) *PasteService {
+func NewPasteService(pasteRepo *repositories.PasteRepository, usageRepo *repositories.UsageRepository, maxSize int64, dailyQuota DailyQuota, slugs *slug.Generator, secrets *secretscan.Scanner, logger zerolog.Logger) *PasteService {
 	return &PasteService{
-		pasteRepo: pasteRepo,
-		logger:    logger,
+		pasteRepo:  pasteRepo,
+		usageRepo:  usageRepo,
+		maxSize:    maxSize,
+		dailyQuota: dailyQuota,
+		slugs:      slugs,
+		secrets:    secrets,
+		logger:     logger,
 	}
 }
+
+// checkSize rejects content over the configured maximum paste size.
+func (p *PasteService) checkSize(content string) error {
+	if p.maxSize > 0 && int64(len(content)) > p.maxSize {
+		return fmt.Errorf("%w: the limit is %s", ErrPasteTooLarge, bytes.Format(p.maxSize))
+	}
+	return nil
+}
+
+// checkClientEncryption validates client-encrypted content. The server never
+// has the key, so only the shape of the ciphertext and metadata is checked.
+func checkClientEncryption(content string, encryption *models.ClientEncryption) error {
+	meta := clientcrypt.Metadata{Algorithm: encryption.Algorithm, Nonce: encryption.Nonce}
+	if err := clientcrypt.Validate(content, meta); err != nil {
+		return fmt.Errorf("%w: %v", ErrEncryptionRejected, err)
+	}
+	return nil
+}
+
+// checkSlug validates a custom slug requested by a user.
+func (p *PasteService) checkSlug(requested string) error {
+	if err := p.slugs.Check(requested); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSlug, err)
+	}
+	return nil
+}
+
+// resolveLanguage normalizes a requested language, or detects one from the
+// content when none was given, taking the title as a file name hint.
+func resolveLanguage(requested, content, title string) (string, error) {
+	if strings.TrimSpace(requested) == "" {
+		return language.Detect(content, title), nil
+	}
+	id, ok := language.Normalize(requested)
+	if !ok {
+		return "", fmt.Errorf("%w %q, see GET /languages", ErrUnknownLanguage, requested)
+	}
+	return id, nil
+}
+
+// normalizeSecretAllowlist trims and dedupes allowlist entries.
+func normalizeSecretAllowlist(allowlist []string) ([]string, error) {
+	normalized := make([]string, 0, len(allowlist))
+	for _, entry := range allowlist {
+		entry = strings.TrimSpace(entry)
+		if entry != "" && !slices.Contains(normalized, entry) {
+			normalized = append(normalized, entry)
+		}
+	}
+	if len(normalized) > maxSecretAllowlist {
+		return nil, fmt.Errorf("%w: at most %d entries are allowed", ErrInvalidSecretAllowlist, maxSecretAllowlist)
+	}
+	return normalized, nil
+}
+
+// scanSecrets scans content for secrets and counts what it finds. The policy
+// only applies to pastes that will be public; findings in private pastes are
+// warnings.
+func (p *PasteService) scanSecrets(ctx context.Context, content string, allowlist []string, public bool) ([]secretscan.Finding, secretscan.Action) {
+	if p.secrets == nil {
+		return nil, ""
+	}
+	_, span := tracing.Start(ctx, "PasteService.scanSecrets")
+	defer span.End()
+	result := p.secrets.Scan(content, allowlist)
+	if !public && len(result.Findings) > 0 {
+		for i := range result.Findings {
+			result.Findings[i].Action = secretscan.ActionWarn
+		}
+		result.Action = secretscan.ActionWarn
+	}
+	for _, f := range result.Findings {
+		metrics.SecretFound(f.RuleID, string(f.Action))
+	}
+	return result.Findings, result.Action
+}
+
 func (p *PasteService) CreatePaste(ctx context.Context, createPaste *models.PasteInput) (*models.PasteOutput, error) {
+	ctx, span := tracing.Start(ctx, "PasteService.CreatePaste")
+	defer span.End()
 	userID, err := auth.GetUserIDFromContext(ctx)
 	if err != nil {
-		p.logger.Error().Err(err).Msg("failed to get userID from context")
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to get userID from context")
 		return nil, fmt.Errorf("unable to get userID from context: %w", err)
 	}
+	if err := p.checkSize(createPaste.Content); err != nil {
+		return nil, err
+	}
+	if createPaste.Slug != "" {
+		if err := p.checkSlug(createPaste.Slug); err != nil {
+			return nil, err
+		}
+	}
+	var redactions map[string]int
+	if createPaste.Redact {
+		if createPaste.Encryption != nil {
+			return nil, fmt.Errorf("%w: client-encrypted content cannot be redacted", ErrEncryptionRejected)
+		}
+		redactor, err := redact.New(createPaste.RedactPatterns)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRedactPattern, err)
+		}
+		createPaste.Content, redactions = redactor.Redact(createPaste.Content)
+		// Placeholders can be longer than what they replace
+		if err := p.checkSize(createPaste.Content); err != nil {
+			return nil, err
+		}
+	}
+	if createPaste.Encryption != nil {
+		if err := checkClientEncryption(createPaste.Content, createPaste.Encryption); err != nil {
+			return nil, err
+		}
+		// Ciphertext has no language to highlight
+		createPaste.Language = ""
+	} else {
+		if createPaste.Language, err = resolveLanguage(createPaste.Language, createPaste.Content, createPaste.Title); err != nil {
+			return nil, err
+		}
+	}
+	if createPaste.SecretAllowlist, err = normalizeSecretAllowlist(createPaste.SecretAllowlist); err != nil {
+		return nil, err
+	}
+	var findings []secretscan.Finding
+	// Ciphertext cannot be scanned
+	if createPaste.Encryption == nil {
+		public := createPaste.Password == "" && !createPaste.IsPrivate
+		var action secretscan.Action
+		findings, action = p.scanSecrets(ctx, createPaste.Content, createPaste.SecretAllowlist, public)
+		switch action {
+		case secretscan.ActionBlock:
+			return nil, &SecretsError{Findings: findings}
+		case secretscan.ActionPrivate:
+			createPaste.IsPrivate = true
+		}
+	}
+	size := int64(len(createPaste.Content))
+	ok, err := p.usageRepo.ConsumeDailyQuota(ctx, userID, size, p.dailyQuota.Pastes, p.dailyQuota.Bytes)
+	if err != nil {
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to check daily quota")
+		return nil, fmt.Errorf("unable to check daily quota: %w", err)
+	}
+	if !ok {
+		return nil, ErrDailyQuotaExceeded
+	}
 	paste, err := p.pasteRepo.CreatePaste(ctx, userID, createPaste)
 	if err != nil {
-		p.logger.Error().Err(err).Msg("failed to create paste")
+		if refundErr := p.usageRepo.RefundDailyQuota(ctx, userID, size); refundErr != nil {
+			logging.FromContext(ctx, p.logger).Error().Err(refundErr).Msg("failed to refund daily quota")
+		}
+		if errors.Is(err, repositories.ErrStorageQuotaExceeded) {
+			return nil, ErrStorageQuotaExceeded
+		}
+		if errors.Is(err, repositories.ErrSlugTaken) {
+			return nil, ErrSlugTaken
+		}
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to create paste")
 		return nil, fmt.Errorf("unable to create paste: %w", err)
 	}
+	metrics.PasteCreated(paste.Language)
+	paste.URL = origin.PasteURL(ctx, paste.Slug)
+	paste.SecretFindings = findings
+	paste.Redactions = redactions
 	return paste, nil
 }
 
-func (p *PasteService) UpdatePaste(ctx context.Context, pasteID uuid.UUID, patchPaste *models.PatchPaste) error {
+func (p *PasteService) UpdatePaste(ctx context.Context, pasteID uuid.UUID, patchPaste *models.PatchPaste) ([]secretscan.Finding, error) {
+	ctx, span := tracing.Start(ctx, "PasteService.UpdatePaste")
+	defer span.End()
 	userID, err := auth.GetUserIDFromContext(ctx)
 	if err != nil {
-		p.logger.Error().Err(err).Msg("failed to get userID from context")
-		return fmt.Errorf("unable to get userID from context: %w", err)
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to get userID from context")
+		return nil, fmt.Errorf("unable to get userID from context: %w", err)
 	}
 
 	paste, err := p.GetPasteByID(ctx, pasteID, true, userID, "")
 	if err != nil {
-		p.logger.Error().Err(err).Msg("failed to get paste by ID")
-		return fmt.Errorf("unable to find paste with ID: %s ", pasteID)
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to get paste by ID")
+		return nil, fmt.Errorf("unable to find paste with ID: %s ", pasteID)
 	}
 
 	if paste.UserID != userID {
-		return fmt.Errorf("user does not have permission to update this paste")
+		return nil, fmt.Errorf("user does not have permission to update this paste")
+	}
+	if patchPaste.Content != nil {
+		if err := p.checkSize(*patchPaste.Content); err != nil {
+			return nil, err
+		}
+	}
+	// Client-encrypted content is replaced only together with the metadata
+	// it was encrypted with, and a paste cannot switch modes
+	if patchPaste.Encryption != nil || (paste.Encryption != nil && patchPaste.Content != nil) {
+		if paste.Encryption == nil {
+			return nil, fmt.Errorf("%w: the paste is not client-encrypted", ErrEncryptionRejected)
+		}
+		if patchPaste.Content == nil || patchPaste.Encryption == nil {
+			return nil, fmt.Errorf("%w: content and encryption must be updated together", ErrEncryptionRejected)
+		}
+		if err := checkClientEncryption(*patchPaste.Content, patchPaste.Encryption); err != nil {
+			return nil, err
+		}
+	}
+	if paste.Encryption != nil {
+		patchPaste.Language = nil
+	} else if patchPaste.Language != nil || (patchPaste.Content != nil && paste.Language == "") {
+		// An empty language asks for detection, as does new content for a
+		// paste whose language is still unknown
+		requested, content := "", paste.Content
+		if patchPaste.Language != nil {
+			requested = *patchPaste.Language
+		}
+		if patchPaste.Content != nil {
+			content = *patchPaste.Content
+		}
+		id, err := resolveLanguage(requested, content, paste.Title)
+		if err != nil {
+			return nil, err
+		}
+		patchPaste.Language = &id
+	}
+	findings, err := p.scanUpdate(ctx, pasteID, paste, patchPaste)
+	if err != nil {
+		return nil, err
 	}
 	err = p.pasteRepo.UpdatePaste(ctx, pasteID, patchPaste)
 	if err != nil {
-		p.logger.Error().Err(err).Msg("failed to update paste")
-		return fmt.Errorf("unable to update paste: %w", err)
+		if errors.Is(err, repositories.ErrStorageQuotaExceeded) {
+			return nil, ErrStorageQuotaExceeded
+		}
+		if errors.Is(err, repositories.ErrCurrentPasswordRequired) {
+			return nil, ErrCurrentPasswordRequired
+		}
+		if errors.Is(err, repositories.ErrInvalidCurrentPassword) {
+			return nil, ErrInvalidCurrentPassword
+		}
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to update paste")
+		return nil, fmt.Errorf("unable to update paste: %w", err)
 	}
-	return nil
+	return findings, nil
+}
+
+// scanUpdate scans a paste for secrets as it will be after patchPaste, when
+// the content, the allowlist or its visibility change. It may make the paste
+// private, as the policy asks.
+func (p *PasteService) scanUpdate(ctx context.Context, pasteID uuid.UUID, paste *models.PasteOutput, patchPaste *models.PatchPaste) ([]secretscan.Finding, error) {
+	if patchPaste.SecretAllowlist != nil {
+		allowlist, err := normalizeSecretAllowlist(*patchPaste.SecretAllowlist)
+		if err != nil {
+			return nil, err
+		}
+		patchPaste.SecretAllowlist = &allowlist
+	}
+	if p.secrets == nil || paste.Encryption != nil {
+		return nil, nil
+	}
+	content := paste.Content
+	if patchPaste.Content != nil {
+		content = *patchPaste.Content
+	} else if paste.ContentLocked {
+		return nil, nil
+	}
+	hasPassword := paste.PasswordHash != ""
+	isPrivate := paste.IsPrivate
+	if patchPaste.Password != nil {
+		hasPassword = *patchPaste.Password != ""
+		isPrivate = hasPassword
+	}
+	if patchPaste.IsPrivate != nil {
+		isPrivate = *patchPaste.IsPrivate
+	}
+	public := !hasPassword && !isPrivate
+	if patchPaste.Content == nil && patchPaste.SecretAllowlist == nil && !(public && paste.IsPrivate) {
+		return nil, nil
+	}
+
+	var allowlist []string
+	if patchPaste.SecretAllowlist != nil {
+		allowlist = *patchPaste.SecretAllowlist
+	} else {
+		var err error
+		if allowlist, err = p.pasteRepo.GetSecretAllowlist(ctx, pasteID); err != nil {
+			logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to get secret allowlist")
+			return nil, fmt.Errorf("unable to get secret allowlist: %w", err)
+		}
+	}
+	findings, action := p.scanSecrets(ctx, content, allowlist, public)
+	switch action {
+	case secretscan.ActionBlock:
+		return nil, &SecretsError{Findings: findings}
+	case secretscan.ActionPrivate:
+		private := true
+		patchPaste.IsPrivate = &private
+	}
+	return findings, nil
 }
 
 func (p *PasteService) GetPasteByID(ctx context.Context, pasteID uuid.UUID, isAuthenticated bool, userID uuid.UUID, password string) (*models.PasteOutput, error) {
+	ctx, span := tracing.Start(ctx, "PasteService.GetPasteByID")
+	defer span.End()
 
 	paste, err := p.pasteRepo.GetPasteByID(ctx, pasteID, isAuthenticated, userID, password)
 	if err != nil {
-		p.logger.Error().Err(err).Msg("failed to get paste by ID")
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to get paste by ID")
 		return nil, fmt.Errorf("unable to get paste by ID: %w", err)
 	}
+	paste.URL = origin.PasteURL(ctx, paste.Slug)
+	p.addLineage(ctx, paste, userID)
 	return paste, nil
 }
 
 func (p *PasteService) GetAllPastes(ctx context.Context, userID uuid.UUID, limit, offset int) (*models.PaginatedPastesResponse, error) {
+	ctx, span := tracing.Start(ctx, "PasteService.GetAllPastes")
+	defer span.End()
 	// Validate and set defaults
 	if limit <= 0 {
 		limit = 10 // default limit
@@ -84,11 +432,14 @@ func (p *PasteService) GetAllPastes(ctx context.Context, userID uuid.UUID, limit
 
 	pastes, total, err := p.pasteRepo.GetAllPastes(ctx, userID, limit, offset)
 	if err != nil {
-		p.logger.Error().Err(err).Msg("failed to get pastes")
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to get pastes")
 		return nil, fmt.Errorf("unable to get pastes: %w", err)
 	}
 
 	hasMore := offset+limit < total
+	for i := range pastes {
+		pastes[i].URL = origin.PasteURL(ctx, pastes[i].Slug)
+	}
 
 	return &models.PaginatedPastesResponse{
 		Pastes:  pastes,
@@ -100,65 +451,449 @@ func (p *PasteService) GetAllPastes(ctx context.Context, userID uuid.UUID, limit
 }
 
 func (p *PasteService) DeletePasteByID(ctx context.Context, pasteID uuid.UUID) error {
+	ctx, span := tracing.Start(ctx, "PasteService.DeletePasteByID")
+	defer span.End()
 	userID, err := auth.GetUserIDFromContext(ctx)
 	if err != nil {
-		p.logger.Error().Err(err).Msg("failed to get userID from context")
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to get userID from context")
 		return fmt.Errorf("unable to get userID from context : %w", err)
 	}
 	paste, err := p.pasteRepo.GetPasteByID(ctx, pasteID, true, userID, "")
 	if err != nil {
-		p.logger.Error().Err(err).Msg("failed to get paste by ID")
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to get paste by ID")
 		return fmt.Errorf("unable to get paste by ID: %w", err)
 	}
 	if paste.UserID != userID {
-		p.logger.Error().Msg("user does not have permission to delete this paste")
+		logging.FromContext(ctx, p.logger).Error().Msg("user does not have permission to delete this paste")
 		return fmt.Errorf("user does not have permission to delete this paste")
 	}
 
 	err = p.pasteRepo.DeletePasteByID(ctx, pasteID)
 	if err != nil {
-		p.logger.Error().Err(err).Msg("failed to delete paste by ID")
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to delete paste by ID")
 		return fmt.Errorf("unable to delete paste by ID: %w", err)
 	}
 	return nil
 }
 
 func (p *PasteService) FilterPastes(ctx context.Context, filter *models.PasteFilters) (*[]models.PasteOutput, error) {
+	ctx, span := tracing.Start(ctx, "PasteService.FilterPastes")
+	defer span.End()
 	if filter == nil {
-		p.logger.Error().Msg("filter is nil")
+		logging.FromContext(ctx, p.logger).Error().Msg("filter is nil")
 		return nil, fmt.Errorf("filter is nil")
 	}
 
 	userID, err := auth.GetUserIDFromContext(ctx)
 	if err != nil {
-		p.logger.Error().Err(err).Msg("failed to get userID from context")
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to get userID from context")
 		return nil, fmt.Errorf("unable to get userID from context: %w", err)
 	}
 	if userID == uuid.Nil {
-		p.logger.Error().Msg("userID is nil")
+		logging.FromContext(ctx, p.logger).Error().Msg("userID is nil")
 		return nil, fmt.Errorf("userID is nil")
 	}
 
+	for i, name := range filter.Languages {
+		if id, ok := language.Normalize(name); ok {
+			filter.Languages[i] = id
+		}
+	}
 	pastes, err := p.pasteRepo.FilterPastes(ctx, userID, filter)
 	if err != nil {
-		p.logger.Error().Err(err).Msg("failed to filter pastes")
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to filter pastes")
 		return nil, fmt.Errorf("unable to filter pastes: %w", err)
 	}
+	for i := range *pastes {
+		(*pastes)[i].URL = origin.PasteURL(ctx, (*pastes)[i].Slug)
+	}
 	return pastes, nil
 }
 
 func (p *PasteService) GetPasteBySlug(ctx context.Context, slug, password string) (*models.PasteOutput, error) {
+	ctx, span := tracing.Start(ctx, "PasteService.GetPasteBySlug")
+	defer span.End()
 	userID, _ := auth.GetUserIDFromContext(ctx) // Optional auth for public routes
 
 	paste, err := p.pasteRepo.GetPasteBySlug(ctx, slug, password)
 	if err != nil {
-		p.logger.Error().Err(err).Msg("failed to get paste by slug")
+		if errors.Is(err, repositories.ErrPasteNotFound) {
+			return nil, ErrPasteNotFound
+		}
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to get paste by slug")
 		return nil, fmt.Errorf("unable to get paste by slug: %w", err)
 	}
 
-	if paste.IsPrivate && paste.UserID != userID {
-		p.logger.Error().Msg("user does not have permission to view this paste")
+	if !visibleTo(paste, userID) {
+		logging.FromContext(ctx, p.logger).Error().Msg("user does not have permission to view this paste")
 		return nil, fmt.Errorf("user does not have permission to view this paste")
 	}
+	paste.URL = origin.PasteURL(ctx, paste.Slug)
+	p.addLineage(ctx, paste, userID)
 	return paste, nil
 }
+
+// visibleTo reports whether userID may read a paste it has passed the
+// password checks of. Private pastes are only readable by their owner.
+func visibleTo(paste *models.PasteOutput, userID uuid.UUID) bool {
+	return !paste.IsPrivate || paste.UserID == userID
+}
+
+// GetStoredPasteBySlug applies the same access rules as GetPasteBySlug but
+// returns the content as stored, possibly compressed, so /raw can pass it
+// through without decompressing.
+func (p *PasteService) GetStoredPasteBySlug(ctx context.Context, slug, password string) (*models.StoredContent, error) {
+	ctx, span := tracing.Start(ctx, "PasteService.GetStoredPasteBySlug")
+	defer span.End()
+	userID, _ := auth.GetUserIDFromContext(ctx) // Optional auth for public routes
+
+	paste, stored, err := p.pasteRepo.GetStoredPasteBySlug(ctx, slug, password)
+	if err != nil {
+		if errors.Is(err, repositories.ErrPasteNotFound) {
+			return nil, ErrPasteNotFound
+		}
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to get paste by slug")
+		return nil, fmt.Errorf("unable to get paste by slug: %w", err)
+	}
+
+	if !visibleTo(paste, userID) {
+		logging.FromContext(ctx, p.logger).Error().Msg("user does not have permission to view this paste")
+		return nil, fmt.Errorf("user does not have permission to view this paste")
+	}
+	return stored, nil
+}
+
+// RenameSlug gives one of the caller's pastes a new slug. The old slug keeps
+// redirecting to the paste.
+func (p *PasteService) RenameSlug(ctx context.Context, pasteID uuid.UUID, newSlug string) (*models.SlugOutput, error) {
+	ctx, span := tracing.Start(ctx, "PasteService.RenameSlug")
+	defer span.End()
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to get userID from context")
+		return nil, fmt.Errorf("unable to get userID from context: %w", err)
+	}
+	if err := p.checkSlug(newSlug); err != nil {
+		return nil, err
+	}
+
+	paste, err := p.pasteRepo.GetPasteByID(ctx, pasteID, true, userID, "")
+	if err != nil {
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to get paste by ID")
+		return nil, fmt.Errorf("unable to find paste with ID: %s ", pasteID)
+	}
+	if paste.UserID != userID {
+		return nil, fmt.Errorf("user does not have permission to update this paste")
+	}
+
+	if err := p.pasteRepo.RenameSlug(ctx, pasteID, newSlug); err != nil {
+		if errors.Is(err, repositories.ErrSlugTaken) {
+			return nil, ErrSlugTaken
+		}
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to rename paste slug")
+		return nil, fmt.Errorf("unable to rename paste slug: %w", err)
+	}
+	return &models.SlugOutput{Slug: newSlug, URL: origin.PasteURL(ctx, newSlug)}, nil
+}
+
+// ResolveSlugRedirect returns the current slug of a paste that used to be
+// shared under oldSlug, or ErrPasteNotFound.
+func (p *PasteService) ResolveSlugRedirect(ctx context.Context, oldSlug string) (string, error) {
+	ctx, span := tracing.Start(ctx, "PasteService.ResolveSlugRedirect")
+	defer span.End()
+	current, err := p.pasteRepo.ResolveSlugRedirect(ctx, oldSlug)
+	if err != nil {
+		if errors.Is(err, repositories.ErrPasteNotFound) {
+			return "", ErrPasteNotFound
+		}
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to resolve slug redirect")
+		return "", fmt.Errorf("unable to resolve slug redirect: %w", err)
+	}
+	return current, nil
+}
+
+// Languages returns the languages pastes can be written in.
+func (p *PasteService) Languages() []language.Language {
+	return language.All()
+}
+
+// addLineage fills in the lineage of a forked paste as viewerID may see it.
+// A paste is still worth returning without it, so failures are only logged.
+func (p *PasteService) addLineage(ctx context.Context, paste *models.PasteOutput, viewerID uuid.UUID) {
+	if paste.ForkedFrom == nil {
+		return
+	}
+	lineage, err := p.pasteRepo.GetForkLineage(ctx, paste.ID, viewerID)
+	if err != nil {
+		logging.FromContext(ctx, p.logger).Warn().Err(err).Msg("failed to get fork lineage")
+		return
+	}
+	for i := range lineage {
+		lineage[i].URL = origin.PasteURL(ctx, lineage[i].Slug)
+	}
+	paste.Lineage = lineage
+}
+
+// ForkPaste creates a paste owned by the caller with the content, language
+// and title of the paste shared under slug. The caller must be allowed to
+// view the source, and forks of private pastes are private. A fork of a
+// password-protected paste is protected by the password the caller gave for
+// the source, so forking does not strip the password off its content.
+func (p *PasteService) ForkPaste(ctx context.Context, slug string, input *models.ForkInput) (*models.PasteOutput, error) {
+	ctx, span := tracing.Start(ctx, "PasteService.ForkPaste")
+	defer span.End()
+	// A source the caller may not view is reported missing, as on GET /p/{slug}
+	source, err := p.GetPasteBySlug(ctx, slug, input.Password)
+	if err != nil {
+		if errors.Is(err, ErrPasteNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrPasteNotFound, err)
+	}
+	if source.ContentLocked {
+		return nil, fmt.Errorf("%w: the paste password is required", ErrPasteNotFound)
+	}
+	title := input.Title
+	if title == "" {
+		title = source.Title
+	}
+	// Only a password that opens the source is carried over to the fork. The
+	// content of older public pastes is readable without it, so reading the
+	// source does not prove the caller knows it.
+	var password string
+	if source.PasswordHash != "" && input.Password != "" {
+		if err := p.pasteRepo.CheckPasteAccess(ctx, source, input.Password); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrPasteNotFound, err)
+		}
+		password = input.Password
+	}
+	password, isPrivate := forkProtection(source, password)
+	return p.CreatePaste(ctx, &models.PasteInput{
+		Title:      title,
+		Content:    source.Content,
+		Language:   source.Language,
+		Slug:       input.Slug,
+		Password:   password,
+		Encryption: source.Encryption,
+		IsPrivate:  isPrivate,
+		Unlisted:   source.Unlisted,
+		ForkedFrom: &source.ID,
+	})
+}
+
+// forkProtection returns the password and privacy of a fork of source, given
+// the verified source password the caller opened it with, if any. A fork of
+// a password-protected paste keeps that password, or is kept private when
+// there is none to carry over.
+func forkProtection(source *models.PasteOutput, password string) (string, bool) {
+	if source.PasswordHash == "" {
+		return "", source.IsPrivate
+	}
+	return password, source.IsPrivate || password == ""
+}
+
+// ListForks returns a page of the forks of one of the caller's pastes.
+// Private forks of other users are counted in the paste's forks but not
+// listed.
+func (p *PasteService) ListForks(ctx context.Context, pasteID uuid.UUID, limit, offset int) (*models.PaginatedPastesResponse, error) {
+	ctx, span := tracing.Start(ctx, "PasteService.ListForks")
+	defer span.End()
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to get userID from context")
+		return nil, fmt.Errorf("unable to get userID from context: %w", err)
+	}
+	limit = min(max(limit, 1), 100)
+	offset = max(offset, 0)
+
+	ownerID, err := p.pasteRepo.GetPasteOwner(ctx, pasteID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrPasteNotFound) {
+			return nil, ErrPasteNotFound
+		}
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to get paste owner")
+		return nil, fmt.Errorf("unable to get paste owner: %w", err)
+	}
+	// Other users' pastes are reported missing rather than forbidden
+	if ownerID != userID {
+		return nil, ErrPasteNotFound
+	}
+
+	forks, total, err := p.pasteRepo.ListForks(ctx, pasteID, userID, limit, offset)
+	if err != nil {
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to list forks")
+		return nil, fmt.Errorf("unable to list forks: %w", err)
+	}
+	for i := range forks {
+		forks[i].URL = origin.PasteURL(ctx, forks[i].Slug)
+	}
+	return &models.PaginatedPastesResponse{
+		Pastes:  forks,
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+		HasMore: offset+limit < total,
+	}, nil
+}
+
+// StarPaste stars the paste shared under slug for the caller, who must be
+// allowed to view it.
+func (p *PasteService) StarPaste(ctx context.Context, slug, password string) error {
+	ctx, span := tracing.Start(ctx, "PasteService.StarPaste")
+	defer span.End()
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to get userID from context")
+		return fmt.Errorf("unable to get userID from context: %w", err)
+	}
+	// A paste the caller may not view is reported missing, as on GET /p/{slug}
+	paste, err := p.pasteRepo.GetPasteAccessBySlug(ctx, slug, password)
+	if err != nil {
+		if errors.Is(err, repositories.ErrPasteNotFound) {
+			return ErrPasteNotFound
+		}
+		return fmt.Errorf("%w: %v", ErrPasteNotFound, err)
+	}
+	if !visibleTo(paste, userID) {
+		return fmt.Errorf("%w: user does not have permission to view this paste", ErrPasteNotFound)
+	}
+
+	if err := p.pasteRepo.StarPaste(ctx, userID, paste.ID); err != nil {
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to star paste")
+		return fmt.Errorf("unable to star paste: %w", err)
+	}
+	return nil
+}
+
+// UnstarPaste removes the caller's star from the paste shared under slug.
+// It succeeds whether or not the paste was starred, and needs no access to
+// the paste so stars of pastes that became private can still be removed.
+func (p *PasteService) UnstarPaste(ctx context.Context, slug string) error {
+	ctx, span := tracing.Start(ctx, "PasteService.UnstarPaste")
+	defer span.End()
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to get userID from context")
+		return fmt.Errorf("unable to get userID from context: %w", err)
+	}
+	if err := p.pasteRepo.UnstarPaste(ctx, userID, slug); err != nil {
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to unstar paste")
+		return fmt.Errorf("unable to unstar paste: %w", err)
+	}
+	return nil
+}
+
+// ListStars returns a page of the pastes the caller starred that are still
+// visible to them, narrowed and sorted by filter.
+func (p *PasteService) ListStars(ctx context.Context, filter *models.PasteFilters, limit, offset int) (*models.PaginatedPastesResponse, error) {
+	ctx, span := tracing.Start(ctx, "PasteService.ListStars")
+	defer span.End()
+	userID, err := auth.GetUserIDFromContext(ctx)
+	if err != nil {
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to get userID from context")
+		return nil, fmt.Errorf("unable to get userID from context: %w", err)
+	}
+	limit = min(max(limit, 1), 100)
+	offset = max(offset, 0)
+	if filter != nil {
+		for i, name := range filter.Languages {
+			if id, ok := language.Normalize(name); ok {
+				filter.Languages[i] = id
+			}
+		}
+	}
+
+	pastes, total, err := p.pasteRepo.ListStars(ctx, userID, filter, limit, offset)
+	if err != nil {
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to list stars")
+		return nil, fmt.Errorf("unable to list stars: %w", err)
+	}
+	for i := range pastes {
+		pastes[i].URL = origin.PasteURL(ctx, pastes[i].Slug)
+	}
+	return &models.PaginatedPastesResponse{
+		Pastes:  pastes,
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+		HasMore: offset+limit < total,
+	}, nil
+}
+
+// Explore returns a page of the public pastes listed in the explore feed,
+// newest first, in one of languages when any are given.
+func (p *PasteService) Explore(ctx context.Context, languages []string, limit, offset int) (*models.PaginatedPastesResponse, error) {
+	ctx, span := tracing.Start(ctx, "PasteService.Explore")
+	defer span.End()
+	limit = min(max(limit, 1), 100)
+	offset = max(offset, 0)
+	languages, err := normalizeLanguageFilter(languages)
+	if err != nil {
+		return nil, err
+	}
+
+	pastes, total, err := p.pasteRepo.ListPublicPastes(ctx, languages, limit, offset)
+	if err != nil {
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to list public pastes")
+		return nil, fmt.Errorf("unable to list public pastes: %w", err)
+	}
+	for i := range pastes {
+		pastes[i].URL = origin.PasteURL(ctx, pastes[i].Slug)
+	}
+	return &models.PaginatedPastesResponse{
+		Pastes:  pastes,
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+		HasMore: offset+limit < total,
+	}, nil
+}
+
+// Trending returns a page of the pastes listed in the explore feed ranked by
+// their views in window, 24h when empty, rather than their lifetime views.
+func (p *PasteService) Trending(ctx context.Context, window string, languages []string, limit, offset int) (*models.TrendingPastesResponse, error) {
+	ctx, span := tracing.Start(ctx, "PasteService.Trending")
+	defer span.End()
+	if window == "" {
+		window = "24h"
+	}
+	period, ok := trendingWindows[window]
+	if !ok {
+		return nil, ErrInvalidTrendingWindow
+	}
+	limit = min(max(limit, 1), 100)
+	offset = max(offset, 0)
+	languages, err := normalizeLanguageFilter(languages)
+	if err != nil {
+		return nil, err
+	}
+
+	pastes, err := p.pasteRepo.ListTrendingPastes(ctx, period, languages, limit, offset)
+	if err != nil {
+		logging.FromContext(ctx, p.logger).Error().Err(err).Msg("failed to list trending pastes")
+		return nil, fmt.Errorf("unable to list trending pastes: %w", err)
+	}
+	for i := range pastes {
+		pastes[i].URL = origin.PasteURL(ctx, pastes[i].Slug)
+	}
+	return &models.TrendingPastesResponse{
+		Window: window,
+		Pastes: pastes,
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
+
+// normalizeLanguageFilter maps the languages a feed is filtered by to their
+// registry IDs.
+func normalizeLanguageFilter(languages []string) ([]string, error) {
+	ids := make([]string, 0, len(languages))
+	for _, name := range languages {
+		id, ok := language.Normalize(name)
+		if !ok {
+			return nil, fmt.Errorf("%w %q, see GET /languages", ErrUnknownLanguage, name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
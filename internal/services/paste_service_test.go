@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"pastebin/internal/models"
+	"slices"
+	"testing"
+)
+
+func TestNormalizeLanguageFilter(t *testing.T) {
+	tests := []struct {
+		name      string
+		languages []string
+		want      []string
+		wantErr   bool
+	}{
+		{"no filter", nil, []string{}, false},
+		{"ids, names and aliases", []string{"go", "Python3", ".rs"}, []string{"go", "python", "rust"}, false},
+		{"unknown language", []string{"go", "cobol"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeLanguageFilter(tt.languages)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnknownLanguage) {
+					t.Fatalf("normalizeLanguageFilter() error = %v, want ErrUnknownLanguage", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("normalizeLanguageFilter() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("normalizeLanguageFilter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrendingRejectsBadInput(t *testing.T) {
+	// Bad windows and languages are refused before the repository is used
+	tests := []struct {
+		name      string
+		window    string
+		languages []string
+		wantErr   error
+	}{
+		{"unknown window", "30d", nil, ErrInvalidTrendingWindow},
+		{"window in other units", "1d", nil, ErrInvalidTrendingWindow},
+		{"default window, unknown language", "", []string{"cobol"}, ErrUnknownLanguage},
+		{"7d window, unknown language", "7d", []string{"cobol"}, ErrUnknownLanguage},
+	}
+	service := &PasteService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Trending(context.Background(), tt.window, tt.languages, 20, 0)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Trending() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestForkProtection(t *testing.T) {
+	tests := []struct {
+		name         string
+		isPrivate    bool
+		passwordHash string
+		password     string
+		wantPassword string
+		wantPrivate  bool
+	}{
+		{"public source", false, "", "", "", false},
+		{"private source", true, "", "", "", true},
+		{"password ignored without a protected source", false, "", "guess", "", false},
+		{"public protected source with its password", false, "hash", "secret", "secret", false},
+		{"public protected source without its password", false, "hash", "", "", true},
+		{"private protected source with its password", true, "hash", "secret", "secret", true},
+		{"private protected source without its password", true, "hash", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &models.PasteOutput{IsPrivate: tt.isPrivate, PasswordHash: tt.passwordHash}
+			password, isPrivate := forkProtection(source, tt.password)
+			if password != tt.wantPassword || isPrivate != tt.wantPrivate {
+				t.Fatalf("forkProtection() = %q, %v; want %q, %v", password, isPrivate, tt.wantPassword, tt.wantPrivate)
+			}
+		})
+	}
+}
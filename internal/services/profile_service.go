@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"pastebin/internal/logging"
 	"pastebin/internal/models"
 	"pastebin/internal/repositories"
 
@@ -10,21 +11,26 @@ import (
 )
 
 type ProfileService struct {
-	profileRepo *repositories.ProfileRepository
-	logger      zerolog.Logger
+	profileRepo  *repositories.ProfileRepository
+	storageQuota int64
+	logger       zerolog.Logger
 }
 
-func NewProfileService(profileRepo *repositories.ProfileRepository, logger zerolog.Logger) *ProfileService {
+func NewProfileService(profileRepo *repositories.ProfileRepository, storageQuota int64, logger zerolog.Logger) *ProfileService {
 	return &ProfileService{
-		profileRepo: profileRepo,
-		logger:      logger,
+		profileRepo:  profileRepo,
+		storageQuota: storageQuota,
+		logger:       logger,
 	}
 }
 
 func (p *ProfileService) GetProfile(ctx context.Context, userID uuid.UUID) (*models.User, error) {
 	user, err := p.profileRepo.GetProfile(ctx, userID)
 	if err != nil {
-		p.logger.Err(err).Msg("failed to get profile")
+		logging.FromContext(ctx, p.logger).Err(err).Msg("failed to get profile")
+		return nil, err
+	}
+	if err := p.attachStorage(ctx, user); err != nil {
 		return nil, err
 	}
 	return user, nil
@@ -33,8 +39,35 @@ func (p *ProfileService) GetProfile(ctx context.Context, userID uuid.UUID) (*mod
 func (p *ProfileService) UpdateProfile(ctx context.Context, userID uuid.UUID, patch *models.PatchProfile) (*models.User, error) {
 	user, err := p.profileRepo.UpdateProfile(ctx, userID, patch)
 	if err != nil {
-		p.logger.Err(err).Msg("failed to update profile")
+		logging.FromContext(ctx, p.logger).Err(err).Msg("failed to update profile")
+		return nil, err
+	}
+	if err := p.attachStorage(ctx, user); err != nil {
 		return nil, err
 	}
 	return user, nil
 }
+
+func (p *ProfileService) attachStorage(ctx context.Context, user *models.User) error {
+	if user == nil {
+		return nil
+	}
+	used, quota, err := p.profileRepo.GetStorageUsage(ctx, user.ID)
+	if err != nil {
+		logging.FromContext(ctx, p.logger).Err(err).Msg("failed to get storage usage")
+		return err
+	}
+	usage := storageUsage(used, quota, p.storageQuota)
+	user.Storage = &usage
+	return nil
+}
+
+// storageUsage resolves a user's effective quota from their override and the default.
+func storageUsage(used int64, override *int64, defaultQuota int64) models.StorageUsage {
+	usage := models.StorageUsage{UsedBytes: used, QuotaBytes: defaultQuota}
+	if override != nil {
+		usage.QuotaBytes = *override
+		usage.CustomQuota = true
+	}
+	return usage
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"pastebin/internal/logging"
+	"pastebin/internal/models"
+	"pastebin/internal/repositories"
+	"pastebin/internal/secretscan"
+	"regexp"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+var (
+	// ErrInvalidSecretRule is returned for secret rules with a bad ID, pattern or action.
+	ErrInvalidSecretRule = errors.New("invalid secret rule")
+	// ErrSecretRuleNotFound is returned when deleting a rule that was never stored.
+	ErrSecretRuleNotFound = errors.New("secret rule not found")
+)
+
+var secretRuleID = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+// SecretRuleService lets admins manage secret scanning rules and keeps the
+// scanner in step with them.
+type SecretRuleService struct {
+	ruleRepo *repositories.SecretRuleRepository
+	scanner  *secretscan.Scanner
+	refresh  time.Duration
+	logger   zerolog.Logger
+}
+
+func NewSecretRuleService(ruleRepo *repositories.SecretRuleRepository, scanner *secretscan.Scanner, refresh time.Duration, logger zerolog.Logger) *SecretRuleService {
+	return &SecretRuleService{
+		ruleRepo: ruleRepo,
+		scanner:  scanner,
+		refresh:  refresh,
+		logger:   logger,
+	}
+}
+
+// Run reloads the rules every refresh interval so changes made on other
+// replicas take effect here. It is registered as a background worker.
+func (s *SecretRuleService) Run(ctx context.Context) error {
+	if err := s.Reload(ctx); err != nil {
+		s.logger.Error().Err(err).Msg("failed to load secret rules")
+	}
+	ticker := time.NewTicker(s.refresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := s.Reload(ctx); err != nil {
+				s.logger.Error().Err(err).Msg("failed to reload secret rules")
+			}
+		}
+	}
+}
+
+// Reload gives the scanner the built-in rules with the stored ones applied.
+func (s *SecretRuleService) Reload(ctx context.Context) error {
+	rules, err := s.rules(ctx)
+	if err != nil {
+		return err
+	}
+	return s.scanner.SetRules(rules)
+}
+
+// ListRules returns every rule, built-in and stored, including disabled ones.
+func (s *SecretRuleService) ListRules(ctx context.Context) ([]secretscan.Rule, error) {
+	rules, err := s.rules(ctx)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error().Err(err).Msg("failed to list secret rules")
+		return nil, fmt.Errorf("unable to list secret rules: %w", err)
+	}
+	return rules, nil
+}
+
+// SaveRule creates the rule id, or changes it when it exists or is built in.
+func (s *SecretRuleService) SaveRule(ctx context.Context, id string, input *models.SecretRuleInput) (*secretscan.Rule, error) {
+	if !secretRuleID.MatchString(id) {
+		return nil, fmt.Errorf("%w: id must be lower-case letters, digits and dashes", ErrInvalidSecretRule)
+	}
+	rule := secretscan.Rule{
+		ID:          id,
+		Description: input.Description,
+		Pattern:     input.Pattern,
+		MinEntropy:  input.MinEntropy,
+		Enabled:     input.Enabled == nil || *input.Enabled,
+	}
+	if input.Action != "" {
+		action, err := secretscan.ParseAction(input.Action)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSecretRule, err)
+		}
+		rule.Action = action
+	}
+	// Check the rule as it will be used, with built-in defaults filled in
+	effective := secretscan.Merge(secretscan.Builtin(), []secretscan.Rule{rule})
+	for _, r := range effective {
+		if r.ID != id {
+			continue
+		}
+		if r.Pattern == "" {
+			return nil, fmt.Errorf("%w: pattern is required", ErrInvalidSecretRule)
+		}
+		if err := r.Compile(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSecretRule, err)
+		}
+		rule = r
+	}
+
+	// A built-in rule keeps following the shipped pattern and description
+	// unless they were replaced
+	stored := rule
+	if rule.Builtin {
+		stored.Pattern = input.Pattern
+		stored.Description = input.Description
+	}
+	if err := s.ruleRepo.SaveRule(ctx, &stored); err != nil {
+		logging.FromContext(ctx, s.logger).Error().Err(err).Msg("failed to save secret rule")
+		return nil, fmt.Errorf("unable to save secret rule: %w", err)
+	}
+	if err := s.Reload(ctx); err != nil {
+		logging.FromContext(ctx, s.logger).Error().Err(err).Msg("failed to reload secret rules")
+	}
+	return &rule, nil
+}
+
+// DeleteRule deletes a stored rule. Deleting a built-in rule resets it.
+func (s *SecretRuleService) DeleteRule(ctx context.Context, id string) error {
+	deleted, err := s.ruleRepo.DeleteRule(ctx, id)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error().Err(err).Msg("failed to delete secret rule")
+		return fmt.Errorf("unable to delete secret rule: %w", err)
+	}
+	if !deleted {
+		return ErrSecretRuleNotFound
+	}
+	if err := s.Reload(ctx); err != nil {
+		logging.FromContext(ctx, s.logger).Error().Err(err).Msg("failed to reload secret rules")
+	}
+	return nil
+}
+
+func (s *SecretRuleService) rules(ctx context.Context) ([]secretscan.Rule, error) {
+	stored, err := s.ruleRepo.ListRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return secretscan.Merge(secretscan.Builtin(), stored), nil
+}
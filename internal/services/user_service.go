@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"pastebin/internal/logging"
 	"pastebin/internal/repositories"
 
 	"github.com/google/uuid"
@@ -28,7 +29,7 @@ func NewUserService(userRepo *repositories.UserRepository, logger zerolog.Logger
 // If an unexpected error occurs while checking, it returns (false, error).
 func (u *UserService) CheckUserExists(ctx context.Context, userID uuid.UUID) (bool, error) {
 	if u == nil || u.userRepo == nil {
-		u.logger.Error().Msg("user service or repository is not initialized")
+		logging.FromContext(ctx, u.logger).Error().Msg("user service or repository is not initialized")
 		return false, fmt.Errorf("user service or repository is not initialized")
 	}
 
@@ -36,16 +37,16 @@ func (u *UserService) CheckUserExists(ctx context.Context, userID uuid.UUID) (bo
 	if err != nil {
 		// If repository wrapped a pgx.ErrNoRows, treat that as "not exists".
 		if errors.Is(err, pgx.ErrNoRows) {
-			u.logger.Error().Msg("user not found")
+			logging.FromContext(ctx, u.logger).Error().Msg("user not found")
 			return false, nil
 		}
 		// Propagate unexpected errors.
-		u.logger.Error().Err(err).Msg("failed to check user existence")
+		logging.FromContext(ctx, u.logger).Error().Err(err).Msg("failed to check user existence")
 		return false, fmt.Errorf("failed to check user existence: %w", err)
 	}
 	// Defensive: if repository returned a nil pointer but no error, treat as not exists.
 	if user == nil {
-		u.logger.Error().Msg("user is nil")
+		logging.FromContext(ctx, u.logger).Error().Msg("user is nil")
 		return false, nil
 	}
 	return true, nil
@@ -0,0 +1,113 @@
+// Package slug generates and validates the short names pastes are shared
+// under, as in /p/{slug}.
+package slug
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrInvalid is returned for custom slugs that are not well formed.
+	ErrInvalid = errors.New("invalid slug")
+	// ErrReserved is returned for custom slugs on the reserved list.
+	ErrReserved = errors.New("slug is reserved")
+)
+
+// DefaultReserved are names that clash with routes or would mislead readers.
+// Configured reserved words are added to these.
+var DefaultReserved = []string{
+	"admin", "api", "docs", "explore", "health", "healthz", "languages",
+	"login", "logout", "metrics", "new", "p", "paste", "pastes", "profile",
+	"raw", "readyz", "register", "settings", "static", "swagger", "trending",
+}
+
+const (
+	minCustomLength = 3
+	maxCustomLength = 64
+	// maxReservedDraws bounds retries when generated slugs hit reserved words.
+	maxReservedDraws = 10
+)
+
+// custom is the shape of a custom slug: letters, digits, '-' and '_',
+// starting and ending with a letter or digit.
+var custom = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9_-]*[A-Za-z0-9])?$`)
+
+// Generator draws random slugs from an alphabet and checks custom ones.
+type Generator struct {
+	alphabet []rune
+	length   int
+	reserved map[string]struct{}
+}
+
+// NewGenerator returns a generator of length-character slugs drawn from
+// alphabet. reserved is added to DefaultReserved.
+func NewGenerator(alphabet string, length int, reserved []string) (*Generator, error) {
+	runes := []rune(alphabet)
+	seen := make(map[rune]bool, len(runes))
+	for _, r := range runes {
+		if !strings.ContainsRune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_", r) {
+			return nil, fmt.Errorf("slug alphabet may only hold letters, digits, '-' and '_', got %q", r)
+		}
+		if seen[r] {
+			return nil, fmt.Errorf("slug alphabet repeats %q", r)
+		}
+		seen[r] = true
+	}
+	if len(runes) < 2 {
+		return nil, errors.New("slug alphabet needs at least two characters")
+	}
+	if length < 1 {
+		return nil, errors.New("slug length must be positive")
+	}
+	g := &Generator{alphabet: runes, length: length, reserved: make(map[string]struct{})}
+	for _, word := range append(DefaultReserved, reserved...) {
+		if word = strings.TrimSpace(word); word != "" {
+			g.reserved[strings.ToLower(word)] = struct{}{}
+		}
+	}
+	return g, nil
+}
+
+// Generate returns a random slug that is not reserved. Uniqueness is up to
+// the caller, which retries on conflict.
+func (g *Generator) Generate() (string, error) {
+	max := big.NewInt(int64(len(g.alphabet)))
+	for range maxReservedDraws {
+		var b strings.Builder
+		for range g.length {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return "", fmt.Errorf("generate slug: %w", err)
+			}
+			b.WriteRune(g.alphabet[n.Int64()])
+		}
+		if !g.isReserved(b.String()) {
+			return b.String(), nil
+		}
+	}
+	return "", errors.New("generate slug: only drew reserved words")
+}
+
+// Check validates a slug requested by a user.
+func (g *Generator) Check(slug string) error {
+	if len(slug) < minCustomLength || len(slug) > maxCustomLength {
+		return fmt.Errorf("%w: must be %d to %d characters", ErrInvalid, minCustomLength, maxCustomLength)
+	}
+	if !custom.MatchString(slug) {
+		return fmt.Errorf("%w: use letters, digits, '-' and '_', starting and ending with a letter or digit", ErrInvalid)
+	}
+	if g.isReserved(slug) {
+		return fmt.Errorf("%w: %q", ErrReserved, slug)
+	}
+	return nil
+}
+
+func (g *Generator) isReserved(slug string) bool {
+	_, ok := g.reserved[strings.ToLower(slug)]
+	return ok
+}
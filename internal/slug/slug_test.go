@@ -0,0 +1,107 @@
+package slug
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewGenerator(t *testing.T) {
+	tests := []struct {
+		name     string
+		alphabet string
+		length   int
+		wantErr  bool
+	}{
+		{"default alphabet", "23456789abcdefghjkmnpqrstuvwxyz", 8, false},
+		{"url-safe symbols", "ab-_", 4, false},
+		{"one character", "a", 8, true},
+		{"repeated character", "abca", 8, true},
+		{"unsafe character", "ab/", 8, true},
+		{"non-ascii letter", "abé", 8, true},
+		{"zero length", "abc", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewGenerator(tt.alphabet, tt.length, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewGenerator() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	const alphabet = "abc123"
+	g, err := NewGenerator(alphabet, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	for range 100 {
+		s, err := g.Generate()
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if len(s) != 10 || strings.Trim(s, alphabet) != "" {
+			t.Fatalf("Generate() = %q, want 10 characters from %q", s, alphabet)
+		}
+		seen[s] = true
+	}
+	if len(seen) < 95 {
+		t.Fatalf("Generate() repeated itself: %d distinct slugs in 100", len(seen))
+	}
+}
+
+func TestGenerateSkipsReserved(t *testing.T) {
+	// Every slug this generator can draw is reserved
+	g, err := NewGenerator("ab", 1, []string{"a", "B"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, err := g.Generate(); err == nil {
+		t.Fatalf("Generate() = %q, want an error", s)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	g, err := NewGenerator("abc", 8, []string{"Billing", " status "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		slug    string
+		wantErr error
+	}{
+		{"deploy-runbook", nil},
+		{"v2_notes", nil},
+		{"a1b", nil},
+		{strings.Repeat("a", 64), nil},
+		{"ab", ErrInvalid},
+		{strings.Repeat("a", 65), ErrInvalid},
+		{"-leading", ErrInvalid},
+		{"trailing_", ErrInvalid},
+		{"has space", ErrInvalid},
+		{"dot.ted", ErrInvalid},
+		{"../etc", ErrInvalid},
+		{"ünï", ErrInvalid},
+		{"admin", ErrReserved},
+		{"RAW", ErrReserved},
+		{"billing", ErrReserved},
+		{"status", ErrReserved},
+	}
+	for _, tt := range tests {
+		t.Run(tt.slug, func(t *testing.T) {
+			err := g.Check(tt.slug)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Check(%q) = %v, want nil", tt.slug, err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Check(%q) = %v, want %v", tt.slug, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	// sqlLiteral matches bind placeholders (kept) as well as string and numeric literals (masked).
+	sqlLiteral = regexp.MustCompile(`\$\d+|'(?:[^']|'')*'|\b\d+(?:\.\d+)?\b`)
+	whitespace = regexp.MustCompile(`\s+`)
+)
+
+// QueryTracer implements pgx.QueryTracer, emitting one client span per query.
+// Bind arguments are never recorded and literals are masked in the statement.
+type QueryTracer struct{}
+
+var _ pgx.QueryTracer = QueryTracer{}
+
+func (QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	stmt := SanitizeSQL(data.SQL)
+	ctx, _ = Start(ctx, spanName(stmt),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBQueryText(stmt),
+			attribute.Int("db.query.args", len(data.Args)),
+		),
+	)
+	return ctx
+}
+
+func (QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	} else {
+		span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	}
+	span.End()
+}
+
+// SanitizeSQL masks string and numeric literals and collapses whitespace so
+// statements are safe to export and group well in trace backends.
+// Placeholders such as $1 are preserved.
+func SanitizeSQL(sql string) string {
+	sql = sqlLiteral.ReplaceAllStringFunc(sql, func(m string) string {
+		if strings.HasPrefix(m, "$") {
+			return m
+		}
+		return "?"
+	})
+	return strings.TrimSpace(whitespace.ReplaceAllString(sql, " "))
+}
+
+// spanName uses the leading SQL verb, e.g. "db SELECT", like most pgx integrations.
+func spanName(stmt string) string {
+	verb, _, _ := strings.Cut(stmt, " ")
+	if verb == "" {
+		return "db"
+	}
+	return "db " + strings.ToUpper(verb)
+}
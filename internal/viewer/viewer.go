@@ -0,0 +1,210 @@
+// Package viewer renders pastes as HTML pages for browsers, with server-side
+// syntax highlighting, line numbers and linkable line ranges (#L10-L20).
+package viewer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"pastebin/internal/models"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+const (
+	lightStyle = "github"
+	darkStyle  = "github-dark"
+	// maxHighlightSize bounds the content that is tokenised for highlighting;
+	// larger pastes are shown as plain text so one paste cannot hog the CPU.
+	maxHighlightSize = 256 << 10
+)
+
+// Page is what a paste page is rendered from.
+type Page struct {
+	Paste *models.PasteOutput
+	// RawURL and DownloadURL point at /raw for the paste.
+	RawURL      string
+	DownloadURL string
+	// Rendered is the paste's Markdown rendered to sanitized HTML. When set
+	// it is shown in place of the highlighted source.
+	Rendered template.HTML
+	// ViewURL links to the other view of a Markdown paste: the rendered page
+	// from the source, or the source from the rendered page.
+	ViewURL string
+}
+
+// Viewer renders paste and error pages.
+type Viewer struct {
+	templates *template.Template
+	formatter *chromahtml.Formatter
+	css       template.CSS
+}
+
+type pageData struct {
+	Page
+	Title    string
+	Language string
+	Code     template.HTML
+	Lines    int
+	Filename string
+	CSS      template.CSS
+	Nonce    string
+}
+
+type errorData struct {
+	Status  int
+	Message string
+	CSS     template.CSS
+	Nonce   string
+}
+
+// New parses the templates and builds the stylesheet for the light and dark
+// themes.
+func New() (*Viewer, error) {
+	templates, err := template.ParseFS(templateFS, "templates/*.html")
+	if err != nil {
+		return nil, fmt.Errorf("parse templates: %w", err)
+	}
+	formatter := chromahtml.New(
+		chromahtml.WithClasses(true),
+		chromahtml.WithLineNumbers(true),
+		chromahtml.WithLinkableLineNumbers(true, "L"),
+	)
+	var css strings.Builder
+	for _, theme := range []struct{ name, style string }{{"light", lightStyle}, {"dark", darkStyle}} {
+		var buf bytes.Buffer
+		if err := formatter.WriteCSS(&buf, styles.Get(theme.style)); err != nil {
+			return nil, fmt.Errorf("write %s theme: %w", theme.name, err)
+		}
+		css.WriteString(scopeCSS(buf.String(), theme.name))
+	}
+	return &Viewer{
+		templates: templates,
+		formatter: formatter,
+		css:       template.CSS(css.String()),
+	}, nil
+}
+
+// chromaSelector matches the start of every selector chroma writes, each of
+// which follows a comment naming the token type.
+var chromaSelector = regexp.MustCompile(`\*/ \.`)
+
+// scopeCSS limits a chroma stylesheet to pages showing theme.
+func scopeCSS(css, theme string) string {
+	return chromaSelector.ReplaceAllString(css, `*/ :root[data-theme="`+theme+`"] .`)
+}
+
+// Write renders page with status to w.
+func (v *Viewer) Write(w http.ResponseWriter, status int, page Page) error {
+	data := pageData{
+		Page:     page,
+		Title:    page.Paste.Title,
+		Filename: Filename(page.Paste.Slug, page.Paste.Language),
+		CSS:      v.css,
+	}
+	// Client-encrypted content is decrypted in the browser and never highlighted
+	if page.Paste.Encryption == nil {
+		lexer := lexerFor(page.Paste.Language, len(page.Paste.Content))
+		data.Language = lexer.Config().Name
+		if lexer.Config().Name == lexers.Fallback.Config().Name {
+			data.Language = "Plain text"
+		}
+		data.Lines = strings.Count(strings.TrimSuffix(page.Paste.Content, "\n"), "\n") + 1
+		if page.Rendered == "" {
+			code, err := v.highlight(lexer, page.Paste.Content)
+			if err != nil {
+				return err
+			}
+			data.Code = code
+		}
+	}
+	return v.write(w, status, "paste.html", func(nonce string) any {
+		data.Nonce = nonce
+		return data
+	})
+}
+
+// WriteError renders an error page with status and message to w.
+func (v *Viewer) WriteError(w http.ResponseWriter, status int, message string) error {
+	return v.write(w, status, "error.html", func(nonce string) any {
+		return errorData{Status: status, Message: message, CSS: v.css, Nonce: nonce}
+	})
+}
+
+func (v *Viewer) write(w http.ResponseWriter, status int, name string, data func(nonce string) any) error {
+	nonce, err := newNonce()
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := v.templates.ExecuteTemplate(&buf, name, data(nonce)); err != nil {
+		return fmt.Errorf("render %s: %w", name, err)
+	}
+	// Paste content is escaped, but only our own scripts may run regardless
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; script-src 'nonce-"+nonce+"'; connect-src 'self'; img-src 'self' data:; base-uri 'none'; form-action 'self'; frame-ancestors 'none'")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func (v *Viewer) highlight(lexer chroma.Lexer, content string) (template.HTML, error) {
+	iterator, err := lexer.Tokenise(nil, content)
+	if err != nil {
+		return "", fmt.Errorf("tokenise paste: %w", err)
+	}
+	var buf bytes.Buffer
+	// The style only matters for inline styles; the themes come from the stylesheet
+	if err := v.formatter.Format(&buf, styles.Get(lightStyle), iterator); err != nil {
+		return "", fmt.Errorf("highlight paste: %w", err)
+	}
+	return template.HTML(buf.String()), nil
+}
+
+// lexerFor returns the lexer for a paste's language, falling back to plain
+// text for unknown languages and content too large to highlight.
+func lexerFor(language string, size int) chroma.Lexer {
+	lexer := lexers.Get(language)
+	if lexer == nil || size > maxHighlightSize {
+		lexer = lexers.Fallback
+	}
+	return chroma.Coalesce(lexer)
+}
+
+// Filename returns the name a paste is downloaded under: its slug with the
+// usual extension for its language, or .txt.
+func Filename(slug, language string) string {
+	ext := ".txt"
+	if lexer := lexers.Get(language); lexer != nil {
+		for _, pattern := range lexer.Config().Filenames {
+			if e := path.Ext(pattern); strings.HasPrefix(pattern, "*.") && e != "" && !strings.ContainsAny(e, "*?[") {
+				ext = e
+				break
+			}
+		}
+	}
+	return slug + ext
+}
+
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate nonce: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
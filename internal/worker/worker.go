@@ -0,0 +1,131 @@
+// Package worker runs named background goroutines tied to the application's
+// lifetime so they can be stopped and reported on.
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Func is the body of a worker. It must return when ctx is cancelled.
+type Func func(ctx context.Context) error
+
+// State describes where a worker is in its lifecycle.
+type State string
+
+const (
+	StatePending State = "pending"
+	StateRunning State = "running"
+	StateStopped State = "stopped"
+	StateFailed  State = "failed"
+)
+
+// Status is a point-in-time view of a worker.
+type Status struct {
+	Name      string    `json:"name"`
+	State     State     `json:"state"`
+	Error     string    `json:"error,omitempty"`
+	StartedAt time.Time `json:"started_at,omitempty"`
+}
+
+type worker struct {
+	fn     Func
+	status Status
+}
+
+// Group owns a set of workers. Register them before Start; Stop cancels them
+// and waits for all of them to return.
+type Group struct {
+	logger  zerolog.Logger
+	mu      sync.Mutex
+	workers []*worker
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func NewGroup(logger zerolog.Logger) *Group {
+	return &Group{logger: logger}
+}
+
+// Register adds a worker. It has no effect once the group has started.
+func (g *Group) Register(name string, fn Func) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.cancel != nil {
+		g.logger.Warn().Str("worker", name).Msg("worker registered after start, ignoring")
+		return
+	}
+	g.workers = append(g.workers, &worker{fn: fn, status: Status{Name: name, State: StatePending}})
+}
+
+// Start launches every registered worker with a context derived from ctx.
+func (g *Group) Start(ctx context.Context) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.cancel != nil {
+		return
+	}
+	ctx, g.cancel = context.WithCancel(ctx)
+	for _, w := range g.workers {
+		w.status.State = StateRunning
+		w.status.StartedAt = time.Now()
+		g.wg.Add(1)
+		go g.run(ctx, w)
+	}
+}
+
+func (g *Group) run(ctx context.Context, w *worker) {
+	defer g.wg.Done()
+	g.logger.Info().Str("worker", w.status.Name).Msg("worker started")
+	err := w.fn(ctx)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err != nil && !errors.Is(err, context.Canceled) {
+		w.status.State = StateFailed
+		w.status.Error = err.Error()
+		g.logger.Error().Err(err).Str("worker", w.status.Name).Msg("worker failed")
+		return
+	}
+	w.status.State = StateStopped
+	g.logger.Info().Str("worker", w.status.Name).Msg("worker stopped")
+}
+
+// Stop cancels all workers and waits for them to return or for ctx to expire.
+func (g *Group) Stop(ctx context.Context) error {
+	g.mu.Lock()
+	cancel := g.cancel
+	g.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for workers: %w", ctx.Err())
+	}
+}
+
+// Statuses returns a snapshot of every registered worker.
+func (g *Group) Statuses() []Status {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	out := make([]Status, 0, len(g.workers))
+	for _, w := range g.workers {
+		out = append(out, w.status)
+	}
+	return out
+}
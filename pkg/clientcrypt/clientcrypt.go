@@ -0,0 +1,177 @@
+// Package clientcrypt encrypts paste content on the client for zero-knowledge
+// pastes. The server only ever stores the ciphertext and its metadata; the
+// key travels in the fragment of the share URL, which browsers and HTTP
+// clients never send to the server.
+//
+// Creating a paste:
+//
+//	key, _ := clientcrypt.NewKey()
+//	content, meta, _ := clientcrypt.Encrypt(key, plaintext)
+//	// POST /paste with {"content": content, "encryption": meta}
+//	link := clientcrypt.ShareURL(paste.URL, key)
+//
+// Reading one:
+//
+//	pasteURL, key, _ := clientcrypt.SplitShareURL(link)
+//	// GET the paste, then
+//	plaintext, _ := clientcrypt.Decrypt(key, paste.Content, paste.Encryption)
+package clientcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// AlgorithmAESGCM is AES-256-GCM with a 96-bit nonce and no additional data.
+const AlgorithmAESGCM = "aes-256-gcm"
+
+// KeySize is the size in bytes of an AES-256-GCM key.
+const KeySize = 32
+
+const (
+	nonceSize = 12
+	tagSize   = 16
+)
+
+var (
+	// ErrUnsupportedAlgorithm is returned for metadata naming an unknown algorithm.
+	ErrUnsupportedAlgorithm = errors.New("unsupported encryption algorithm")
+	// ErrInvalidContent is returned for content or metadata that is not well formed.
+	ErrInvalidContent = errors.New("invalid encrypted content")
+	// ErrDecrypt is returned when the key does not match the content.
+	ErrDecrypt = errors.New("unable to decrypt content")
+)
+
+// Metadata describes how paste content was encrypted. It is sent and
+// returned as the paste's "encryption" field.
+type Metadata struct {
+	Algorithm string `json:"algorithm"`
+	// Nonce is base64 encoded.
+	Nonce string `json:"nonce"`
+}
+
+// NewKey returns a new random key.
+func NewKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("generate key: %w", err)
+	}
+	return key, nil
+}
+
+// Encrypt encrypts plaintext under key and returns the base64 content to
+// submit as the paste content together with its metadata.
+func Encrypt(key, plaintext []byte) (string, Metadata, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", Metadata{}, err
+	}
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", Metadata{}, fmt.Errorf("generate nonce: %w", err)
+	}
+	ciphertext := aead.Seal(nil, nonce, plaintext, nil)
+	return base64.StdEncoding.EncodeToString(ciphertext), Metadata{
+		Algorithm: AlgorithmAESGCM,
+		Nonce:     base64.StdEncoding.EncodeToString(nonce),
+	}, nil
+}
+
+// Decrypt reverses Encrypt.
+func Decrypt(key []byte, content string, meta Metadata) ([]byte, error) {
+	ciphertext, nonce, err := parse(content, meta)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// Validate checks that content and meta are well formed without decrypting,
+// which is all the server can do.
+func Validate(content string, meta Metadata) error {
+	_, _, err := parse(content, meta)
+	return err
+}
+
+// Ciphertext returns the raw ciphertext carried by content.
+func Ciphertext(content string) ([]byte, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: content is not base64", ErrInvalidContent)
+	}
+	return ciphertext, nil
+}
+
+func parse(content string, meta Metadata) ([]byte, []byte, error) {
+	if meta.Algorithm != AlgorithmAESGCM {
+		return nil, nil, fmt.Errorf("%w %q", ErrUnsupportedAlgorithm, meta.Algorithm)
+	}
+	nonce, err := base64.StdEncoding.DecodeString(meta.Nonce)
+	if err != nil || len(nonce) != nonceSize {
+		return nil, nil, fmt.Errorf("%w: nonce must be %d base64-encoded bytes", ErrInvalidContent, nonceSize)
+	}
+	ciphertext, err := Ciphertext(content)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(ciphertext) < tagSize {
+		return nil, nil, fmt.Errorf("%w: content is too short", ErrInvalidContent)
+	}
+	return ciphertext, nonce, nil
+}
+
+// KeyToFragment encodes key for use as a URL fragment.
+func KeyToFragment(key []byte) string {
+	return base64.RawURLEncoding.EncodeToString(key)
+}
+
+// KeyFromFragment decodes a key encoded by KeyToFragment.
+func KeyFromFragment(fragment string) ([]byte, error) {
+	key, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(fragment, "#"))
+	if err != nil || len(key) != KeySize {
+		return nil, errors.New("fragment does not hold a valid key")
+	}
+	return key, nil
+}
+
+// ShareURL returns pasteURL with key in its fragment.
+func ShareURL(pasteURL string, key []byte) string {
+	return pasteURL + "#" + KeyToFragment(key)
+}
+
+// SplitShareURL splits a URL built by ShareURL into the paste URL and key.
+func SplitShareURL(shareURL string) (string, []byte, error) {
+	pasteURL, fragment, ok := strings.Cut(shareURL, "#")
+	if !ok {
+		return "", nil, errors.New("share URL has no key fragment")
+	}
+	key, err := KeyFromFragment(fragment)
+	if err != nil {
+		return "", nil, err
+	}
+	return pasteURL, key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes", KeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	return cipher.NewGCMWithNonceSize(block, nonceSize)
+}
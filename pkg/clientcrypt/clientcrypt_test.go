@@ -0,0 +1,115 @@
+package clientcrypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func mustKey(t *testing.T) []byte {
+	t.Helper()
+	key, err := NewKey()
+	if err != nil {
+		t.Fatalf("NewKey() error = %v", err)
+	}
+	return key
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	key := mustKey(t)
+	for _, plaintext := range [][]byte{[]byte("ssh-ed25519 AAAA..."), {}, bytes.Repeat([]byte("log line\n"), 1000)} {
+		content, meta, err := Encrypt(key, plaintext)
+		if err != nil {
+			t.Fatalf("Encrypt() error = %v", err)
+		}
+		if meta.Algorithm != AlgorithmAESGCM {
+			t.Fatalf("Algorithm = %q, want %q", meta.Algorithm, AlgorithmAESGCM)
+		}
+		if err := Validate(content, meta); err != nil {
+			t.Fatalf("Validate() error = %v", err)
+		}
+		got, err := Decrypt(key, content, meta)
+		if err != nil {
+			t.Fatalf("Decrypt() error = %v", err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Fatal("Decrypt() did not return the encrypted content")
+		}
+	}
+}
+
+func TestDecryptRejects(t *testing.T) {
+	key := mustKey(t)
+	content, meta, err := Encrypt(key, []byte("zero-knowledge paste"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(content)
+	raw[0] ^= 1
+	tampered := base64.StdEncoding.EncodeToString(raw)
+	otherNonce := meta
+	otherNonce.Nonce = base64.StdEncoding.EncodeToString(make([]byte, nonceSize))
+
+	tests := []struct {
+		name    string
+		key     []byte
+		content string
+		meta    Metadata
+		wantErr error
+	}{
+		{"wrong key", mustKey(t), content, meta, ErrDecrypt},
+		{"tampered content", key, tampered, meta, ErrDecrypt},
+		{"other nonce", key, content, otherNonce, ErrDecrypt},
+		{"unknown algorithm", key, content, Metadata{Algorithm: "chacha20-poly1305", Nonce: meta.Nonce}, ErrUnsupportedAlgorithm},
+		{"short nonce", key, content, Metadata{Algorithm: AlgorithmAESGCM, Nonce: base64.StdEncoding.EncodeToString([]byte("short"))}, ErrInvalidContent},
+		{"content not base64", key, "not base64!", meta, ErrInvalidContent},
+		{"content shorter than a tag", key, base64.StdEncoding.EncodeToString([]byte("short")), meta, ErrInvalidContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decrypt(tt.key, tt.content, tt.meta); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Decrypt() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	if _, err := Decrypt(key[:16], content, meta); err == nil {
+		t.Fatal("Decrypt() with a short key succeeded")
+	}
+}
+
+func TestShareURL(t *testing.T) {
+	key := mustKey(t)
+	link := ShareURL("https://paste.example.com/p/abc123", key)
+	pasteURL, got, err := SplitShareURL(link)
+	if err != nil {
+		t.Fatalf("SplitShareURL() error = %v", err)
+	}
+	if pasteURL != "https://paste.example.com/p/abc123" || !bytes.Equal(got, key) {
+		t.Fatalf("SplitShareURL() = %q, %x; want the paste URL and key back", pasteURL, got)
+	}
+
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"no fragment", "https://paste.example.com/p/abc123"},
+		{"not base64", "https://paste.example.com/p/abc123#not*base64"},
+		{"short key", "https://paste.example.com/p/abc123#" + KeyToFragment(key[:16])},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := SplitShareURL(tt.url); err == nil {
+				t.Fatal("SplitShareURL() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestKeyFromFragmentAcceptsHash(t *testing.T) {
+	key := mustKey(t)
+	got, err := KeyFromFragment("#" + KeyToFragment(key))
+	if err != nil || !bytes.Equal(got, key) {
+		t.Fatalf("KeyFromFragment() = %x, %v", got, err)
+	}
+}